@@ -6,6 +6,7 @@ import (
 
 	"bilibililivetools/gover/backend/httpapi"
 	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/store"
 )
 
 type accountModule struct {
@@ -31,6 +32,10 @@ func (m *accountModule) Routes() []router.Route {
 		{Method: http.MethodPost, Pattern: "/cookie", Summary: "Set raw cookie", Handler: m.setCookie},
 		{Method: http.MethodGet, Pattern: "/cookie/need-refresh", Summary: "Check whether cookie needs refresh", Handler: m.needRefresh},
 		{Method: http.MethodPost, Pattern: "/cookie/refresh", Summary: "Refresh cookie with refresh_token", Handler: m.refreshCookie},
+		{Method: http.MethodGet, Pattern: "/cookie/health", Summary: "Get background cookie check status and recent checks", Handler: m.cookieHealth},
+		{Method: http.MethodGet, Pattern: "/cookie/health/setting", Summary: "Get background cookie check setting", Handler: m.getCookieHealthSetting},
+		{Method: http.MethodPost, Pattern: "/cookie/health/setting", Summary: "Save background cookie check setting", Handler: m.saveCookieHealthSetting},
+		{Method: http.MethodPost, Pattern: "/cookie/health/check", Summary: "Check and refresh the cookie of every account now", Handler: m.checkCookieHealth},
 		{Method: http.MethodPost, Pattern: "/stream-url", Summary: "Set fallback stream URL", Handler: m.setStreamURL},
 	}
 }
@@ -58,7 +63,7 @@ func (m *accountModule) logout(w http.ResponseWriter, r *http.Request) {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
-	_ = m.deps.Stream.Stop(r.Context())
+	m.deps.Stream.StopAll(r.Context())
 	httpapi.OKMessage(w, "Success")
 }
 
@@ -103,6 +108,50 @@ func (m *accountModule) refreshCookie(w http.ResponseWriter, r *http.Request) {
 	httpapi.OKMessage(w, "Success")
 }
 
+func (m *accountModule) cookieHealth(w http.ResponseWriter, r *http.Request) {
+	history, err := m.deps.Store.ListLiveEventsByType(r.Context(), "bilibili.cookie.check", parseIntOrDefault(r.URL.Query().Get("limit"), 20))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"status":  m.deps.Credential.Status(r.Context()),
+		"history": history,
+	})
+}
+
+func (m *accountModule) getCookieHealthSetting(w http.ResponseWriter, r *http.Request) {
+	setting, err := m.deps.Credential.GetSetting(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, setting)
+}
+
+func (m *accountModule) saveCookieHealthSetting(w http.ResponseWriter, r *http.Request) {
+	var req store.CookieHealthSetting
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	updated, err := m.deps.Credential.SaveSetting(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, updated)
+}
+
+func (m *accountModule) checkCookieHealth(w http.ResponseWriter, r *http.Request) {
+	results, err := m.deps.Credential.CheckNow(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, results)
+}
+
 func (m *accountModule) setStreamURL(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		URL string `json:"url"`
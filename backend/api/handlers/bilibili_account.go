@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/store"
+)
+
+// bilibiliAccountModule manages the extra Bilibili accounts; /account keeps serving the main one.
+type bilibiliAccountModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &bilibiliAccountModule{deps: deps}
+	})
+}
+
+func (m *bilibiliAccountModule) Prefix() string {
+	return m.deps.Config.APIBase + "/accounts"
+}
+
+func (m *bilibiliAccountModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List Bilibili accounts", Handler: m.list},
+		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update a Bilibili account (isBot marks the danmaku bot)", Handler: m.save},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete Bilibili accounts not bound to a push channel", Handler: m.delete},
+		{Method: http.MethodGet, Pattern: "/{id}/status", Summary: "Get login status of an account", Handler: m.status},
+		{Method: http.MethodPost, Pattern: "/{id}/login/qrcode/start", Summary: "Start QR login of an account", Handler: m.loginByQRCode},
+		{Method: http.MethodPost, Pattern: "/{id}/logout", Summary: "Logout an account", Handler: m.logout},
+		{Method: http.MethodPost, Pattern: "/{id}/cookie", Summary: "Set raw cookie of an account", Handler: m.setCookie},
+		{Method: http.MethodPost, Pattern: "/{id}/cookie/refresh", Summary: "Refresh cookie of an account with its refresh_token", Handler: m.refreshCookie},
+	}
+}
+
+func (m *bilibiliAccountModule) list(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Store.ListBilibiliAccounts(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *bilibiliAccountModule) save(w http.ResponseWriter, r *http.Request) {
+	var req store.BilibiliAccountSaveRequest
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := m.deps.Store.SaveBilibiliAccount(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *bilibiliAccountModule) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	affected, err := m.deps.Store.DeleteBilibiliAccounts(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": affected,
+	})
+}
+
+func (m *bilibiliAccountModule) status(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := m.accountContext(w, r)
+	if !ok {
+		return
+	}
+	result, err := m.deps.Bilibili.GetLoginStatus(ctx)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, result)
+}
+
+func (m *bilibiliAccountModule) loginByQRCode(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := m.accountContext(w, r)
+	if !ok {
+		return
+	}
+	status, err := m.deps.Bilibili.RequestQRCodeLogin(ctx)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, status)
+}
+
+// logout clears the account's cookie and stops the channels that push with it.
+func (m *bilibiliAccountModule) logout(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := m.accountContext(w, r)
+	if !ok {
+		return
+	}
+	if err := m.deps.Bilibili.Logout(ctx); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	if settings, err := m.deps.Store.ListPushSettings(r.Context()); err == nil {
+		for _, setting := range settings {
+			if setting.AccountID == bilibili.AccountFromContext(ctx) {
+				_ = m.deps.Stream.Stop(r.Context(), setting.ID)
+			}
+		}
+	}
+	httpapi.OKMessage(w, "Success")
+}
+
+func (m *bilibiliAccountModule) setCookie(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := m.accountContext(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := m.deps.Bilibili.SetCookie(ctx, req.Content); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OKMessage(w, "Success")
+}
+
+func (m *bilibiliAccountModule) refreshCookie(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := m.accountContext(w, r)
+	if !ok {
+		return
+	}
+	if err := m.deps.Bilibili.RefreshCookie(ctx); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OKMessage(w, "Success")
+}
+
+// accountContext binds the request context to the account of the {id} path parameter, writing the
+// error response when there is no such account.
+func (m *bilibiliAccountModule) accountContext(w http.ResponseWriter, r *http.Request) (context.Context, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid account id", http.StatusBadRequest)
+		return nil, false
+	}
+	if _, err := m.deps.Store.GetBilibiliAccountByID(r.Context(), id); err != nil {
+		httpapi.Error(w, -1, "account not found", http.StatusOK)
+		return nil, false
+	}
+	return bilibili.WithAccount(r.Context(), id), true
+}
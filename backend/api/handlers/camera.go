@@ -33,6 +33,13 @@ func (m *cameraModule) Routes() []router.Route {
 	return []router.Route{
 		{Method: http.MethodGet, Pattern: "", Summary: "List camera sources", Handler: m.list},
 		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get camera source detail", Handler: m.detail},
+		{
+			Method:      http.MethodGet,
+			Pattern:     "/{id}/usage",
+			Summary:     "List channels, scenes and schedules using a camera source",
+			Description: "A camera in use can not be deleted; mosaic sources referencing it by cameraId pick up its edits on the next start.",
+			Handler:     m.usage,
+		},
 		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update camera source", Handler: m.save},
 		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete camera sources", Handler: m.delete},
 		{Method: http.MethodPost, Pattern: "/{id}/apply-push", Summary: "Apply camera source to push setting", Handler: m.applyPush},
@@ -73,6 +80,20 @@ func (m *cameraModule) detail(w http.ResponseWriter, r *http.Request) {
 	httpapi.OK(w, item)
 }
 
+func (m *cameraModule) usage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid camera source id", http.StatusBadRequest)
+		return
+	}
+	items, err := m.deps.Store.CameraSourceUsage(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
 func (m *cameraModule) save(w http.ResponseWriter, r *http.Request) {
 	var raw struct {
 		ID                  int64  `json:"id"`
@@ -168,63 +189,24 @@ func (m *cameraModule) applyPush(w http.ResponseWriter, r *http.Request) {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
-	setting, err := m.deps.Store.GetPushSetting(r.Context())
+	setting, err := m.deps.Store.GetPushSettingByID(r.Context(), channelIDFromRequest(r))
 	if err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
 
-	updateReq := buildPushUpdateRequest(setting)
-	switch camera.SourceType {
-	case store.CameraSourceTypeRTSP:
-		updateReq.InputType = string(store.InputTypeRTSP)
-		updateReq.RTSPURL = camera.RTSPURL
-		updateReq.MJPEGURL = ""
-		updateReq.RTMPURL = ""
-		updateReq.GBPullURL = ""
-	case store.CameraSourceTypeMJPEG:
-		updateReq.InputType = string(store.InputTypeMJPEG)
-		updateReq.MJPEGURL = camera.MJPEGURL
-		updateReq.RTSPURL = ""
-		updateReq.RTMPURL = ""
-		updateReq.GBPullURL = ""
-	case store.CameraSourceTypeONVIF:
-		updateReq.InputType = string(store.InputTypeONVIF)
-		updateReq.RTSPURL = camera.RTSPURL
-		updateReq.MJPEGURL = ""
-		updateReq.RTMPURL = ""
-		updateReq.GBPullURL = ""
-		updateReq.ONVIFEndpoint = camera.ONVIFEndpoint
-		updateReq.ONVIFUsername = camera.ONVIFUsername
-		updateReq.ONVIFPassword = camera.ONVIFPassword
-		updateReq.ONVIFProfileToken = camera.ONVIFProfileToken
-	case store.CameraSourceTypeUSB:
-		updateReq.InputType = string(store.InputTypeUSBCamera)
-		updateReq.RTSPURL = ""
-		updateReq.MJPEGURL = ""
-		updateReq.RTMPURL = ""
-		updateReq.GBPullURL = ""
-		updateReq.InputDeviceName = camera.USBDeviceName
-		updateReq.InputDeviceResolution = camera.USBDeviceResolution
-		updateReq.InputDeviceFramerate = camera.USBDeviceFramerate
-	case store.CameraSourceTypeRTMP:
-		updateReq.InputType = string(store.InputTypeRTMP)
-		updateReq.RTSPURL = ""
-		updateReq.MJPEGURL = ""
-		updateReq.RTMPURL = camera.RTMPURL
-		updateReq.GBPullURL = ""
-	case store.CameraSourceTypeGB28181:
-		updateReq.InputType = string(store.InputTypeGB28181)
-		updateReq.RTSPURL = ""
-		updateReq.MJPEGURL = ""
-		updateReq.RTMPURL = ""
-		updateReq.GBPullURL = camera.GBPullURL
-	default:
-		httpapi.Error(w, -1, "unsupported camera source type", http.StatusOK)
+	updateReq := store.NewPushSettingUpdateRequest(setting)
+	if err := store.ApplyCameraSourceToUpdateRequest(&updateReq, camera); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
 
-	updated, err := m.deps.Store.UpdatePushSetting(r.Context(), updateReq)
+	updated, err := m.deps.Store.UpdatePushSettingByID(r.Context(), setting.ID, updateReq)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	manager, err := m.deps.Stream.Channel(r.Context(), updated.ID)
 	if err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
@@ -232,7 +214,7 @@ func (m *cameraModule) applyPush(w http.ResponseWriter, r *http.Request) {
 	httpapi.OK(w, map[string]any{
 		"camera":          camera,
 		"pushSetting":     updated,
-		"restartRequired": m.deps.Stream.Status() != store.PushStatusStopped,
+		"restartRequired": manager.Status() != store.PushStatusStopped,
 	})
 }
 
@@ -381,74 +363,3 @@ func buildCameraPreviewSetting(camera *store.CameraSource) (*store.PushSetting,
 	}
 	return setting, nil
 }
-
-func buildPushUpdateRequest(item *store.PushSetting) store.PushSettingUpdateRequest {
-	req := store.PushSettingUpdateRequest{
-		Model:                 item.Model,
-		FFmpegCommand:         item.FFmpegCommand,
-		IsAutoRetry:           item.IsAutoRetry,
-		RetryInterval:         item.RetryInterval,
-		InputType:             string(item.InputType),
-		OutputResolution:      item.OutputResolution,
-		OutputQuality:         item.OutputQuality,
-		OutputBitrateKbps:     item.OutputBitrateKbps,
-		CustomOutputParams:    item.CustomOutputParams,
-		CustomVideoCodec:      item.CustomVideoCodec,
-		IsMute:                item.IsMute,
-		InputScreen:           item.InputScreen,
-		InputDeviceName:       item.InputDeviceName,
-		InputDeviceResolution: item.InputDeviceResolution,
-		InputDeviceFramerate:  item.InputDeviceFramerate,
-		InputDevicePlugins:    item.InputDevicePlugins,
-		RTSPURL:               item.RTSPURL,
-		MJPEGURL:              item.MJPEGURL,
-		RTMPURL:               item.RTMPURL,
-		GBPullURL:             item.GBPullURL,
-		ONVIFEndpoint:         item.ONVIFEndpoint,
-		ONVIFUsername:         item.ONVIFUsername,
-		ONVIFPassword:         item.ONVIFPassword,
-		ONVIFProfileToken:     item.ONVIFProfileToken,
-		MultiInputEnabled:     item.MultiInputEnabled,
-		MultiInputLayout:      item.MultiInputLayout,
-		MultiInputURLs:        item.MultiInputURLs,
-		MultiInputMeta:        item.MultiInputMeta,
-	}
-	if item.VideoMaterialID != nil {
-		req.VideoID = *item.VideoMaterialID
-	}
-	if item.AudioMaterialID != nil {
-		audioID := *item.AudioMaterialID
-		switch item.InputType {
-		case store.InputTypeDesktop:
-			if item.InputAudioSource == store.InputAudioSourceDevice {
-				req.DesktopAudioFrom = true
-				req.DesktopAudioDevice = item.InputAudioDeviceName
-			} else {
-				req.DesktopAudioID = audioID
-			}
-		case store.InputTypeUSBCamera, store.InputTypeCameraPlus:
-			if item.InputAudioSource == store.InputAudioSourceDevice {
-				req.InputDeviceAudioFrom = true
-				req.InputDeviceAudioDevice = item.InputAudioDeviceName
-			} else {
-				req.InputDeviceAudioID = audioID
-			}
-		default:
-			req.AudioID = audioID
-		}
-	} else {
-		switch item.InputType {
-		case store.InputTypeDesktop:
-			if item.InputAudioSource == store.InputAudioSourceDevice {
-				req.DesktopAudioFrom = true
-				req.DesktopAudioDevice = item.InputAudioDeviceName
-			}
-		case store.InputTypeUSBCamera, store.InputTypeCameraPlus:
-			if item.InputAudioSource == store.InputAudioSourceDevice {
-				req.InputDeviceAudioFrom = true
-				req.InputDeviceAudioDevice = item.InputAudioDeviceName
-			}
-		}
-	}
-	return req
-}
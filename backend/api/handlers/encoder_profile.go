@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/store"
+)
+
+type encoderProfileModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &encoderProfileModule{deps: deps}
+	})
+}
+
+func (m *encoderProfileModule) Prefix() string {
+	return m.deps.Config.APIBase + "/encoder-profiles"
+}
+
+func (m *encoderProfileModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List encoder profiles", Handler: m.list},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get encoder profile detail", Handler: m.detail},
+		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update encoder profile (applies on next push start)", Handler: m.save},
+		{Method: http.MethodPost, Pattern: "/validate", Summary: "Check an encoder profile against the local ffmpeg without saving", Handler: m.validate},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete encoder profiles not used by any channel", Handler: m.delete},
+	}
+}
+
+func (m *encoderProfileModule) list(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Store.ListEncoderProfiles(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *encoderProfileModule) detail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid encoder profile id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetEncoderProfileByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *encoderProfileModule) save(w http.ResponseWriter, r *http.Request) {
+	var req store.EncoderProfile
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := m.check(r.Context(), req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	saved, err := m.deps.Store.SaveEncoderProfile(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *encoderProfileModule) validate(w http.ResponseWriter, r *http.Request) {
+	var req store.EncoderProfile
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	normalized, err := m.check(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, normalized)
+}
+
+func (m *encoderProfileModule) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	affected, err := m.deps.Store.DeleteEncoderProfiles(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": affected,
+	})
+}
+
+// check normalizes the profile and makes sure the local ffmpeg build has its video encoder.
+func (m *encoderProfileModule) check(ctx context.Context, req store.EncoderProfile) (store.EncoderProfile, error) {
+	normalized, err := store.NormalizeEncoderProfile(req)
+	if err != nil {
+		return normalized, err
+	}
+	codecs, err := m.deps.FFmpeg.ListVideoCodecs(ctx)
+	if err != nil {
+		return normalized, fmt.Errorf("list ffmpeg codecs failed: %w", err)
+	}
+	for _, codec := range codecs {
+		if codec == normalized.VideoCodec {
+			return normalized, nil
+		}
+	}
+	return normalized, fmt.Errorf("video codec %q is not available in this ffmpeg build", normalized.VideoCodec)
+}
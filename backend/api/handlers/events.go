@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/service/events"
+)
+
+const eventsKeepAliveInterval = 15 * time.Second
+
+type eventsModule struct {
+	deps     *router.Dependencies
+	upgrader websocket.Upgrader
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		module := &eventsModule{deps: deps}
+		module.upgrader = websocket.Upgrader{CheckOrigin: module.checkOrigin}
+		return module
+	})
+}
+
+func (m *eventsModule) Prefix() string {
+	return m.deps.Config.APIBase + "/events"
+}
+
+func (m *eventsModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "/topics", Summary: "List event topics", Handler: m.topics},
+		{
+			Method:      http.MethodGet,
+			Pattern:     "/stream",
+			Summary:     "Stream events over SSE",
+			Description: "Query topics=push,gb28181 filters by topic prefix and channelId by push channel; Last-Event-ID (header or lastEventId query) replays missed events.",
+			Handler:     m.streamSSE,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     "/ws",
+			Summary:     "Stream events over WebSocket",
+			Description: "Same filters as /events/stream; every event is sent as one JSON text message.",
+			Handler:     m.streamWebSocket,
+		},
+	}
+}
+
+func (m *eventsModule) topics(w http.ResponseWriter, r *http.Request) {
+	httpapi.OK(w, events.Topics)
+}
+
+func (m *eventsModule) streamSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpapi.Error(w, -1, "streaming not supported", http.StatusOK)
+		return
+	}
+	lastEventID := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	afterID, _ := strconv.ParseInt(lastEventID, 10, 64)
+	sub := m.subscribe(r, afterID)
+	defer sub.Close()
+
+	// The server's write timeout would cut the stream after two minutes.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := writeEventSSE(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (m *eventsModule) streamWebSocket(w http.ResponseWriter, r *http.Request) {
+	afterID, _ := strconv.ParseInt(r.URL.Query().Get("lastEventId"), 10, 64)
+	conn, err := m.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response.
+		return
+	}
+	defer conn.Close()
+	sub := m.subscribe(r, afterID)
+	defer sub.Close()
+
+	// Clients only send control frames; reading handles them and notices the close.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(eventsKeepAliveInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (m *eventsModule) subscribe(r *http.Request, afterID int64) *events.Subscription {
+	topics := strings.Split(r.URL.Query().Get("topics"), ",")
+	return m.deps.Events.Subscribe(topics, channelIDFromRequest(r), afterID)
+}
+
+// checkOrigin accepts clients without an Origin, same-host pages and an explicitly configured CORS
+// origin. The session cookie authenticates the upgrade, so a wildcard origin is not enough here.
+func (m *eventsModule) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if allowed := strings.TrimSpace(m.deps.Config.AllowOrigin); allowed != "*" && strings.EqualFold(origin, allowed) {
+		return true
+	}
+	return strings.EqualFold(strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://"), r.Host)
+}
+
+func writeEventSSE(w io.Writer, event events.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Topic, body)
+	return err
+}
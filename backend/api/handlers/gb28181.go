@@ -375,24 +375,26 @@ func (m *gb28181Module) bindSessionCameraSource(w http.ResponseWriter, r *http.R
 		"camera":  camera,
 	}
 	if req.ApplyPush {
-		pushSetting, getErr := m.deps.Store.GetPushSetting(r.Context())
+		pushSetting, getErr := m.deps.Store.GetPushSettingByID(r.Context(), channelIDFromRequest(r))
 		if getErr != nil {
 			httpapi.Error(w, -1, getErr.Error(), http.StatusOK)
 			return
 		}
-		updateReq := buildPushUpdateRequest(pushSetting)
+		updateReq := store.NewPushSettingUpdateRequest(pushSetting)
 		updateReq.InputType = string(store.InputTypeGB28181)
 		updateReq.RTSPURL = ""
 		updateReq.MJPEGURL = ""
 		updateReq.RTMPURL = ""
 		updateReq.GBPullURL = sessionSDP.SDPPath
-		updated, updateErr := m.deps.Store.UpdatePushSetting(r.Context(), updateReq)
+		updated, updateErr := m.deps.Store.UpdatePushSettingByID(r.Context(), pushSetting.ID, updateReq)
 		if updateErr != nil {
 			httpapi.Error(w, -1, updateErr.Error(), http.StatusOK)
 			return
 		}
 		resp["pushSetting"] = updated
-		resp["restartRequired"] = m.deps.Stream.Status() != store.PushStatusStopped
+		if manager, channelErr := m.deps.Stream.Channel(r.Context(), updated.ID); channelErr == nil {
+			resp["restartRequired"] = manager.Status() != store.PushStatusStopped
+		}
 	}
 	httpapi.OK(w, resp)
 }
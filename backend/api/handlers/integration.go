@@ -887,7 +887,7 @@ func (m *integrationModule) checkBilibiliAPIAlert(w http.ResponseWriter, r *http
 	success := make([]map[string]any, 0)
 	failed := make([]map[string]any, 0)
 	for _, item := range webhooks {
-		if !item.Enabled {
+		if !item.Enabled || !item.Accepts(eventType) {
 			continue
 		}
 		taskID, callErr := m.deps.Integration.EnqueueWebhookTask(r.Context(), item, eventType, payload, 3)
@@ -1154,7 +1154,7 @@ func (m *integrationModule) notifyWebhooks(w http.ResponseWriter, r *http.Reques
 	failed := make([]map[string]any, 0)
 	enabledCount := 0
 	for _, item := range webhooks {
-		if !item.Enabled {
+		if !item.Enabled || !item.Accepts(eventType) {
 			continue
 		}
 		enabledCount++
@@ -1211,7 +1211,7 @@ func (m *integrationModule) botCommand(w http.ResponseWriter, r *http.Request) {
 	}
 	command := strings.ToLower(strings.TrimSpace(req.Command))
 	switch command {
-	case "start_live", "stop_live", "ptz", "send_danmaku", "provider_notify":
+	case "start_live", "stop_live", "ptz", "scene", "audio", "send_danmaku", "provider_notify":
 	default:
 		httpapi.Error(w, -1, "unsupported bot command", http.StatusOK)
 		return
@@ -1274,7 +1274,7 @@ func (m *integrationModule) providerInboundWebhook(w http.ResponseWriter, r *htt
 	}
 	command = strings.ToLower(strings.TrimSpace(command))
 	switch command {
-	case "start_live", "stop_live", "ptz", "send_danmaku", "provider_notify":
+	case "start_live", "stop_live", "ptz", "scene", "audio", "send_danmaku", "provider_notify":
 	default:
 		httpapi.Error(w, -1, "unsupported inbound command", http.StatusOK)
 		return
@@ -1846,6 +1846,36 @@ func parseTextCommand(text string) (string, map[string]any, error) {
 		params["action"] = action
 		params["speed"] = speed
 		return command, params, nil
+	case "scene":
+		if len(tokens) < 2 {
+			return "", nil, errors.New("scene requires an id or name")
+		}
+		target := strings.TrimSpace(strings.Join(tokens[1:], " "))
+		if sceneID, err := strconv.ParseInt(target, 10, 64); err == nil && sceneID > 0 {
+			params["sceneId"] = sceneID
+		} else {
+			params["name"] = target
+		}
+		return command, params, nil
+	case "audio":
+		// audio <input|music|device> <gain dB|mute|unmute>
+		if len(tokens) < 3 {
+			return "", nil, errors.New("audio requires a source and a gain, mute or unmute")
+		}
+		params["source"] = strings.ToLower(tokens[1])
+		switch strings.ToLower(tokens[2]) {
+		case "mute":
+			params["muted"] = true
+		case "unmute":
+			params["muted"] = false
+		default:
+			gain, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(tokens[2]), "db"), 64)
+			if err != nil {
+				return "", nil, errors.New("audio gain must be a number of dB")
+			}
+			params["gainDb"] = gain
+		}
+		return command, params, nil
 	case "send_danmaku":
 		if len(tokens) < 2 {
 			return "", nil, errors.New("send_danmaku requires message")
@@ -1906,16 +1936,24 @@ func (m *integrationModule) executeRule(ctx context.Context, rule store.DanmakuP
 		}
 		return result, nil
 	case "start_live":
-		if err := m.deps.Stream.Start(ctx, false); err != nil {
+		channelID, err := m.deps.Stream.ChannelForRoom(ctx, req.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.deps.Stream.Start(ctx, channelID, false); err != nil {
 			return nil, err
 		}
-		return map[string]any{"started": true}, nil
+		return map[string]any{"started": true, "channelId": channelID}, nil
 	case "stop_live":
-		_ = m.deps.Stream.Stop(ctx)
-		if room, err := m.deps.Store.GetLiveSetting(ctx); err == nil && room.RoomID > 0 {
-			_ = m.deps.Bilibili.StopLive(ctx, room.RoomID)
+		channelID, err := m.deps.Stream.ChannelForRoom(ctx, req.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		_ = m.deps.Stream.Stop(ctx, channelID)
+		if roomID, err := m.deps.Stream.RoomID(ctx, channelID); err == nil && roomID > 0 {
+			_ = m.deps.Bilibili.StopLive(m.deps.Stream.AccountContext(ctx, channelID), roomID)
 		}
-		return map[string]any{"stopped": true}, nil
+		return map[string]any{"stopped": true, "channelId": channelID}, nil
 	case "webhook":
 		payload := map[string]any{
 			"eventType": "danmaku.rule.webhook",
@@ -34,6 +34,10 @@ func (m *liveDataModule) Routes() []router.Route {
 		{Method: http.MethodGet, Pattern: "/events", Summary: "List live events", Handler: m.listEvents},
 		{Method: http.MethodPost, Pattern: "/danmaku", Summary: "Insert danmaku record", Handler: m.insertDanmaku},
 		{Method: http.MethodGet, Pattern: "/danmaku", Summary: "List danmaku records", Handler: m.listDanmaku},
+		{Method: http.MethodGet, Pattern: "/gifts", Summary: "List gifts received", Handler: m.listGifts},
+		{Method: http.MethodGet, Pattern: "/super-chats", Summary: "List super chats", Handler: m.listSuperChats},
+		{Method: http.MethodGet, Pattern: "/guards", Summary: "List guard purchases", Handler: m.listGuardBuys},
+		{Method: http.MethodGet, Pattern: "/interactions", Summary: "List enters, follows, shares and likes (kind filters)", Handler: m.listInteractions},
 		{Method: http.MethodGet, Pattern: "/stats", Summary: "Basic live statistics", Handler: m.stats},
 		{Method: http.MethodGet, Pattern: "/stats/advanced", Summary: "Advanced live statistics", Handler: m.advancedStats},
 		{Method: http.MethodGet, Pattern: "/stats/advanced/export", Summary: "Export advanced statistics to csv/json", Handler: m.exportAdvancedStats},
@@ -87,6 +91,57 @@ func (m *liveDataModule) listDanmaku(w http.ResponseWriter, r *http.Request) {
 	httpapi.OK(w, items)
 }
 
+func (m *liveDataModule) listGifts(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Integration.ListLiveGifts(r.Context(), roomIDFromQuery(r), sinceFromQuery(r), parseIntOrDefault(r.URL.Query().Get("limit"), 100))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *liveDataModule) listSuperChats(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Integration.ListLiveSuperChats(r.Context(), roomIDFromQuery(r), sinceFromQuery(r), parseIntOrDefault(r.URL.Query().Get("limit"), 100))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *liveDataModule) listGuardBuys(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Integration.ListLiveGuardBuys(r.Context(), roomIDFromQuery(r), sinceFromQuery(r), parseIntOrDefault(r.URL.Query().Get("limit"), 100))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *liveDataModule) listInteractions(w http.ResponseWriter, r *http.Request) {
+	kind := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("kind")))
+	items, err := m.deps.Integration.ListLiveInteractions(r.Context(), roomIDFromQuery(r), kind, sinceFromQuery(r), parseIntOrDefault(r.URL.Query().Get("limit"), 100))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func roomIDFromQuery(r *http.Request) int64 {
+	roomID, _ := strconv.ParseInt(strings.TrimSpace(r.URL.Query().Get("roomId")), 10, 64)
+	return roomID
+}
+
+// sinceFromQuery reads ?hours= as a cutoff, zero (no cutoff) when it is missing.
+func sinceFromQuery(r *http.Request) time.Time {
+	hours := parseIntOrDefault(r.URL.Query().Get("hours"), 0)
+	if hours <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-time.Duration(hours) * time.Hour)
+}
+
 func (m *liveDataModule) stats(w http.ResponseWriter, r *http.Request) {
 	hours := parseIntOrDefault(r.URL.Query().Get("hours"), 24)
 	if hours <= 0 {
@@ -210,6 +265,25 @@ func buildAdvancedStatsCSV(result map[string]any) ([]byte, error) {
 	if err := writeCSVRows(writer, writeTitle, "deadLetter", []string{"id", "taskType", "status", "attempt", "maxAttempts", "lastError", "updatedAt"}, toRows(result["deadLetter"])); err != nil {
 		return nil, err
 	}
+	revenueColumns := []string{"giftGold", "superChatGold", "guardGold", "totalGold", "battery", "silverCoin", "giftCount", "superChatCount", "guardCount"}
+	if err := writeCSVRows(writer, writeTitle, "revenueDaily", append([]string{"day"}, revenueColumns...), revenueRows(result["revenueDaily"])); err != nil {
+		return nil, err
+	}
+	if err := writeCSVRows(writer, writeTitle, "revenueBySession", append([]string{"sessionId", "channelId", "roomId", "startedAt", "endedAt"}, revenueColumns...), revenueRows(result["revenueBySession"])); err != nil {
+		return nil, err
+	}
+	if err := writeCSVRows(writer, writeTitle, "topSupporters", []string{"uid", "uname", "giftGold", "superChatGold", "guardGold", "totalGold", "battery"}, revenueRows(result["topSupporters"])); err != nil {
+		return nil, err
+	}
+	if err := writeCSVRows(writer, writeTitle, "giftBreakdown", []string{"giftId", "giftName", "coinType", "times", "num", "totalCoin"}, revenueRows(result["giftBreakdown"])); err != nil {
+		return nil, err
+	}
+	if err := writeCSVRows(writer, writeTitle, "superChats", []string{"id", "roomId", "uid", "uname", "price", "message", "durationSec", "createdAt"}, revenueRows(result["superChats"])); err != nil {
+		return nil, err
+	}
+	if err := writeCSVRows(writer, writeTitle, "guardHistory", []string{"id", "roomId", "uid", "uname", "guardLevel", "giftName", "num", "price", "createdAt"}, revenueRows(result["guardHistory"])); err != nil {
+		return nil, err
+	}
 
 	if sessionStats := toMap(result["sessionStats"]); len(sessionStats) > 0 {
 		if err := writeTitle("sessionStats"); err != nil {
@@ -224,6 +298,19 @@ func buildAdvancedStatsCSV(result map[string]any) ([]byte, error) {
 			}
 		}
 	}
+	if revenue := revenueMap(result["revenue"]); len(revenue) > 0 {
+		if err := writeTitle("revenue"); err != nil {
+			return nil, err
+		}
+		if err := writer.Write([]string{"key", "value"}); err != nil {
+			return nil, err
+		}
+		for key, value := range revenue {
+			if err := writer.Write([]string{key, fmt.Sprintf("%v", value)}); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if queueSummary := toMap(result["queueSummary"]); len(queueSummary) > 0 {
 		if err := writeTitle("queueSummary"); err != nil {
 			return nil, err
@@ -267,10 +354,11 @@ func writeCSVRows(writer *csv.Writer, writeTitle func(string) error, section str
 func parseAdvancedExportFields(raw string) map[string]bool {
 	result := map[string]bool{}
 	presets := map[string][]string{
-		"all":    {"all"},
-		"basic":  {"totals", "hourlyEvents", "hourlyDanmaku", "eventTypeTop"},
-		"ops":    {"totals", "hourlyEvents", "hourlyDanmaku", "eventTypeTop", "keywordStats", "sessionStats", "queueSummary", "consumerState"},
-		"alerts": {"totals", "alertTrend", "eventTypeTop", "deadLetter"},
+		"all":     {"all"},
+		"basic":   {"totals", "hourlyEvents", "hourlyDanmaku", "eventTypeTop"},
+		"ops":     {"totals", "hourlyEvents", "hourlyDanmaku", "eventTypeTop", "keywordStats", "sessionStats", "queueSummary", "consumerState"},
+		"alerts":  {"totals", "alertTrend", "eventTypeTop", "deadLetter"},
+		"revenue": {"totals", "revenue", "revenueDaily", "revenueBySession", "topSupporters", "giftBreakdown", "superChats", "guardHistory"},
 	}
 	for _, item := range strings.Split(raw, ",") {
 		key := strings.ToLower(strings.TrimSpace(item))
@@ -308,28 +396,37 @@ func buildAdvancedStatsExportView(result map[string]any, fields map[string]bool,
 	if totals, ok := result["totals"].(map[string]any); ok {
 		view["totals"] = totals
 	}
-	copyRows := func(key string) {
+	copyRows := func(key string, rowsOf func(any) []map[string]any) {
 		if !include(key) {
 			return
 		}
-		rows := toRows(result[key])
+		rows := rowsOf(result[key])
 		if len(rows) > maxRows {
 			rows = rows[:maxRows]
 		}
 		view[key] = rows
 	}
-	copyRows("hourlyEvents")
-	copyRows("hourlyDanmaku")
-	copyRows("eventTypeTop")
-	copyRows("keywordStats")
-	copyRows("alertTrend")
-	copyRows("deadLetter")
+	copyRows("hourlyEvents", toRows)
+	copyRows("hourlyDanmaku", toRows)
+	copyRows("eventTypeTop", toRows)
+	copyRows("keywordStats", toRows)
+	copyRows("alertTrend", toRows)
+	copyRows("deadLetter", toRows)
+	copyRows("revenueDaily", revenueRows)
+	copyRows("revenueBySession", revenueRows)
+	copyRows("topSupporters", revenueRows)
+	copyRows("giftBreakdown", revenueRows)
+	copyRows("superChats", revenueRows)
+	copyRows("guardHistory", revenueRows)
 
 	if include("sessionStats") {
 		if item := toMap(result["sessionStats"]); len(item) > 0 {
 			view["sessionStats"] = item
 		}
 	}
+	if include("revenue") {
+		view["revenue"] = result["revenue"]
+	}
 	if include("queueSummary") {
 		if item := toMap(result["queueSummary"]); len(item) > 0 {
 			view["queueSummary"] = item
@@ -378,3 +475,31 @@ func toMap(value any) map[string]any {
 	}
 	return result
 }
+
+// revenueRows converts the typed revenue rows to generic maps. Numbers stay json.Number so the CSV
+// export prints large amounts in full instead of as floats.
+func revenueRows(value any) []map[string]any {
+	rows := []map[string]any{}
+	if value == nil || decodeJSONNumbers(value, &rows) != nil {
+		return []map[string]any{}
+	}
+	return rows
+}
+
+func revenueMap(value any) map[string]any {
+	result := map[string]any{}
+	if value == nil || decodeJSONNumbers(value, &result) != nil {
+		return map[string]any{}
+	}
+	return result
+}
+
+func decodeJSONNumbers(value any, target any) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
@@ -28,5 +28,10 @@ func (m *logModule) Routes() []router.Route {
 }
 
 func (m *logModule) ffmpegLogs(w http.ResponseWriter, r *http.Request) {
-	httpapi.OK(w, m.deps.Stream.Logs())
+	manager, err := m.deps.Stream.Channel(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, manager.Logs())
 }
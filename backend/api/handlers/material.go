@@ -205,6 +205,8 @@ func detectFileType(ext string) store.FileType {
 		return store.FileTypeVideo
 	case ".wav", ".flac", ".ape", ".alac", ".mp3", ".aac", ".ogg":
 		return store.FileTypeMusic
+	case ".png", ".jpg", ".jpeg", ".bmp", ".gif", ".webp":
+		return store.FileTypeImage
 	default:
 		return store.FileTypeUnknown
 	}
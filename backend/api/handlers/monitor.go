@@ -32,6 +32,8 @@ func (m *monitorModule) Routes() []router.Route {
 		{Method: http.MethodPost, Pattern: "/email", Summary: "Update monitor email settings", Handler: m.updateEmail},
 		{Method: http.MethodPost, Pattern: "/email/test", Summary: "Send monitor test email", Handler: m.testEmail},
 		{Method: http.MethodGet, Pattern: "/status", Summary: "List monitor runtime logs", Handler: m.statusLogs},
+		{Method: http.MethodGet, Pattern: "/room/state", Summary: "Get room monitor runtime state", Handler: m.roomState},
+		{Method: http.MethodGet, Pattern: "/history", Summary: "List room state changes seen by the monitor", Handler: m.history},
 	}
 }
 
@@ -64,7 +66,9 @@ func (m *monitorModule) updateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if m.deps.Monitor != nil {
-		m.deps.Monitor.Infof("monitor room setting updated enabled=%v roomId=%d", req.IsEnabled, req.RoomID)
+		m.deps.Monitor.Infof("monitor room setting updated enabled=%v roomId=%d interval=%ds debounce=%d cooldown=%ds",
+			updated.IsEnabled, updated.RoomID, updated.PollIntervalSec, updated.DebounceCount, updated.CooldownSec)
+		m.deps.Monitor.PollRoomNow()
 	}
 	httpapi.OK(w, updated)
 }
@@ -159,6 +163,26 @@ func (m *monitorModule) statusLogs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (m *monitorModule) roomState(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Monitor == nil {
+		httpapi.Error(w, -1, "monitor runtime is unavailable", http.StatusOK)
+		return
+	}
+	httpapi.OK(w, m.deps.Monitor.RoomState())
+}
+
+func (m *monitorModule) history(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Store.ListMonitorStateChanges(r.Context(), roomIDFromQuery(r), parseIntOrDefault(r.URL.Query().Get("limit"), 100))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"count": len(items),
+		"items": items,
+	})
+}
+
 func isValidEmail(email string) bool {
 	email = strings.TrimSpace(email)
 	if email == "" {
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	streamsvc "bilibililivetools/gover/backend/service/stream"
+	"bilibililivetools/gover/backend/store"
+)
+
+type overlayModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &overlayModule{deps: deps}
+	})
+}
+
+func (m *overlayModule) Prefix() string {
+	return m.deps.Config.APIBase + "/overlays"
+}
+
+func (m *overlayModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List overlay layers", Handler: m.list},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get overlay layer detail", Handler: m.detail},
+		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update overlay layer (layout applies on next push start)", Handler: m.save},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete overlay layers", Handler: m.delete},
+		{Method: http.MethodPost, Pattern: "/{id}/text", Summary: "Change overlay text while streaming", Handler: m.updateText},
+		{Method: http.MethodPost, Pattern: "/live-values", Summary: "Set a live value shown by live_value overlays", Handler: m.updateLiveValue},
+		{Method: http.MethodPost, Pattern: "/danmaku/clear", Summary: "Clear the danmaku chat boxes of a channel", Handler: m.clearDanmaku},
+	}
+}
+
+func (m *overlayModule) list(w http.ResponseWriter, r *http.Request) {
+	channelID, _ := strconv.ParseInt(r.URL.Query().Get("channelId"), 10, 64)
+	items, err := m.deps.Store.ListOverlays(r.Context(), channelID)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *overlayModule) detail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid overlay id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetOverlayByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *overlayModule) save(w http.ResponseWriter, r *http.Request) {
+	var req store.OverlaySaveRequest
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := m.deps.Store.SaveOverlay(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	// A running ffmpeg already draws this layer if it existed before; keep its text current.
+	if saved.Type == store.OverlayTypeDanmaku {
+		manager, chErr := m.deps.Stream.Channel(r.Context(), saved.ChannelID)
+		if chErr != nil {
+			httpapi.Error(w, -1, chErr.Error(), http.StatusOK)
+			return
+		}
+		err = manager.RenderDanmakuOverlay(*saved)
+	} else {
+		err = streamsvc.WriteOverlayText(m.deps.Config.MediaDir, *saved, time.Now())
+	}
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *overlayModule) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	affected, err := m.deps.Store.DeleteOverlays(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": affected,
+	})
+}
+
+func (m *overlayModule) updateText(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid overlay id", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	current, err := m.deps.Store.GetOverlayByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	if current.Type == store.OverlayTypeImage {
+		httpapi.Error(w, -1, "image overlays have no text", http.StatusOK)
+		return
+	}
+	if current.Type == store.OverlayTypeDanmaku {
+		httpapi.Error(w, -1, "danmaku overlays show incoming danmaku", http.StatusOK)
+		return
+	}
+	item, err := m.deps.Store.UpdateOverlayContent(r.Context(), id, req.Content)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	if err := streamsvc.WriteOverlayText(m.deps.Config.MediaDir, *item, time.Now()); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *overlayModule) updateLiveValue(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChannelID int64  `json:"channelId"`
+		Key       string `json:"key"`
+		Value     string `json:"value"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := streamsvc.UpdateLiveValue(r.Context(), m.deps.Store, m.deps.Config.MediaDir, req.ChannelID, req.Key, req.Value)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"updated": len(items),
+	})
+}
+
+func (m *overlayModule) clearDanmaku(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChannelID int64 `json:"channelId"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	manager, err := m.deps.Stream.Channel(r.Context(), req.ChannelID)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	if err := manager.ClearDanmaku(r.Context()); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"channelId": manager.ChannelID(),
+		"cleared":   true,
+	})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/store"
+)
+
+type playlistModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &playlistModule{deps: deps}
+	})
+}
+
+func (m *playlistModule) Prefix() string {
+	return m.deps.Config.APIBase + "/playlists"
+}
+
+func (m *playlistModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List playlists", Handler: m.list},
+		{Method: http.MethodGet, Pattern: "/now-playing", Summary: "Get the playlist item a channel is playing", Handler: m.nowPlaying},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get playlist detail", Handler: m.detail},
+		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update playlist (applied at the next item while streaming)", Handler: m.save},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete playlists", Handler: m.delete},
+	}
+}
+
+func (m *playlistModule) list(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Store.ListPlaylists(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *playlistModule) detail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid playlist id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetPlaylistByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *playlistModule) save(w http.ResponseWriter, r *http.Request) {
+	var req store.PlaylistSaveRequest
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := m.deps.Store.SavePlaylist(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *playlistModule) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	affected, err := m.deps.Store.DeletePlaylists(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": affected,
+	})
+}
+
+func (m *playlistModule) nowPlaying(w http.ResponseWriter, r *http.Request) {
+	manager, err := m.deps.Stream.Channel(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"channelId":  manager.ChannelID(),
+		"status":     manager.Status(),
+		"nowPlaying": manager.NowPlaying(),
+	})
+}
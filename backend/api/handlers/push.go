@@ -3,11 +3,15 @@ package handlers
 import (
 	"context"
 	"errors"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
 	"bilibililivetools/gover/backend/httpapi"
 	"bilibililivetools/gover/backend/router"
 	streamsvc "bilibililivetools/gover/backend/service/stream"
@@ -36,17 +40,38 @@ func (m *pushModule) Routes() []router.Route {
 		{Method: http.MethodPost, Pattern: "/stop", Summary: "Stop push stream", Handler: m.stop},
 		{Method: http.MethodPost, Pattern: "/restart", Summary: "Restart push stream", Handler: m.restart},
 		{Method: http.MethodGet, Pattern: "/status", Summary: "Get push status", Handler: m.status},
+		{Method: http.MethodGet, Pattern: "/metrics", Summary: "Get ffmpeg progress metrics", Handler: m.metrics},
+		{Method: http.MethodGet, Pattern: "/metrics/summaries", Summary: "List per-run metric aggregates", Handler: m.metricSummaries},
+		{Method: http.MethodGet, Pattern: "/sessions", Summary: "List push sessions", Handler: m.listSessions},
+		{Method: http.MethodGet, Pattern: "/sessions/{id}", Summary: "Get push session detail", Handler: m.getSession},
+		{Method: http.MethodGet, Pattern: "/channels", Summary: "List push channels with status", Handler: m.listChannels},
+		{Method: http.MethodPost, Pattern: "/channels/save", Summary: "Create or update push channel", Handler: m.saveChannel},
+		{Method: http.MethodPost, Pattern: "/channels/delete", Summary: "Delete push channels", Handler: m.deleteChannels},
 		{Method: http.MethodGet, Pattern: "/preview/mjpeg", Summary: "Preview current push source as MJPEG stream", Handler: m.preview},
 		{Method: http.MethodPost, Pattern: "/preview/webrtc/offer", Summary: "Preview current push source via WebRTC (RTSP/H264)", Handler: m.previewWebRTCOffer},
 		{Method: http.MethodPost, Pattern: "/preview/webrtc/close", Summary: "Close WebRTC preview session", Handler: m.previewWebRTCClose},
+		{Method: http.MethodPost, Pattern: "/validate", Summary: "Dry-run the current or a proposed push setting and probe its inputs", Handler: m.validate},
+		{Method: http.MethodPost, Pattern: "/preview-command", Summary: "Render the ffmpeg argv of the next start without running it", Handler: m.previewCommand},
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/audio-mix",
+			Summary:     "Set gain or mute of one audio mix source",
+			Description: "source is input, music or device; a running push restarts its input to apply the change.",
+			Handler:     m.setAudioMixSource,
+		},
 		{Method: http.MethodGet, Pattern: "/devices", Summary: "List available ffmpeg devices", Handler: m.devices},
 		{Method: http.MethodGet, Pattern: "/codecs", Summary: "List available codecs", Handler: m.codecs},
 		{Method: http.MethodGet, Pattern: "/version", Summary: "Get ffmpeg version", Handler: m.version},
 	}
 }
 
+// channelIDFromRequest reads the optional channelId query parameter; 0 addresses the default channel.
+func channelIDFromRequest(r *http.Request) int64 {
+	return int64(parseIntOrDefault(r.URL.Query().Get("channelId"), 0))
+}
+
 func (m *pushModule) getSetting(w http.ResponseWriter, r *http.Request) {
-	setting, err := m.deps.Store.GetPushSetting(r.Context())
+	setting, err := m.deps.Store.GetPushSettingByID(r.Context(), channelIDFromRequest(r))
 	if err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
@@ -60,12 +85,23 @@ func (m *pushModule) updateSetting(w http.ResponseWriter, r *http.Request) {
 		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
 		return
 	}
-	updated, err := m.deps.Store.UpdatePushSetting(r.Context(), req)
+	if req.Model == store.ConfigModelAdvance {
+		if err := streamsvc.ValidateCommandTemplate(req.FFmpegCommand); err != nil {
+			httpapi.Error(w, -1, err.Error(), http.StatusOK)
+			return
+		}
+	}
+	updated, err := m.deps.Store.UpdatePushSettingByID(r.Context(), channelIDFromRequest(r), req)
 	if err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
-	if m.deps.Stream.Status() != store.PushStatusStopped {
+	manager, err := m.deps.Stream.Channel(r.Context(), updated.ID)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	if manager.Status() != store.PushStatusStopped {
 		httpapi.Error(w, 1, "setting saved, restart required", http.StatusOK)
 		return
 	}
@@ -73,7 +109,7 @@ func (m *pushModule) updateSetting(w http.ResponseWriter, r *http.Request) {
 }
 
 func (m *pushModule) start(w http.ResponseWriter, r *http.Request) {
-	if err := m.deps.Stream.Start(r.Context(), false); err != nil {
+	if err := m.deps.Stream.Start(r.Context(), channelIDFromRequest(r), false); err != nil {
 		httpapi.Error(w, -1, "start stream failed: "+err.Error(), http.StatusOK)
 		return
 	}
@@ -83,12 +119,13 @@ func (m *pushModule) start(w http.ResponseWriter, r *http.Request) {
 func (m *pushModule) stop(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
-	if err := m.deps.Stream.Stop(ctx); err != nil {
+	channelID := channelIDFromRequest(r)
+	if err := m.deps.Stream.Stop(ctx, channelID); err != nil {
 		httpapi.Error(w, -1, "stop stream failed: "+err.Error(), http.StatusOK)
 		return
 	}
-	if room, err := m.deps.Store.GetLiveSetting(r.Context()); err == nil && room.RoomID > 0 {
-		_ = m.deps.Bilibili.StopLive(r.Context(), room.RoomID)
+	if roomID, err := m.deps.Stream.RoomID(r.Context(), channelID); err == nil && roomID > 0 {
+		_ = m.deps.Bilibili.StopLive(m.deps.Stream.AccountContext(r.Context(), channelID), roomID)
 	}
 	httpapi.OKMessage(w, "Success")
 }
@@ -96,7 +133,7 @@ func (m *pushModule) stop(w http.ResponseWriter, r *http.Request) {
 func (m *pushModule) restart(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
-	if err := m.deps.Stream.Restart(ctx); err != nil {
+	if err := m.deps.Stream.Restart(ctx, channelIDFromRequest(r)); err != nil {
 		httpapi.Error(w, -1, "restart stream failed: "+err.Error(), http.StatusOK)
 		return
 	}
@@ -104,11 +141,205 @@ func (m *pushModule) restart(w http.ResponseWriter, r *http.Request) {
 }
 
 func (m *pushModule) status(w http.ResponseWriter, r *http.Request) {
-	httpapi.OK(w, store.PushStatusResponse{Status: m.deps.Stream.Status()})
+	manager, err := m.deps.Stream.Channel(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, store.PushStatusResponse{
+		ChannelID: manager.ChannelID(),
+		Status:    manager.Status(),
+		Outputs:   manager.OutputStatuses(),
+		Retry:     manager.RetryState(),
+		Failover:  manager.FailoverState(),
+		Playlist:  manager.NowPlaying(),
+	})
+}
+
+func (m *pushModule) metrics(w http.ResponseWriter, r *http.Request) {
+	manager, err := m.deps.Stream.Channel(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	current, series := manager.Metrics(parseIntOrDefault(r.URL.Query().Get("limit"), 120))
+	httpapi.OK(w, store.PushMetricsResponse{
+		ChannelID: manager.ChannelID(),
+		Status:    manager.Status(),
+		Current:   current,
+		Series:    series,
+	})
+}
+
+func (m *pushModule) metricSummaries(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Store.ListPushMetricSummaries(r.Context(), channelIDFromRequest(r), parseIntOrDefault(r.URL.Query().Get("limit"), 50))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *pushModule) listSessions(w http.ResponseWriter, r *http.Request) {
+	result, err := m.deps.Store.ListStreamSessions(r.Context(), store.StreamSessionListRequest{
+		ChannelID: channelIDFromRequest(r),
+		Status:    strings.TrimSpace(r.URL.Query().Get("status")),
+		Page:      parseIntOrDefault(r.URL.Query().Get("page"), 1),
+		Limit:     parseIntOrDefault(r.URL.Query().Get("limit"), 20),
+	})
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, result)
+}
+
+func (m *pushModule) getSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid stream session id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetStreamSessionByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *pushModule) listChannels(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Stream.List(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *pushModule) saveChannel(w http.ResponseWriter, r *http.Request) {
+	var req store.PushChannelSaveRequest
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := m.deps.Store.SavePushChannel(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *pushModule) deleteChannels(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	deleted, err := m.deps.Store.DeletePushSettings(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	m.deps.Stream.Forget(ctx, deleted)
+	httpapi.OK(w, map[string]any{"deleted": len(deleted)})
+}
+
+func (m *pushModule) validate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Setting    *store.PushSettingUpdateRequest `json:"setting"`
+		TimeoutSec int                             `json:"timeoutSec"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	manager, err := m.deps.Stream.Channel(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	var proposed *store.PushSetting
+	if req.Setting != nil {
+		if req.Setting.Model == store.ConfigModelAdvance {
+			if err := streamsvc.ValidateCommandTemplate(req.Setting.FFmpegCommand); err != nil {
+				httpapi.Error(w, -1, err.Error(), http.StatusOK)
+				return
+			}
+		}
+		proposed, err = m.deps.Store.ResolvePushSettingUpdate(r.Context(), manager.ChannelID(), *req.Setting)
+		if err != nil {
+			httpapi.Error(w, -1, err.Error(), http.StatusOK)
+			return
+		}
+	}
+	timeout := streamsvc.DefaultValidateProbeTimeout
+	if req.TimeoutSec > 0 {
+		timeout = time.Duration(min(req.TimeoutSec, 30)) * time.Second
+	}
+	report, err := manager.Validate(r.Context(), proposed, timeout)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, report)
+}
+
+func (m *pushModule) setAudioMixSource(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source string   `json:"source"`
+		GainDB *float64 `json:"gainDb"`
+		Muted  *bool    `json:"muted"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mix, err := m.deps.Stream.SetAudioMixSource(r.Context(), channelIDFromRequest(r), req.Source, req.GainDB, req.Muted)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, mix)
+}
+
+func (m *pushModule) previewCommand(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FFmpegCommand string `json:"ffmpegCommand"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.FFmpegCommand != "" {
+		if err := streamsvc.ValidateCommandTemplate(req.FFmpegCommand); err != nil {
+			httpapi.Error(w, -1, err.Error(), http.StatusOK)
+			return
+		}
+	}
+	manager, err := m.deps.Stream.Channel(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	preview, err := manager.PreviewCommand(r.Context(), req.FFmpegCommand)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, preview)
 }
 
 func (m *pushModule) preview(w http.ResponseWriter, r *http.Request) {
-	setting, err := m.deps.Store.GetPushSetting(r.Context())
+	setting, err := m.deps.Store.GetPushSettingByID(r.Context(), channelIDFromRequest(r))
+	if err == nil {
+		setting, err = m.deps.Store.ResolveMosaicSources(r.Context(), setting)
+	}
 	if err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
@@ -121,6 +352,18 @@ func (m *pushModule) preview(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if setting.InputType == store.InputTypePlaylist {
+		playlist, playlistErr := m.deps.Store.GetPlaylistByID(r.Context(), setting.PlaylistID)
+		if playlistErr != nil || len(playlist.Items) == 0 {
+			httpapi.Error(w, -1, "playlist not found or empty", http.StatusOK)
+			return
+		}
+		videoMaterial, err = m.deps.Store.GetMaterialByID(r.Context(), playlist.Items[0].MaterialID)
+		if err != nil {
+			httpapi.Error(w, -1, "playlist material not found: "+err.Error(), http.StatusOK)
+			return
+		}
+	}
 	command, args, err := streamsvc.BuildPreviewCommand(streamsvc.BuildContext{
 		Setting:       setting,
 		MediaDir:      m.deps.Config.MediaDir,
@@ -150,6 +393,9 @@ func (m *pushModule) previewWebRTCOffer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	setting, err := m.deps.Store.GetPushSetting(r.Context())
+	if err == nil {
+		setting, err = m.deps.Store.ResolveMosaicSources(r.Context(), setting)
+	}
 	if err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
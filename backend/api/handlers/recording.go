@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/store"
+)
+
+type recordingModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &recordingModule{deps: deps}
+	})
+}
+
+func (m *recordingModule) Prefix() string {
+	return m.deps.Config.APIBase + "/recordings"
+}
+
+func (m *recordingModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List recorded segments", Handler: m.list},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get recorded segment detail", Handler: m.detail},
+		{Method: http.MethodGet, Pattern: "/{id}/download", Summary: "Download recorded segment", Handler: m.download},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete recorded segments and their files", Handler: m.delete},
+	}
+}
+
+func (m *recordingModule) list(w http.ResponseWriter, r *http.Request) {
+	sessionID, _ := strconv.ParseInt(r.URL.Query().Get("sessionId"), 10, 64)
+	result, err := m.deps.Store.ListRecordings(r.Context(), store.RecordingListRequest{
+		ChannelID: channelIDFromRequest(r),
+		SessionID: sessionID,
+		Page:      parseIntOrDefault(r.URL.Query().Get("page"), 1),
+		Limit:     parseIntOrDefault(r.URL.Query().Get("limit"), 20),
+	})
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, result)
+}
+
+func (m *recordingModule) detail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid recording id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetRecordingByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *recordingModule) download(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid recording id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetRecordingByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, "recording not found", http.StatusNotFound)
+		return
+	}
+	fullPath := filepath.Join(m.deps.Config.RecordingDir, filepath.FromSlash(item.Path))
+	if _, err := os.Stat(fullPath); err != nil {
+		httpapi.Error(w, -1, "file not found", http.StatusNotFound)
+		return
+	}
+	contentType := mime.TypeByExtension("." + item.Format)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	fileName := "channel-" + strconv.FormatInt(item.ChannelID, 10) + "-" + item.FileName
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+urlEncode(fileName))
+	http.ServeFile(w, r, fullPath)
+}
+
+func (m *recordingModule) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := m.deps.Store.DeleteRecordings(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	for _, item := range items {
+		_ = os.Remove(filepath.Join(m.deps.Config.RecordingDir, filepath.FromSlash(item.Path)))
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": len(items),
+	})
+}
@@ -72,7 +72,7 @@ func (m *roomModule) updateRoom(w http.ResponseWriter, r *http.Request) {
 		httpapi.Error(w, -1, "roomId is required", http.StatusOK)
 		return
 	}
-	if err := m.deps.Bilibili.UpdateLiveRoomInfo(r.Context(), req.RoomID, req.RoomName, req.AreaID); err != nil {
+	if err := m.deps.Bilibili.UpdateLiveRoomInfo(m.deps.Stream.RoomAccountContext(r.Context(), req.RoomID), req.RoomID, req.RoomName, req.AreaID); err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
@@ -94,7 +94,7 @@ func (m *roomModule) updateAnnouncement(w http.ResponseWriter, r *http.Request)
 		httpapi.Error(w, -1, "content length must be <= 60", http.StatusOK)
 		return
 	}
-	if err := m.deps.Bilibili.UpdateRoomNews(r.Context(), req.RoomID, req.Content); err != nil {
+	if err := m.deps.Bilibili.UpdateRoomNews(m.deps.Stream.RoomAccountContext(r.Context(), req.RoomID), req.RoomID, req.Content); err != nil {
 		httpapi.Error(w, -1, err.Error(), http.StatusOK)
 		return
 	}
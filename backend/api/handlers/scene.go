@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/store"
+)
+
+type sceneModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &sceneModule{deps: deps}
+	})
+}
+
+func (m *sceneModule) Prefix() string {
+	return m.deps.Config.APIBase + "/scenes"
+}
+
+func (m *sceneModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List scenes", Handler: m.list},
+		{Method: http.MethodGet, Pattern: "/active", Summary: "Get the active scene of a channel", Handler: m.active},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get scene detail", Handler: m.detail},
+		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update scene", Handler: m.save},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete scenes", Handler: m.delete},
+		{Method: http.MethodPost, Pattern: "/{id}/activate", Summary: "Switch the channel of a scene to it (force skips the dwell time)", Handler: m.activate},
+	}
+}
+
+func (m *sceneModule) list(w http.ResponseWriter, r *http.Request) {
+	items, err := m.deps.Store.ListScenes(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *sceneModule) active(w http.ResponseWriter, r *http.Request) {
+	item, err := m.deps.Stream.ActiveScene(r.Context(), channelIDFromRequest(r))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *sceneModule) detail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid scene id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Store.GetSceneByID(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *sceneModule) save(w http.ResponseWriter, r *http.Request) {
+	var req store.Scene
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := m.deps.Store.SaveScene(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *sceneModule) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	affected, err := m.deps.Store.DeleteScenes(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": affected,
+	})
+}
+
+func (m *sceneModule) activate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid scene id", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Force bool `json:"force"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	active, err := m.deps.Stream.ActivateScene(r.Context(), id, "api", req.Force)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, active)
+}
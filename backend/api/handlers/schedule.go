@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"bilibililivetools/gover/backend/httpapi"
+	"bilibililivetools/gover/backend/router"
+	"bilibililivetools/gover/backend/store"
+)
+
+type scheduleModule struct {
+	deps *router.Dependencies
+}
+
+func init() {
+	router.Register(func(deps *router.Dependencies) router.Module {
+		return &scheduleModule{deps: deps}
+	})
+}
+
+func (m *scheduleModule) Prefix() string {
+	return m.deps.Config.APIBase + "/schedules"
+}
+
+func (m *scheduleModule) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "", Summary: "List push schedules with next run time", Handler: m.list},
+		{Method: http.MethodGet, Pattern: "/history", Summary: "List schedule run history", Handler: m.history},
+		{Method: http.MethodGet, Pattern: "/{id}", Summary: "Get push schedule detail", Handler: m.detail},
+		{Method: http.MethodPost, Pattern: "/save", Summary: "Create or update push schedule", Handler: m.save},
+		{Method: http.MethodPost, Pattern: "/delete", Summary: "Delete push schedules", Handler: m.delete},
+		{Method: http.MethodPost, Pattern: "/preview", Summary: "Preview upcoming runs of a schedule", Handler: m.preview},
+		{Method: http.MethodPost, Pattern: "/{id}/run", Summary: "Run push schedule now", Handler: m.runNow},
+	}
+}
+
+func (m *scheduleModule) list(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	items, err := m.deps.Schedule.List(r.Context())
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *scheduleModule) detail(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid schedule id", http.StatusBadRequest)
+		return
+	}
+	item, err := m.deps.Schedule.Get(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, item)
+}
+
+func (m *scheduleModule) save(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	var req store.PushScheduleSaveRequest
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := m.deps.Schedule.Save(r.Context(), req)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, saved)
+}
+
+func (m *scheduleModule) delete(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	affected, err := m.deps.Schedule.Delete(r.Context(), req.IDs)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, map[string]any{
+		"affected": affected,
+	})
+}
+
+func (m *scheduleModule) preview(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	var req struct {
+		store.PushScheduleSaveRequest
+		Count int `json:"count"`
+	}
+	if err := httpapi.DecodeJSON(r, &req); err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := m.deps.Schedule.Preview(req.PushScheduleSaveRequest, req.Count)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
+
+func (m *scheduleModule) runNow(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, -1, "invalid schedule id", http.StatusBadRequest)
+		return
+	}
+	run, err := m.deps.Schedule.RunNow(r.Context(), id)
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, run)
+}
+
+func (m *scheduleModule) history(w http.ResponseWriter, r *http.Request) {
+	if m.deps.Schedule == nil {
+		httpapi.Error(w, -1, "schedule service not available", http.StatusOK)
+		return
+	}
+	scheduleID, _ := strconv.ParseInt(r.URL.Query().Get("scheduleId"), 10, 64)
+	channelID, _ := strconv.ParseInt(r.URL.Query().Get("channelId"), 10, 64)
+	items, err := m.deps.Schedule.History(r.Context(), scheduleID, channelID, parseIntOrDefault(r.URL.Query().Get("limit"), 50))
+	if err != nil {
+		httpapi.Error(w, -1, err.Error(), http.StatusOK)
+		return
+	}
+	httpapi.OK(w, items)
+}
@@ -20,12 +20,15 @@ import (
 	"bilibililivetools/gover/backend/router"
 	authsvc "bilibililivetools/gover/backend/service/auth"
 	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/credential"
+	"bilibililivetools/gover/backend/service/events"
 	ffsvc "bilibililivetools/gover/backend/service/ffmpeg"
 	gbsvc "bilibililivetools/gover/backend/service/gb28181"
 	"bilibililivetools/gover/backend/service/integration"
 	"bilibililivetools/gover/backend/service/maintenance"
 	"bilibililivetools/gover/backend/service/monitor"
 	"bilibililivetools/gover/backend/service/onvif"
+	"bilibililivetools/gover/backend/service/schedule"
 	"bilibililivetools/gover/backend/service/stream"
 	"bilibililivetools/gover/backend/service/telemetry"
 	previewsvc "bilibililivetools/gover/backend/service/webrtcpreview"
@@ -36,11 +39,14 @@ type App struct {
 	cfg           config.Config
 	cfgManager    *config.Manager
 	store         *store.Store
-	stream        *stream.Manager
+	stream        *stream.Registry
 	server        *http.Server
 	telemetry     *telemetry.Service
 	integration   *integration.Service
 	maintenance   *maintenance.Service
+	monitor       *monitor.Service
+	schedule      *schedule.Service
+	credential    *credential.Service
 	gb28181       *gbsvc.Service
 	webrtcPreview *previewsvc.Service
 	frontendFS    fs.FS
@@ -66,16 +72,34 @@ func New(cfgManager *config.Manager, embeddedFrontend fs.FS) (*App, error) {
 		return nil, err
 	}
 
+	eventHub := events.NewHub(cfg.LogBufferSize)
 	ffmpegSvc := ffsvc.New(cfg.FFmpegPath, cfg.FFprobePath)
 	bilibiliSvc := bilibili.New(storeDB, cfg)
 	authService := authsvc.New(storeDB, 24*time.Hour)
-	maintenanceSvc := maintenance.New(storeDB)
-	monitorSvc := monitor.New(storeDB, cfg.LogBufferSize)
+	maintenanceSvc := maintenance.New(storeDB, cfg.RecordingDir)
+	monitorSvc := monitor.New(storeDB, bilibiliSvc, cfg.LogBufferSize)
+	monitorSvc.SetEvents(eventHub)
 	onvifSvc := onvif.New()
 	gbSvc := gbsvc.New(storeDB, cfg)
-	streamMgr := stream.NewManager(storeDB, ffmpegSvc, bilibiliSvc, cfg.MediaDir, cfg.LogBufferSize, cfg.EnableDebugLogs || cfg.DebugMode)
+	gbSvc.SetEvents(eventHub)
+	if closed, err := storeDB.CloseOpenStreamSessions(context.Background()); err != nil {
+		log.Printf("[stream][warn] close dangling stream sessions failed: %v", err)
+	} else if closed > 0 {
+		log.Printf("[stream] marked %d dangling stream session(s) as interrupted", closed)
+	}
+	streamMgr := stream.NewRegistry(storeDB, ffmpegSvc, bilibiliSvc, cfg.MediaDir, cfg.DataDir, cfg.RecordingDir, cfg.LogBufferSize, cfg.EnableDebugLogs || cfg.DebugMode)
 	telemetrySvc := telemetry.New(storeDB, bilibiliSvc, streamMgr.Status)
 	integrationSvc := integration.New(storeDB, streamMgr, bilibiliSvc, onvifSvc)
+	streamMgr.OnAlert(integrationSvc.NotifyPushAlert)
+	streamMgr.SetEvents(eventHub)
+	integrationSvc.SetEvents(eventHub)
+	monitorSvc.SetPushProbe(streamMgr.RoomPushing)
+	scheduleSvc := schedule.New(storeDB, streamMgr, bilibiliSvc)
+	credentialSvc := credential.New(storeDB, bilibiliSvc)
+	credentialSvc.SetEvents(eventHub)
+	credentialSvc.SetNextStart(scheduleSvc.NextStart)
+	credentialSvc.SetEmail(monitorSvc.SendNotice)
+	credentialSvc.OnAlert(integrationSvc.NotifyCookieAlert)
 	webrtcPreviewSvc := previewsvc.New(24, cfg.EnableDebugLogs || cfg.DebugMode)
 	loggerMgr, err := logging.New(cfg)
 	if err != nil {
@@ -94,9 +118,12 @@ func New(cfgManager *config.Manager, embeddedFrontend fs.FS) (*App, error) {
 		Bilibili:      bilibiliSvc,
 		Integration:   integrationSvc,
 		Maintenance:   maintenanceSvc,
+		Schedule:      scheduleSvc,
+		Credential:    credentialSvc,
 		Monitor:       monitorSvc,
 		ONVIF:         onvifSvc,
 		WebRTCPreview: webrtcPreviewSvc,
+		Events:        eventHub,
 		FrontendFS:    embeddedFrontend,
 	}
 	apiHandler, routes := router.Build(deps)
@@ -122,6 +149,9 @@ func New(cfgManager *config.Manager, embeddedFrontend fs.FS) (*App, error) {
 		telemetry:     telemetrySvc,
 		integration:   integrationSvc,
 		maintenance:   maintenanceSvc,
+		monitor:       monitorSvc,
+		schedule:      scheduleSvc,
+		credential:    credentialSvc,
 		gb28181:       gbSvc,
 		webrtcPreview: webrtcPreviewSvc,
 		frontendFS:    frontendSub,
@@ -262,9 +292,7 @@ func (a *App) Run() error {
 	startupCfg := a.cfgManager.Current()
 	if startupCfg.AutoStartPush {
 		go func() {
-			if err := a.stream.Start(context.Background(), true); err != nil {
-				log.Printf("startup stream skipped: %v", err)
-			}
+			a.stream.StartAll(context.Background())
 		}()
 	} else {
 		log.Printf("startup auto push disabled by config (autoStartPush=false)")
@@ -272,6 +300,9 @@ func (a *App) Run() error {
 	a.telemetry.Start()
 	a.integration.Start()
 	a.maintenance.Start()
+	a.monitor.Start()
+	a.schedule.Start()
+	a.credential.Start()
 	if a.gb28181 != nil && a.cfg.GB28181Enabled {
 		if err := a.gb28181.Start(context.Background()); err != nil {
 			log.Printf("startup gb28181 skipped: %v", err)
@@ -286,6 +317,9 @@ func (a *App) Shutdown(ctx context.Context) error {
 		ctx = context.Background()
 	}
 	a.cfgManager.StopWatching()
+	a.credential.Stop()
+	a.schedule.Stop()
+	a.monitor.Stop()
 	a.maintenance.Stop()
 	a.integration.Stop()
 	a.telemetry.Stop()
@@ -295,7 +329,7 @@ func (a *App) Shutdown(ctx context.Context) error {
 	if a.webrtcPreview != nil {
 		a.webrtcPreview.CloseAll()
 	}
-	_ = a.stream.Stop(ctx)
+	a.stream.StopAll(ctx)
 	shutdownErr := a.server.Shutdown(ctx)
 	closeErr := a.store.Close()
 	if a.logger != nil {
@@ -453,6 +487,60 @@ func routeExample(method string, pattern string) map[string]any {
 				"gbPullUrl":        "",
 				"isAutoRetry":      true,
 				"retryInterval":    30,
+				"stallTimeoutSec":  20,
+				"retryPolicy": map[string]any{
+					"initialDelaySec": 10,
+					"multiplier":      2,
+					"maxDelaySec":     300,
+					"maxAttempts":     0,
+					"resetWindowSec":  600,
+				},
+				"relayEnabled":     false,
+				"playlistId":       0,
+				"encoderProfileId": 0,
+				"failover": map[string]any{
+					"enabled":          true,
+					"failureThreshold": 3,
+					"backupCameraId":   2,
+					"slateMaterialId":  5,
+					"testCard":         true,
+					"probeIntervalSec": 30,
+				},
+				"recording": map[string]any{
+					"enabled":    true,
+					"format":     "mp4",
+					"segmentSec": 600,
+				},
+				"audioMix": map[string]any{
+					"enabled":          true,
+					"input":            map[string]any{"gainDb": 0, "muted": false},
+					"music":            map[string]any{"gainDb": -12, "muted": false},
+					"device":           map[string]any{"gainDb": 3, "muted": false},
+					"musicMaterialIds": []int64{7, 8},
+					"deviceName":       "hw:1,0",
+					"ducking":          true,
+					"duckThresholdDb":  -30,
+					"duckRatio":        8,
+					"targetLufs":       -16,
+				},
+				"extraOutputs": []map[string]any{
+					{
+						"name":          "backup",
+						"url":           "rtmp://127.0.0.1/live/backup",
+						"format":        "flv",
+						"enabled":       true,
+						"failurePolicy": "ignore",
+					},
+				},
+			},
+		}
+	case "POST /api/v1/push/channels/save":
+		return map[string]any{
+			"request": map[string]any{
+				"name":      "second-room",
+				"roomId":    654321,
+				"areaId":    235,
+				"roomTitle": "第二直播间",
 			},
 		}
 	case "POST /api/v1/integration/danmaku/dispatch":
@@ -465,6 +553,32 @@ func routeExample(method string, pattern string) map[string]any {
 				"source":  "bilibili.danmaku",
 			},
 		}
+	case "POST /api/v1/integration/danmaku-rules":
+		return map[string]any{
+			"request": map[string]any{
+				"eventType":    "gift",
+				"keyword":      "*",
+				"minGold":      100000,
+				"action":       "ptz",
+				"ptzDirection": "center",
+				"ptzSpeed":     3,
+				"enabled":      true,
+			},
+		}
+	case "POST /api/v1/integration/webhooks":
+		return map[string]any{
+			"request": map[string]any{
+				"name":    "revenue-bot",
+				"url":     "https://example.com/hooks/live",
+				"secret":  "",
+				"enabled": true,
+				"events":  []string{"live.gift", "live.super_chat", "live.guard", "push.alert"},
+			},
+		}
+	case "GET /api/v1/live/interactions":
+		return map[string]any{
+			"query": map[string]any{"roomId": 123456, "kind": "follow", "limit": 100},
+		}
 	case "POST /api/v1/ptz/command":
 		return map[string]any{
 			"request": map[string]any{
@@ -526,12 +640,172 @@ func routeExample(method string, pattern string) map[string]any {
 		return map[string]any{
 			"request": map[string]any{},
 		}
+	case "POST /api/v1/overlays/save":
+		return map[string]any{
+			"request": map[string]any{
+				"channelId": 0,
+				"name":      "clock",
+				"type":      "clock",
+				"enabled":   true,
+				"zIndex":    1,
+				"content":   "%Y-%m-%d %H:%M:%S",
+				"style": map[string]any{
+					"x":         "w-tw-20",
+					"y":         "20",
+					"fontSize":  28,
+					"fontColor": "white",
+					"box":       true,
+				},
+			},
+		}
+	case "POST /api/v1/overlays/danmaku/clear":
+		return map[string]any{
+			"request": map[string]any{
+				"channelId": 0,
+			},
+		}
+	case "POST /api/v1/overlays/live-values":
+		return map[string]any{
+			"request": map[string]any{
+				"channelId": 0,
+				"key":       "current_song",
+				"value":     "Song title - Artist",
+			},
+		}
+	case "POST /api/v1/playlists/save":
+		return map[string]any{
+			"request": map[string]any{
+				"name": "replay",
+				"mode": "shuffle",
+				"items": []map[string]any{
+					{"materialId": 3, "inPoint": 0, "outPoint": 0},
+					{"materialId": 4, "inPoint": 12.5, "outPoint": 1800},
+				},
+			},
+		}
+	case "GET /api/v1/events/stream", "GET /api/v1/events/ws":
+		return map[string]any{
+			"query": map[string]any{"topics": "push,gb28181", "channelId": 1, "lastEventId": 0},
+		}
+	case "POST /api/v1/push/validate":
+		return map[string]any{
+			"query": map[string]any{"channelId": 1},
+			"request": map[string]any{
+				"timeoutSec": 10,
+				"setting": map[string]any{
+					"model":         1,
+					"inputType":     "rtsp",
+					"rtspUrl":       "rtsp://192.168.1.20:554/stream1",
+					"outputQuality": 0,
+					"isMute":        false,
+				},
+			},
+		}
+	case "POST /api/v1/push/audio-mix":
+		return map[string]any{
+			"query":   map[string]any{"channelId": 1},
+			"request": map[string]any{"source": "music", "gainDb": -18},
+		}
+	case "POST /api/v1/push/preview-command":
+		return map[string]any{
+			"query": map[string]any{"channelId": 1},
+			"request": map[string]any{
+				"ffmpegCommand": `ffmpeg -re -stream_loop -1 -i {{.VideoPath}} -i {{camera 2}} -map 0:v -map 1:a -c:v libx264 -b:v {{.BitrateKbps}}k -s {{.Resolution}} -c:a aac -f flv {{.URL}}`,
+			},
+		}
+	case "POST /api/v1/encoder-profiles/save", "POST /api/v1/encoder-profiles/validate":
+		return map[string]any{
+			"request": map[string]any{
+				"name":             "1080p60 cbr",
+				"videoCodec":       "libx264",
+				"frameRate":        60,
+				"gopSec":           2,
+				"preset":           "veryfast",
+				"tune":             "zerolatency",
+				"profile":          "high",
+				"level":            "4.2",
+				"rateControl":      "cbr",
+				"bitrateKbps":      6000,
+				"crf":              23,
+				"bFrames":          0,
+				"pixelFormat":      "yuv420p",
+				"audioCodec":       "aac",
+				"audioBitrateKbps": 160,
+				"audioSampleRate":  48000,
+				"extraArgs":        "",
+			},
+		}
+	case "POST /api/v1/scenes/save":
+		return map[string]any{
+			"request": map[string]any{
+				"channelId":   1,
+				"name":        "2x2 grid",
+				"layout":      "2x2",
+				"minDwellSec": 30,
+				"slateSec":    2,
+				"sources": []map[string]any{
+					{"url": "rtsp://192.168.1.20:554/stream1", "title": "Cam 1", "primary": true, "enableAudio": true},
+					{"url": "rtsp://192.168.1.21:554/stream1", "title": "Cam 2"},
+					{"url": "rtsp://192.168.1.22:554/stream1", "title": "Cam 3"},
+					{"url": "rtsp://192.168.1.23:554/stream1", "title": "Cam 4"},
+				},
+			},
+		}
+	case "POST /api/v1/scenes/{id}/activate":
+		return map[string]any{
+			"request": map[string]any{"force": false},
+		}
+	case "POST /api/v1/schedules/save":
+		return map[string]any{
+			"request": map[string]any{
+				"name":      "weekday evening",
+				"enabled":   true,
+				"channelId": 1,
+				"action":    "start",
+				"windows": []map[string]any{
+					{"days": []int{1, 2, 3, 4, 5}, "start": "19:00", "end": "23:00"},
+				},
+				"timezone":  "Asia/Shanghai",
+				"params":    map[string]any{"title": "晚间直播"},
+				"skipDates": []string{"2026-10-01"},
+			},
+		}
+	case "POST /api/v1/schedules/preview":
+		return map[string]any{
+			"request": map[string]any{
+				"action":   "switch_camera",
+				"cronExpr": "0 8 * * mon-fri",
+				"timezone": "Asia/Shanghai",
+				"params":   map[string]any{"cameraId": 2},
+				"count":    5,
+			},
+		}
+	case "POST /api/v1/account/cookie/health/setting":
+		return map[string]any{
+			"request": map[string]any{
+				"enabled":         true,
+				"intervalMinutes": 60,
+				"leadMinutes":     120,
+				"emailAlert":      true,
+				"webhookAlert":    true,
+				"provider":        "telegram",
+				"providerParams":  map[string]any{"chatId": "123456789"},
+			},
+		}
 	case "POST /api/v1/maintenance/setting":
 		return map[string]any{
 			"request": map[string]any{
-				"enabled":       true,
-				"retentionDays": 7,
-				"autoVacuum":    true,
+				"enabled":                true,
+				"retentionDays":          7,
+				"autoVacuum":             true,
+				"recordingRetentionDays": 14,
+				"recordingMaxSizeMb":     51200,
+			},
+		}
+	case "POST /api/v1/recordings/delete":
+		return map[string]any{
+			"request": map[string]any{
+				"ids": []int64{1, 2},
 			},
 		}
 	case "POST /api/v1/maintenance/cleanup":
@@ -16,6 +16,7 @@ type Config struct {
 	DataDir                  string `json:"dataDir"`
 	DBPath                   string `json:"dbPath"`
 	MediaDir                 string `json:"mediaDir"`
+	RecordingDir             string `json:"recordingDir"`
 	FFmpegPath               string `json:"ffmpegPath"`
 	FFprobePath              string `json:"ffprobePath"`
 	LogBufferSize            int    `json:"logBufferSize"`
@@ -391,6 +392,9 @@ func defaultConfig(configFile string) Config {
 	if cfg.MediaDir == "" {
 		cfg.MediaDir = filepath.Join(cfg.DataDir, "media")
 	}
+	if cfg.RecordingDir == "" {
+		cfg.RecordingDir = filepath.Join(cfg.DataDir, "recordings")
+	}
 	cfg.ConfigFile = configFile
 	return cfg
 }
@@ -496,6 +500,11 @@ func normalizeConfig(cfg Config, configFile string) Config {
 		cfg.MediaDir = filepath.Join(cfg.DataDir, "media")
 	}
 
+	cfg.RecordingDir = absPathWithBase(cfg.RecordingDir, configDir)
+	if strings.TrimSpace(cfg.RecordingDir) == "" {
+		cfg.RecordingDir = filepath.Join(cfg.DataDir, "recordings")
+	}
+
 	cfg.FFmpegPath = absPathWithBase(cfg.FFmpegPath, configDir)
 	cfg.FFprobePath = absPathWithBase(cfg.FFprobePath, configDir)
 	return cfg
@@ -14,12 +14,15 @@ import (
 	"bilibililivetools/gover/backend/httpapi"
 	authsvc "bilibililivetools/gover/backend/service/auth"
 	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/credential"
+	"bilibililivetools/gover/backend/service/events"
 	ffsvc "bilibililivetools/gover/backend/service/ffmpeg"
 	gbsvc "bilibililivetools/gover/backend/service/gb28181"
 	"bilibililivetools/gover/backend/service/integration"
 	"bilibililivetools/gover/backend/service/maintenance"
 	"bilibililivetools/gover/backend/service/monitor"
 	"bilibililivetools/gover/backend/service/onvif"
+	"bilibililivetools/gover/backend/service/schedule"
 	"bilibililivetools/gover/backend/service/stream"
 	previewsvc "bilibililivetools/gover/backend/service/webrtcpreview"
 	"bilibililivetools/gover/backend/store"
@@ -31,14 +34,17 @@ type Dependencies struct {
 	Store         *store.Store
 	Auth          *authsvc.Service
 	FFmpeg        *ffsvc.Service
-	Stream        *stream.Manager
+	Stream        *stream.Registry
 	GB28181       *gbsvc.Service
 	Bilibili      bilibili.Service
 	Integration   *integration.Service
 	Maintenance   *maintenance.Service
+	Schedule      *schedule.Service
+	Credential    *credential.Service
 	Monitor       *monitor.Service
 	ONVIF         *onvif.Service
 	WebRTCPreview *previewsvc.Service
+	Events        *events.Hub
 	FrontendFS    fs.FS
 }
 
@@ -0,0 +1,26 @@
+package bilibili
+
+import "context"
+
+type accountContextKey struct{}
+
+// WithAccount makes the calls made with the returned context use the credentials of a stored account.
+// Account 0 is the main account, which is also what a context without an account uses.
+func WithAccount(ctx context.Context, accountID int64) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if accountID < 0 {
+		accountID = 0
+	}
+	return context.WithValue(ctx, accountContextKey{}, accountID)
+}
+
+// AccountFromContext returns the account a context was bound to with WithAccount.
+func AccountFromContext(ctx context.Context) int64 {
+	if ctx == nil {
+		return 0
+	}
+	accountID, _ := ctx.Value(accountContextKey{}).(int64)
+	return accountID
+}
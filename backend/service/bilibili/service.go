@@ -66,6 +66,7 @@ type Service interface {
 	Logout(ctx context.Context) error
 	GetStreamURL(ctx context.Context, live *store.LiveSetting) (string, error)
 	GetMyLiveRoomInfo(ctx context.Context) (*store.MyLiveRoomInfo, error)
+	GetRoomInfo(ctx context.Context, roomID int64) (*store.MyLiveRoomInfo, error)
 	GetLiveAreas(ctx context.Context) ([]store.LiveAreaItem, error)
 	UpdateLiveRoomInfo(ctx context.Context, roomID int64, title string, areaID int) error
 	UpdateRoomNews(ctx context.Context, roomID int64, content string) error
@@ -73,6 +74,7 @@ type Service interface {
 	SendDanmaku(ctx context.Context, roomID int64, message string) (map[string]any, error)
 	CookieNeedToRefresh(ctx context.Context) (bool, error)
 	RefreshCookie(ctx context.Context) error
+	CheckLogin(ctx context.Context) error
 	SetManualStreamURL(url string)
 }
 
@@ -83,7 +85,7 @@ type APIService struct {
 
 	mu              sync.RWMutex
 	manualStreamURL string
-	qrState         *qrState
+	qrStates        map[int64]*qrState
 	areas           []store.LiveAreaItem
 	areasExpireAt   time.Time
 }
@@ -115,12 +117,34 @@ type apiErrorReport struct {
 
 type bilibiliAPIError struct {
 	report apiErrorReport
+	cause  error
 }
 
 func (e *bilibiliAPIError) Error() string {
 	return e.report.Detail
 }
 
+func (e *bilibiliAPIError) Unwrap() error {
+	return e.cause
+}
+
+// apiCodeError is a response whose envelope carries a non-zero Bilibili code.
+type apiCodeError struct {
+	Code    int
+	Message string
+}
+
+func (e *apiCodeError) Error() string {
+	return fmt.Sprintf("bilibili api error code=%d message=%s", e.Code, e.Message)
+}
+
+// notLoggedInCode is what Bilibili answers for a missing or expired login.
+const notLoggedInCode = -101
+
+// ErrNotLoggedIn is returned when Bilibili says the cookie no longer belongs to a logged-in user, as
+// opposed to a request that failed.
+var ErrNotLoggedIn = errors.New("user is not logged in")
+
 func (s *APIService) recordAPIError(report apiErrorReport) {
 	report.Endpoint = strings.TrimSpace(report.Endpoint)
 	report.Method = strings.ToUpper(strings.TrimSpace(report.Method))
@@ -185,6 +209,7 @@ func New(storeDB *store.Store, cfg config.Config) *APIService {
 			Timeout: 15 * time.Second,
 		},
 		manualStreamURL: strings.TrimSpace(""),
+		qrStates:        make(map[int64]*qrState),
 	}
 }
 
@@ -206,14 +231,17 @@ func (s *APIService) SetManualStreamURL(streamURL string) {
 	s.manualStreamURL = strings.TrimSpace(streamURL)
 }
 
+// GetLoginStatus checks the account of ctx (see WithAccount) and, for a stored account, records who it
+// is logged in as.
 func (s *APIService) GetLoginStatus(ctx context.Context) (store.LoginStatus, error) {
 	status := store.LoginStatus{Status: store.AccountStatusNotLogin}
-	cookieSetting, err := s.store.GetCookieSetting(ctx)
+	accountID := AccountFromContext(ctx)
+	cookieSetting, err := s.store.GetBilibiliCookie(ctx, accountID)
 	if err != nil {
 		return status, err
 	}
 
-	if qr := s.getQRCodeStatus(); qr != nil {
+	if qr := s.getQRCodeStatus(accountID); qr != nil {
 		status.Status = store.AccountStatusLogging
 		status.QrCodeStatus = qr
 	}
@@ -241,6 +269,11 @@ func (s *APIService) GetLoginStatus(ctx context.Context) (store.LoginStatus, err
 		}
 		return status, nil
 	}
+	if accountID > 0 {
+		if err := s.store.UpdateBilibiliAccountUser(ctx, accountID, user.Mid, user.Uname, user.Face); err != nil {
+			s.logWarn("save account %d user info failed: %v", accountID, err)
+		}
+	}
 	status.Status = store.AccountStatusLogged
 	status.Message = "Logged in as " + user.Uname
 	status.RedirectURL = "/"
@@ -280,47 +313,47 @@ func (s *APIService) RequestQRCodeLogin(ctx context.Context) (*store.QrCodeStatu
 		expireAt: now.Add(180 * time.Second),
 	}
 	s.mu.Lock()
-	s.qrState = state
+	s.qrStates[AccountFromContext(ctx)] = state
 	s.mu.Unlock()
 
 	copied := state.status
 	return &copied, nil
 }
 
-func (s *APIService) getQRCodeStatus() *store.QrCodeStatus {
+func (s *APIService) getQRCodeStatus(accountID int64) *store.QrCodeStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.qrState == nil {
+	state := s.qrStates[accountID]
+	if state == nil {
 		return nil
 	}
-	if time.Now().After(s.qrState.expireAt) {
-		expired := s.qrState.status
+	if time.Now().After(state.expireAt) {
+		expired := state.status
 		expired.QrCodeEffectiveTime = 0
 		expired.Message = "二维码已失效"
-		s.qrState = nil
+		delete(s.qrStates, accountID)
 		return &expired
 	}
 
-	if !s.qrState.isCompleted && time.Since(s.qrState.lastPollAt) >= 2*time.Second {
-		s.pollQRCodeLocked(context.Background())
+	if !state.isCompleted && time.Since(state.lastPollAt) >= 2*time.Second {
+		s.pollQRCodeLocked(WithAccount(context.Background(), accountID), state)
 	}
 
-	remaining := int(time.Until(s.qrState.expireAt).Seconds())
+	remaining := int(time.Until(state.expireAt).Seconds())
 	if remaining < 0 {
 		remaining = 0
 	}
-	current := s.qrState.status
+	current := state.status
 	current.QrCodeEffectiveTime = remaining
 	return &current
 }
 
-func (s *APIService) pollQRCodeLocked(ctx context.Context) {
-	if s.qrState == nil {
-		return
-	}
-	s.qrState.lastPollAt = time.Now()
+// pollQRCodeLocked polls the login of state and stores the cookie for the account of ctx.
+func (s *APIService) pollQRCodeLocked(ctx context.Context, state *qrState) {
+	state.lastPollAt = time.Now()
+	accountID := AccountFromContext(ctx)
 
-	pollURL := qrCodePollAPI + "?qrcode_key=" + url.QueryEscape(s.qrState.status.QrCodeKey) + "&source=main_mini"
+	pollURL := qrCodePollAPI + "?qrcode_key=" + url.QueryEscape(state.status.QrCodeKey) + "&source=main_mini"
 	type qrPollData struct {
 		Code         int    `json:"code"`
 		Message      string `json:"message"`
@@ -329,35 +362,35 @@ func (s *APIService) pollQRCodeLocked(ctx context.Context) {
 	pollData, _, cookies, err := requestJSON[qrPollData](s, ctx, http.MethodGet, pollURL, nil, false)
 	if err != nil {
 		s.logWarn("poll qrcode failed: %v", err)
-		s.qrState.status.Message = "轮询二维码失败: " + err.Error()
+		state.status.Message = "轮询二维码失败: " + err.Error()
 		return
 	}
 
 	switch pollData.Code {
 	case 0:
 		if len(cookies) > 0 {
-			merged := mergeCookieWithResponse(s.readCookieString(), cookies)
-			_ = s.store.SaveCookie(context.Background(), merged, pollData.RefreshToken)
+			merged := mergeCookieWithResponse(s.readCookieString(ctx), cookies)
+			_ = s.store.SaveBilibiliCookie(ctx, accountID, merged, pollData.RefreshToken)
 		} else {
-			_ = s.store.SaveRefreshToken(context.Background(), pollData.RefreshToken)
+			_ = s.store.SaveBilibiliCookie(ctx, accountID, s.readCookieString(ctx), pollData.RefreshToken)
 		}
-		s.logInfo("qrcode login succeeded")
-		s.qrState.status.IsLogged = true
-		s.qrState.status.IsScaned = true
-		s.qrState.status.Message = "登录成功"
-		s.qrState.status.RefreshToken = pollData.RefreshToken
-		s.qrState.isCompleted = true
+		s.logInfo("qrcode login succeeded account=%d", accountID)
+		state.status.IsLogged = true
+		state.status.IsScaned = true
+		state.status.Message = "登录成功"
+		state.status.RefreshToken = pollData.RefreshToken
+		state.isCompleted = true
 	case 86090:
-		s.qrState.status.IsScaned = true
-		s.qrState.status.Message = "二维码已扫码，待确认"
+		state.status.IsScaned = true
+		state.status.Message = "二维码已扫码，待确认"
 	case 86101:
-		s.qrState.status.IsScaned = false
-		s.qrState.status.Message = "二维码未扫码"
+		state.status.IsScaned = false
+		state.status.Message = "二维码未扫码"
 	case 86038:
-		s.qrState.status.Message = "二维码已失效"
-		s.qrState.expireAt = time.Now()
+		state.status.Message = "二维码已失效"
+		state.expireAt = time.Now()
 	default:
-		s.qrState.status.Message = fmt.Sprintf("二维码状态异常: code=%d, message=%s", pollData.Code, pollData.Message)
+		state.status.Message = fmt.Sprintf("二维码状态异常: code=%d, message=%s", pollData.Code, pollData.Message)
 	}
 }
 
@@ -365,17 +398,23 @@ func (s *APIService) SetCookie(ctx context.Context, content string) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	return s.store.SaveCookieContent(ctx, strings.TrimSpace(content))
+	accountID := AccountFromContext(ctx)
+	setting, err := s.store.GetBilibiliCookie(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveBilibiliCookie(ctx, accountID, strings.TrimSpace(content), setting.RefreshToken)
 }
 
 func (s *APIService) Logout(ctx context.Context) error {
-	s.mu.Lock()
-	s.qrState = nil
-	s.mu.Unlock()
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	return s.store.SaveCookie(ctx, "", "")
+	accountID := AccountFromContext(ctx)
+	s.mu.Lock()
+	delete(s.qrStates, accountID)
+	s.mu.Unlock()
+	return s.store.SaveBilibiliCookie(ctx, accountID, "", "")
 }
 
 func (s *APIService) GetStreamURL(ctx context.Context, live *store.LiveSetting) (string, error) {
@@ -518,7 +557,7 @@ func (s *APIService) UpdateRoomNews(ctx context.Context, roomID int64, content s
 	if err != nil {
 		return err
 	}
-	cookieMap := parseCookieString(s.readCookieString())
+	cookieMap := parseCookieString(s.readCookieString(ctx))
 	uid := cookieMap["DedeUserID"]
 	if uid == "" {
 		if user, userErr := s.getUserInfo(ctx); userErr == nil {
@@ -559,6 +598,8 @@ func (s *APIService) StopLive(ctx context.Context, roomID int64) error {
 	return err
 }
 
+// SendDanmaku sends message as the account of ctx. Without a room it goes to the live setting's room
+// or, for the main account, to the account's own room.
 func (s *APIService) SendDanmaku(ctx context.Context, roomID int64, message string) (map[string]any, error) {
 	message = strings.TrimSpace(message)
 	if message == "" {
@@ -569,7 +610,7 @@ func (s *APIService) SendDanmaku(ctx context.Context, roomID int64, message stri
 			roomID = live.RoomID
 		}
 	}
-	if roomID <= 0 {
+	if roomID <= 0 && AccountFromContext(ctx) == 0 {
 		if roomInfo, err := s.GetMyLiveRoomInfo(ctx); err == nil && roomInfo.RoomID > 0 {
 			roomID = roomInfo.RoomID
 		}
@@ -641,7 +682,8 @@ func (s *APIService) RefreshCookie(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	setting, err := s.store.GetCookieSetting(ctx)
+	accountID := AccountFromContext(ctx)
+	setting, err := s.store.GetBilibiliCookie(ctx, accountID)
 	if err != nil {
 		return err
 	}
@@ -680,7 +722,7 @@ func (s *APIService) RefreshCookie(ctx context.Context) error {
 		return errors.New("refresh cookie failed: empty refresh_token in response")
 	}
 	merged := mergeCookieWithResponse(setting.Content, cookies)
-	if err := s.store.SaveCookie(ctx, merged, data.RefreshToken); err != nil {
+	if err := s.store.SaveBilibiliCookie(ctx, accountID, merged, data.RefreshToken); err != nil {
 		return err
 	}
 
@@ -691,7 +733,7 @@ func (s *APIService) RefreshCookie(ctx context.Context) error {
 		s.logError("confirm refresh cookie failed: %v", err)
 		return err
 	}
-	s.logInfo("cookie refresh succeeded")
+	s.logInfo("cookie refresh succeeded account=%d", accountID)
 	return nil
 }
 
@@ -699,6 +741,7 @@ type userInfo struct {
 	IsLogin bool   `json:"isLogin"`
 	Mid     int64  `json:"mid"`
 	Uname   string `json:"uname"`
+	Face    string `json:"face"`
 }
 
 type roomInfoOldData struct {
@@ -721,14 +764,25 @@ type roomInfoData struct {
 func (s *APIService) getUserInfo(ctx context.Context) (*userInfo, error) {
 	data, _, _, err := requestJSON[userInfo](s, ctx, http.MethodGet, navAPI, nil, true)
 	if err != nil {
+		var codeErr *apiCodeError
+		if errors.As(err, &codeErr) && codeErr.Code == notLoggedInCode {
+			return nil, fmt.Errorf("%w: %s", ErrNotLoggedIn, codeErr.Message)
+		}
 		return nil, err
 	}
 	if !data.IsLogin {
-		return nil, errors.New("user is not logged in")
+		return nil, ErrNotLoggedIn
 	}
 	return &data, nil
 }
 
+// CheckLogin asks Bilibili whether the cookie of the account of ctx is still logged in, without
+// refreshing it or touching the stored account. The error wraps ErrNotLoggedIn when it is not.
+func (s *APIService) CheckLogin(ctx context.Context) error {
+	_, err := s.getUserInfo(ctx)
+	return err
+}
+
 func (s *APIService) getMyLiveRoomInfoFallback(ctx context.Context) (*store.MyLiveRoomInfo, error) {
 	user, err := s.getUserInfo(ctx)
 	if err != nil {
@@ -743,7 +797,7 @@ func (s *APIService) getMyLiveRoomInfoFallback(ctx context.Context) (*store.MyLi
 		return nil, errors.New("fallback get room info failed: room not initialized")
 	}
 
-	info, roomErr := s.getRoomInfoByRoomID(ctx, roomOld.RoomID)
+	info, roomErr := s.GetRoomInfo(ctx, roomOld.RoomID)
 	if roomErr != nil {
 		s.logWarn("fallback room detail query failed, using coarse room data: %v", roomErr)
 		return &store.MyLiveRoomInfo{
@@ -769,7 +823,8 @@ func (s *APIService) getMyLiveRoomInfoFallback(ctx context.Context) (*store.MyLi
 	return info, nil
 }
 
-func (s *APIService) getRoomInfoByRoomID(ctx context.Context, roomID int64) (*store.MyLiveRoomInfo, error) {
+// GetRoomInfo reads the public info of any room, short ids included, without the cookie.
+func (s *APIService) GetRoomInfo(ctx context.Context, roomID int64) (*store.MyLiveRoomInfo, error) {
 	if roomID <= 0 {
 		return nil, errors.New("room id is required")
 	}
@@ -878,7 +933,7 @@ func (s *APIService) fetchLiveVersion(ctx context.Context) (string, string, erro
 }
 
 func (s *APIService) getCsrf(ctx context.Context) (string, error) {
-	cookieSetting, err := s.store.GetCookieSetting(ctx)
+	cookieSetting, err := s.store.GetBilibiliCookie(ctx, AccountFromContext(ctx))
 	if err != nil {
 		return "", err
 	}
@@ -912,8 +967,12 @@ func (s *APIService) getRefreshCSRF(ctx context.Context) (string, error) {
 	return value, nil
 }
 
-func (s *APIService) readCookieString() string {
-	cookieSetting, err := s.store.GetCookieSetting(context.Background())
+// readCookieString returns the cookie of the account of ctx, empty when it has none.
+func (s *APIService) readCookieString(ctx context.Context) string {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cookieSetting, err := s.store.GetBilibiliCookie(ctx, AccountFromContext(ctx))
 	if err != nil {
 		return ""
 	}
@@ -1063,7 +1122,7 @@ func requestRawOnce(s *APIService, ctx context.Context, method string, targetURL
 		req.Header.Set("Referer", "https://www.bilibili.com/")
 	}
 	if withCookie {
-		cookieContent := s.readCookieString()
+		cookieContent := s.readCookieString(ctx)
 		if strings.TrimSpace(cookieContent) == "" {
 			return "", &bilibiliAPIError{report: apiErrorReport{
 				Endpoint:    targetURL,
@@ -1185,7 +1244,7 @@ func requestJSONOnce[T any](s *APIService, ctx context.Context, method string, t
 		req.Header.Set("Referer", "https://live.bilibili.com/")
 	}
 	if withCookie {
-		cookieContent := s.readCookieString()
+		cookieContent := s.readCookieString(ctx)
 		if strings.TrimSpace(cookieContent) == "" {
 			return zero, nil, nil, &bilibiliAPIError{report: apiErrorReport{
 				Endpoint:    targetURL,
@@ -1237,11 +1296,11 @@ func requestJSONOnce[T any](s *APIService, ctx context.Context, method string, t
 	parsed, err := decodeEnvelopeData[T](bodyBytes)
 	if err != nil {
 		stage := "decode_response"
-		lower := strings.ToLower(err.Error())
-		if strings.Contains(lower, "bilibili api error code=") {
+		var codeErr *apiCodeError
+		if errors.As(err, &codeErr) {
 			stage = "api_code"
 		}
-		return zero, resp.Header.Clone(), resp.Cookies(), &bilibiliAPIError{report: apiErrorReport{
+		return zero, resp.Header.Clone(), resp.Cookies(), &bilibiliAPIError{cause: err, report: apiErrorReport{
 			Endpoint:        targetURL,
 			Method:          method,
 			Stage:           stage,
@@ -1286,7 +1345,7 @@ func decodeEnvelopeData[T any](bodyBytes []byte) (T, error) {
 		if message == "" {
 			message = "unknown bilibili api error"
 		}
-		return zero, fmt.Errorf("bilibili api error code=%d message=%s", env.Code, message)
+		return zero, &apiCodeError{Code: env.Code, Message: message}
 	}
 
 	payload := bytes.TrimSpace(env.Data)
@@ -0,0 +1,386 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/events"
+	"bilibililivetools/gover/backend/store"
+)
+
+const (
+	ReasonScheduled = "scheduled"
+	ReasonPreStream = "pre_stream"
+	ReasonManual    = "manual"
+
+	checkEvent = "bilibili.cookie.check"
+)
+
+// Service checks the login of the main and the extra Bilibili accounts in the background and refreshes
+// their cookies through the correspond-path flow before Bilibili drops them.
+type Service struct {
+	store  *store.Store
+	bili   bilibili.Service
+	events *events.Hub
+
+	nextStartFn func(ctx context.Context) (time.Time, bool)
+	alertFn     func(check store.CookieHealthCheck, setting store.CookieHealthSetting, title string, content string)
+	emailFn     func(ctx context.Context, subject string, body string) error
+
+	wake    chan struct{}
+	checkMu sync.Mutex
+
+	mu            sync.RWMutex
+	cancel        context.CancelFunc
+	lastCheckAt   time.Time
+	nextCheckAt   time.Time
+	nextStreamAt  time.Time
+	preCheckedFor time.Time
+	latest        map[int64]store.CookieHealthCheck
+}
+
+// Status is the runtime view of the checker: when it ran and runs next, the next scheduled start it
+// prepares for and the latest outcome of every account.
+type Status struct {
+	Enabled      bool                      `json:"enabled"`
+	LastCheckAt  *time.Time                `json:"lastCheckAt,omitempty"`
+	NextCheckAt  *time.Time                `json:"nextCheckAt,omitempty"`
+	NextStreamAt *time.Time                `json:"nextStreamAt,omitempty"`
+	Accounts     []store.CookieHealthCheck `json:"accounts"`
+}
+
+func New(storeDB *store.Store, bili bilibili.Service) *Service {
+	return &Service{
+		store:  storeDB,
+		bili:   bili,
+		wake:   make(chan struct{}, 1),
+		latest: make(map[int64]store.CookieHealthCheck),
+	}
+}
+
+// SetEvents makes every check outcome also go to hub. Call it before Start.
+func (s *Service) SetEvents(hub *events.Hub) {
+	s.events = hub
+}
+
+// SetNextStart tells the checker when the next scheduled stream starts, so it can check ahead of it.
+// Call it before Start.
+func (s *Service) SetNextStart(fn func(ctx context.Context) (time.Time, bool)) {
+	s.nextStartFn = fn
+}
+
+// OnAlert registers the handler that sends failed checks to webhooks and the bot provider, and SetEmail
+// the one that mails them. Call both before Start.
+func (s *Service) OnAlert(fn func(check store.CookieHealthCheck, setting store.CookieHealthSetting, title string, content string)) {
+	s.alertFn = fn
+}
+
+func (s *Service) SetEmail(fn func(ctx context.Context, subject string, body string) error) {
+	s.emailFn = fn
+}
+
+func (s *Service) Start() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	go s.loop(ctx)
+}
+
+func (s *Service) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (s *Service) GetSetting(ctx context.Context) (*store.CookieHealthSetting, error) {
+	return s.store.GetCookieHealthSetting(ctx)
+}
+
+// SaveSetting stores the setting and reschedules the next check with it.
+func (s *Service) SaveSetting(ctx context.Context, req store.CookieHealthSetting) (*store.CookieHealthSetting, error) {
+	saved, err := s.store.SaveCookieHealthSetting(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return saved, nil
+}
+
+func (s *Service) Status(ctx context.Context) Status {
+	status := Status{Accounts: []store.CookieHealthCheck{}}
+	if setting, err := s.store.GetCookieHealthSetting(ctx); err == nil {
+		status.Enabled = setting.Enabled
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status.LastCheckAt = timePtr(s.lastCheckAt)
+	status.NextCheckAt = timePtr(s.nextCheckAt)
+	status.NextStreamAt = timePtr(s.nextStreamAt)
+	for _, check := range s.latest {
+		status.Accounts = append(status.Accounts, check)
+	}
+	sort.Slice(status.Accounts, func(i, j int) bool { return status.Accounts[i].AccountID < status.Accounts[j].AccountID })
+	return status
+}
+
+// CheckNow checks every logged-in account at once, whether or not the background check is enabled.
+func (s *Service) CheckNow(ctx context.Context) ([]store.CookieHealthCheck, error) {
+	setting, err := s.store.GetCookieHealthSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, *setting, ReasonManual), nil
+}
+
+func (s *Service) loop(ctx context.Context) {
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+		timer.Reset(s.tick(ctx))
+	}
+}
+
+// tick runs the check when it is due, either by interval or because a scheduled start is within the
+// lead time, and returns when to look again.
+func (s *Service) tick(ctx context.Context) time.Duration {
+	setting, err := s.store.GetCookieHealthSetting(ctx)
+	if err != nil {
+		log.Printf("[credential][warn] load cookie health setting failed: %v", err)
+		return 5 * time.Minute
+	}
+	interval := time.Duration(setting.IntervalMinutes) * time.Minute
+	if interval < 5*time.Minute {
+		interval = time.Hour
+	}
+	lead := time.Duration(setting.LeadMinutes) * time.Minute
+	var nextStart time.Time
+	if s.nextStartFn != nil && lead > 0 {
+		if at, ok := s.nextStartFn(ctx); ok {
+			nextStart = at
+		}
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	s.nextStreamAt = nextStart
+	if !setting.Enabled {
+		s.nextCheckAt = time.Time{}
+		s.mu.Unlock()
+		return interval
+	}
+	reason := ""
+	if !nextStart.IsZero() && !nextStart.Equal(s.preCheckedFor) && !now.Before(nextStart.Add(-lead)) {
+		reason = ReasonPreStream
+		s.preCheckedFor = nextStart
+	} else if s.lastCheckAt.IsZero() || now.Sub(s.lastCheckAt) >= interval {
+		reason = ReasonScheduled
+	}
+	s.mu.Unlock()
+
+	if reason != "" {
+		s.run(ctx, *setting, reason)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := s.lastCheckAt.Add(interval)
+	if !nextStart.IsZero() && !nextStart.Equal(s.preCheckedFor) && nextStart.Add(-lead).Before(next) {
+		next = nextStart.Add(-lead)
+	}
+	s.nextCheckAt = next
+	wait := time.Until(next)
+	if wait < 10*time.Second {
+		wait = 10 * time.Second
+	}
+	return wait
+}
+
+// run checks the main account and every stored account. An account without a cookie can not push, so
+// it is reported as logged out without asking Bilibili.
+func (s *Service) run(ctx context.Context, setting store.CookieHealthSetting, reason string) []store.CookieHealthCheck {
+	s.checkMu.Lock()
+	defer s.checkMu.Unlock()
+
+	type target struct {
+		id        int64
+		name      string
+		hasCookie bool
+	}
+	targets := make([]target, 0, 4)
+	if main, err := s.store.GetCookieSetting(ctx); err == nil {
+		targets = append(targets, target{id: 0, name: "main", hasCookie: strings.TrimSpace(main.Content) != ""})
+	}
+	if accounts, err := s.store.ListBilibiliAccounts(ctx); err == nil {
+		for _, account := range accounts {
+			targets = append(targets, target{id: account.ID, name: account.Name, hasCookie: account.HasCookie})
+		}
+	}
+
+	results := make([]store.CookieHealthCheck, 0, len(targets))
+	for _, item := range targets {
+		var check store.CookieHealthCheck
+		if item.hasCookie {
+			check = s.checkAccount(bilibili.WithAccount(ctx, item.id), item.name, reason)
+		} else {
+			check = store.CookieHealthCheck{
+				AccountID: item.id,
+				Account:   item.name,
+				Reason:    reason,
+				CheckedAt: time.Now().UTC(),
+				Outcome:   store.CookieHealthLoggedOut,
+				Error:     "no cookie stored",
+			}
+		}
+		s.mu.RLock()
+		previous, seen := s.latest[item.id]
+		s.mu.RUnlock()
+		// A failure is reported when it starts and again on pre-stream and manual checks, not every interval.
+		if check.Failed() && (!seen || previous.Outcome != check.Outcome || reason != ReasonScheduled) {
+			check.Alerted = true
+			s.alert(ctx, setting, check)
+		}
+		s.record(ctx, check)
+		results = append(results, check)
+	}
+
+	s.mu.Lock()
+	s.lastCheckAt = time.Now()
+	s.mu.Unlock()
+	return results
+}
+
+// checkAccount refreshes the cookie of the account of ctx when Bilibili asks for it, then makes sure the
+// account is still logged in. Only Bilibili's own not-logged-in answer counts as logged out; a request
+// that fails is check_failed.
+func (s *Service) checkAccount(ctx context.Context, name string, reason string) store.CookieHealthCheck {
+	check := store.CookieHealthCheck{
+		AccountID: bilibili.AccountFromContext(ctx),
+		Account:   name,
+		Reason:    reason,
+		CheckedAt: time.Now().UTC(),
+	}
+	need, err := s.bili.CookieNeedToRefresh(ctx)
+	if err != nil {
+		// A dead cookie fails the refresh check as well.
+		if loginErr := s.bili.CheckLogin(ctx); errors.Is(loginErr, bilibili.ErrNotLoggedIn) {
+			check.Outcome = store.CookieHealthLoggedOut
+			check.Error = loginErr.Error()
+			return check
+		}
+		check.Outcome = store.CookieHealthCheckFailed
+		check.Error = err.Error()
+		return check
+	}
+	if need {
+		if err := s.bili.RefreshCookie(ctx); err != nil {
+			check.Outcome = store.CookieHealthRefreshFailed
+			check.Error = err.Error()
+			return check
+		}
+		check.Outcome = store.CookieHealthRefreshed
+	}
+	err = s.bili.CheckLogin(ctx)
+	switch {
+	case errors.Is(err, bilibili.ErrNotLoggedIn):
+		check.Outcome = store.CookieHealthLoggedOut
+		check.Error = err.Error()
+	case err != nil:
+		check.Outcome = store.CookieHealthCheckFailed
+		check.Error = err.Error()
+	case check.Outcome == "":
+		check.Outcome = store.CookieHealthOK
+	}
+	return check
+}
+
+// record keeps the outcome as a live event and the account's latest state, and publishes it.
+func (s *Service) record(ctx context.Context, check store.CookieHealthCheck) {
+	if body, err := json.Marshal(check); err == nil {
+		if err := s.store.CreateLiveEvent(ctx, checkEvent, string(body)); err != nil {
+			log.Printf("[credential][warn] save cookie check of %s failed: %v", check.Account, err)
+		}
+	}
+	if check.Outcome != store.CookieHealthOK {
+		log.Printf("[credential] account %s (%d): %s %s", check.Account, check.AccountID, check.Outcome, check.Error)
+	}
+	s.mu.Lock()
+	s.latest[check.AccountID] = check
+	s.mu.Unlock()
+	s.events.Publish(events.TopicAccountCookie, 0, check)
+}
+
+func (s *Service) alert(ctx context.Context, setting store.CookieHealthSetting, check store.CookieHealthCheck) {
+	subject, body := describeCheck(check)
+	if setting.EmailAlert && s.emailFn != nil {
+		if err := s.emailFn(ctx, subject, body); err != nil {
+			log.Printf("[credential][warn] email cookie alert of %s failed: %v", check.Account, err)
+		}
+	}
+	if s.alertFn != nil {
+		s.alertFn(check, setting, subject, body)
+	}
+}
+
+func describeCheck(check store.CookieHealthCheck) (string, string) {
+	what := "could not refresh its cookie"
+	if check.Outcome == store.CookieHealthLoggedOut {
+		what = "is no longer logged in"
+	}
+	summary := fmt.Sprintf("Bilibili account %s %s", check.Account, what)
+	lines := []string{
+		summary + ".",
+		"",
+		fmt.Sprintf("Account: %s (id %d)", check.Account, check.AccountID),
+		"Outcome: " + check.Outcome,
+	}
+	if check.Error != "" {
+		lines = append(lines, "Error: "+check.Error)
+	}
+	if check.Reason == ReasonPreStream {
+		lines = append(lines, "A scheduled stream starts soon; log in again before it does.")
+	} else {
+		lines = append(lines, "Log in again before the next stream.")
+	}
+	lines = append(lines, "Time: "+check.CheckedAt.Local().Format("2006-01-02 15:04:05 MST"))
+	return "[Gover] " + summary, strings.Join(lines, "\n")
+}
+
+func timePtr(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	return &value
+}
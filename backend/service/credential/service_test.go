@@ -0,0 +1,160 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/store"
+)
+
+// fakeBilibili answers the calls of checkAccount; every other method of bilibili.Service is left nil.
+type fakeBilibili struct {
+	bilibili.Service
+	loginErr error
+	checks   int
+}
+
+func (f *fakeBilibili) CookieNeedToRefresh(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeBilibili) RefreshCookie(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeBilibili) CheckLogin(ctx context.Context) error {
+	f.checks++
+	return f.loginErr
+}
+
+func TestServiceTick(t *testing.T) {
+	loggedOut := fmt.Errorf("%w: account not logged in", bilibili.ErrNotLoggedIn)
+	cases := []struct {
+		name string
+		// lastCheck is how long ago the previous check ran; 0 means never.
+		lastCheck time.Duration
+		// nextStart is when the next scheduled stream starts from now; 0 means none is scheduled.
+		nextStart  time.Duration
+		preChecked bool
+		previous   string
+		loginErr   error
+		wantReason string
+		wantAlert  bool
+	}{
+		{name: "first check", wantReason: ReasonScheduled},
+		{name: "interval due", lastCheck: 61 * time.Minute, wantReason: ReasonScheduled},
+		{name: "interval not due", lastCheck: 30 * time.Minute},
+		{name: "stream beyond lead time", lastCheck: 30 * time.Minute, nextStart: 3 * time.Hour},
+		{name: "stream within lead time", lastCheck: 30 * time.Minute, nextStart: time.Hour, wantReason: ReasonPreStream},
+		{name: "stream already pre-checked", lastCheck: 30 * time.Minute, nextStart: time.Hour, preChecked: true},
+		{name: "new failure alerts", lastCheck: 61 * time.Minute, previous: store.CookieHealthOK, loginErr: loggedOut, wantReason: ReasonScheduled, wantAlert: true},
+		{name: "repeated failure on schedule stays quiet", lastCheck: 61 * time.Minute, previous: store.CookieHealthLoggedOut, loginErr: loggedOut, wantReason: ReasonScheduled},
+		{name: "repeated failure before a stream alerts", lastCheck: 30 * time.Minute, nextStart: time.Hour, previous: store.CookieHealthLoggedOut, loginErr: loggedOut, wantReason: ReasonPreStream, wantAlert: true},
+		{name: "failed request is no alert", lastCheck: 61 * time.Minute, previous: store.CookieHealthOK, loginErr: fmt.Errorf("dial tcp: i/o timeout"), wantReason: ReasonScheduled},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			storeDB, err := store.Open(filepath.Join(t.TempDir(), "gover.db"))
+			if err != nil {
+				t.Fatalf("open store: %v", err)
+			}
+			defer storeDB.Close()
+			if err := storeDB.SaveBilibiliCookie(ctx, 0, "SESSDATA=test; bili_jct=test", "token"); err != nil {
+				t.Fatalf("save cookie: %v", err)
+			}
+			if _, err := storeDB.SaveCookieHealthSetting(ctx, store.CookieHealthSetting{Enabled: true, IntervalMinutes: 60, LeadMinutes: 120}); err != nil {
+				t.Fatalf("save setting: %v", err)
+			}
+
+			bili := &fakeBilibili{loginErr: tc.loginErr}
+			service := New(storeDB, bili)
+			now := time.Now()
+			nextStart := time.Time{}
+			if tc.nextStart > 0 {
+				nextStart = now.Add(tc.nextStart)
+			}
+			service.SetNextStart(func(context.Context) (time.Time, bool) {
+				return nextStart, !nextStart.IsZero()
+			})
+			alerts := 0
+			service.OnAlert(func(store.CookieHealthCheck, store.CookieHealthSetting, string, string) {
+				alerts++
+			})
+			if tc.lastCheck > 0 {
+				service.lastCheckAt = now.Add(-tc.lastCheck)
+			}
+			if tc.preChecked {
+				service.preCheckedFor = nextStart
+			}
+			if tc.previous != "" {
+				service.latest[0] = store.CookieHealthCheck{AccountID: 0, Outcome: tc.previous, Reason: ReasonScheduled}
+			}
+
+			wait := service.tick(ctx)
+
+			if tc.wantReason == "" {
+				if bili.checks != 0 {
+					t.Fatalf("checked %d times, want no check", bili.checks)
+				}
+			} else {
+				if bili.checks != 1 {
+					t.Fatalf("checked %d times, want 1", bili.checks)
+				}
+				if got := service.latest[0].Reason; got != tc.wantReason {
+					t.Fatalf("reason = %q, want %q", got, tc.wantReason)
+				}
+			}
+			if got := alerts > 0; got != tc.wantAlert {
+				t.Fatalf("alerted = %t, want %t", got, tc.wantAlert)
+			}
+			if tc.wantReason == ReasonPreStream && !service.preCheckedFor.Equal(nextStart) {
+				t.Fatalf("pre-stream check is not remembered for %s", nextStart)
+			}
+			if wait <= 0 || wait > time.Hour {
+				t.Fatalf("next tick in %s", wait)
+			}
+		})
+	}
+}
+
+func TestRunReportsAccountWithoutCookie(t *testing.T) {
+	ctx := context.Background()
+	storeDB, err := store.Open(filepath.Join(t.TempDir(), "gover.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer storeDB.Close()
+	if err := storeDB.SaveBilibiliCookie(ctx, 0, "SESSDATA=test; bili_jct=test", "token"); err != nil {
+		t.Fatalf("save cookie: %v", err)
+	}
+	account, err := storeDB.SaveBilibiliAccount(ctx, store.BilibiliAccountSaveRequest{Name: "second"})
+	if err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+
+	bili := &fakeBilibili{}
+	service := New(storeDB, bili)
+	alerted := map[int64]bool{}
+	service.OnAlert(func(check store.CookieHealthCheck, _ store.CookieHealthSetting, _ string, _ string) {
+		alerted[check.AccountID] = true
+	})
+	results := service.run(ctx, store.CookieHealthSetting{Enabled: true}, ReasonManual)
+
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want the main and the second account", results)
+	}
+	if bili.checks != 1 {
+		t.Fatalf("checked %d times, want only the account with a cookie", bili.checks)
+	}
+	if got := service.latest[account.ID].Outcome; got != store.CookieHealthLoggedOut {
+		t.Fatalf("outcome = %q, want %q", got, store.CookieHealthLoggedOut)
+	}
+	if !alerted[account.ID] || alerted[0] {
+		t.Fatalf("alerted = %v, want only account %d", alerted, account.ID)
+	}
+}
@@ -0,0 +1,196 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Topics published by the services. Subscribers filter by prefix, so "push" matches both push topics.
+const (
+	TopicPushLog         = "push.log"
+	TopicPushStatus      = "push.status"
+	TopicMonitorLog      = "monitor.log"
+	TopicMonitorState    = "monitor.state"
+	TopicAccountCookie   = "account.cookie"
+	TopicConsumerState   = "consumer.state"
+	TopicTaskQueue       = "task.queue"
+	TopicGB28181Register = "gb28181.register"
+	TopicSceneActive     = "scene.active"
+	TopicLiveGift        = "live.gift"
+	TopicLiveSuperChat   = "live.super_chat"
+	TopicLiveGuard       = "live.guard"
+	TopicLiveEnter       = "live.enter"
+	TopicLiveFollow      = "live.follow"
+	TopicLiveShare       = "live.share"
+	TopicLiveLike        = "live.like"
+	TopicLiveRoomChange  = "live.room_change"
+	TopicLiveStats       = "live.stats"
+)
+
+const (
+	defaultHistorySize    = 500
+	subscriberChannelSize = 256
+)
+
+// Topics lists every topic with a short description, for clients and the API docs.
+var Topics = map[string]string{
+	TopicPushLog:         "ffmpeg log line of a push channel",
+	TopicPushStatus:      "push status transition of a channel",
+	TopicMonitorLog:      "room monitor log line",
+	TopicMonitorState:    "room went live, offline or changed its title, as seen by the room monitor",
+	TopicAccountCookie:   "background login check or cookie refresh of a Bilibili account",
+	TopicConsumerState:   "danmaku consumer state change",
+	TopicTaskQueue:       "integration task queued, retried, finished or dead",
+	TopicGB28181Register: "GB28181 device REGISTER or unREGISTER",
+	TopicSceneActive:     "scene switched on a push channel",
+	TopicLiveGift:        "gift sent in the consumed live room",
+	TopicLiveSuperChat:   "super chat in the consumed live room",
+	TopicLiveGuard:       "guard (舰长/提督/总督) bought in the consumed live room",
+	TopicLiveEnter:       "viewer entered the consumed live room",
+	TopicLiveFollow:      "viewer followed the streamer",
+	TopicLiveShare:       "viewer shared the consumed live room",
+	TopicLiveLike:        "viewer liked the consumed live room",
+	TopicLiveRoomChange:  "title or area of the consumed live room changed",
+	TopicLiveStats:       "watched and online rank counts of the consumed live room",
+}
+
+type Event struct {
+	ID        int64     `json:"id"`
+	Topic     string    `json:"topic"`
+	ChannelID int64     `json:"channelId,omitempty"`
+	Time      time.Time `json:"time"`
+	Data      any       `json:"data"`
+}
+
+// Hub fans events out to subscribers and keeps a short history so a reconnecting client can resume from
+// the last id it saw. A nil *Hub accepts and drops everything, so services publish without checking.
+type Hub struct {
+	mu          sync.Mutex
+	nextID      int64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives matching events on C. Events are dropped, and Dropped counts them, while the
+// subscriber is too slow to drain C.
+type Subscription struct {
+	C <-chan Event
+
+	hub       *Hub
+	ch        chan Event
+	topics    []string
+	channelID int64
+	dropped   int64
+	closed    bool
+}
+
+func NewHub(historySize int) *Hub {
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+	return &Hub{
+		historySize: historySize,
+		history:     make([]Event, 0, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to every matching subscriber. channelID 0 marks an event not bound to a push
+// channel.
+func (h *Hub) Publish(topic string, channelID int64, data any) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextID++
+	event := Event{ID: h.nextID, Topic: topic, ChannelID: channelID, Time: time.Now().UTC(), Data: data}
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+	for sub := range h.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the given topic prefixes (all topics when empty) and, when
+// channelID is positive, that channel's events plus events of no channel. Matching events newer than
+// afterID are replayed first; afterID 0 replays nothing.
+func (h *Hub) Subscribe(topics []string, channelID int64, afterID int64) *Subscription {
+	ch := make(chan Event, subscriberChannelSize)
+	sub := &Subscription{C: ch, hub: h, ch: ch, channelID: channelID}
+	for _, topic := range topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			sub.topics = append(sub.topics, topic)
+		}
+	}
+	if h == nil {
+		return sub
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if afterID > 0 {
+		for _, event := range h.history {
+			if event.ID <= afterID || !sub.matches(event) {
+				continue
+			}
+			select {
+			case ch <- event:
+			default:
+				sub.dropped++
+			}
+		}
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Close unregisters the subscription and closes C.
+func (s *Subscription) Close() {
+	if s.hub == nil {
+		return
+	}
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(s.hub.subscribers, s)
+	close(s.ch)
+}
+
+// Dropped returns how many events were lost because the subscriber fell behind.
+func (s *Subscription) Dropped() int64 {
+	if s.hub == nil {
+		return 0
+	}
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription) matches(event Event) bool {
+	if s.channelID > 0 && event.ChannelID != 0 && event.ChannelID != s.channelID {
+		return false
+	}
+	if len(s.topics) == 0 {
+		return true
+	}
+	for _, topic := range s.topics {
+		if event.Topic == topic || strings.HasPrefix(event.Topic, topic+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -7,11 +7,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -82,11 +84,20 @@ func (s *Service) ListVideoCodecs(ctx context.Context) ([]string, error) {
 			if len(fields) < 2 {
 				continue
 			}
-			name := fields[1]
-			if strings.Contains(strings.ToLower(name), "264") || strings.Contains(strings.ToLower(name), "265") || strings.Contains(strings.ToLower(name), "hevc") {
-				if _, ok := seen[name]; !ok {
-					seen[name] = struct{}{}
-					codecs = append(codecs, name)
+			// Encoder names such as libx264 or h264_nvenc follow the codec name in "(encoders: ...)".
+			names := []string{fields[1]}
+			if start := strings.Index(line, "(encoders:"); start >= 0 {
+				rest := line[start+len("(encoders:"):]
+				if end := strings.Index(rest, ")"); end >= 0 {
+					names = append(names, strings.Fields(rest[:end])...)
+				}
+			}
+			for _, name := range names {
+				if strings.Contains(strings.ToLower(name), "264") || strings.Contains(strings.ToLower(name), "265") || strings.Contains(strings.ToLower(name), "hevc") {
+					if _, ok := seen[name]; !ok {
+						seen[name] = struct{}{}
+						codecs = append(codecs, name)
+					}
 				}
 			}
 		}
@@ -218,6 +229,93 @@ func (s *Service) AnalyzeMedia(ctx context.Context, filePath string) (string, er
 	return string(normalized), nil
 }
 
+// ProbeStream is one stream of a probed source.
+type ProbeStream struct {
+	CodecType  string
+	CodecName  string
+	Width      int
+	Height     int
+	FPS        float64
+	SampleRate int
+	Channels   int
+}
+
+// ProbeResult is what ffprobe reports about a source.
+type ProbeResult struct {
+	FormatName  string
+	DurationSec float64
+	Streams     []ProbeStream
+}
+
+// ProbeSource runs ffprobe on a file or URL; inputArgs are demuxer options such as "-rtsp_transport tcp"
+// that go before the source. The caller bounds the run with ctx.
+func (s *Service) ProbeSource(ctx context.Context, source string, inputArgs ...string) (*ProbeResult, error) {
+	args := append([]string{"-v", "error", "-print_format", "json", "-show_format", "-show_streams"}, inputArgs...)
+	cmd := exec.CommandContext(ctx, s.FFprobePath(), append(args, source)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if text := strings.TrimSpace(stderr.String()); text != "" {
+			return nil, fmt.Errorf("%w: %s", err, text)
+		}
+		return nil, err
+	}
+	var parsed struct {
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType    string `json:"codec_type"`
+			CodecName    string `json:"codec_name"`
+			Width        int    `json:"width"`
+			Height       int    `json:"height"`
+			AvgFrameRate string `json:"avg_frame_rate"`
+			RFrameRate   string `json:"r_frame_rate"`
+			SampleRate   string `json:"sample_rate"`
+			Channels     int    `json:"channels"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &parsed); err != nil {
+		return nil, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+	result := &ProbeResult{FormatName: parsed.Format.FormatName}
+	result.DurationSec, _ = strconv.ParseFloat(parsed.Format.Duration, 64)
+	for _, stream := range parsed.Streams {
+		fps := parseFrameRate(stream.AvgFrameRate)
+		if fps == 0 {
+			fps = parseFrameRate(stream.RFrameRate)
+		}
+		sampleRate, _ := strconv.Atoi(stream.SampleRate)
+		result.Streams = append(result.Streams, ProbeStream{
+			CodecType:  stream.CodecType,
+			CodecName:  stream.CodecName,
+			Width:      stream.Width,
+			Height:     stream.Height,
+			FPS:        fps,
+			SampleRate: sampleRate,
+			Channels:   stream.Channels,
+		})
+	}
+	return result, nil
+}
+
+// parseFrameRate turns ffprobe's "30000/1001" into frames per second; "0/0" and garbage are 0.
+func parseFrameRate(raw string) float64 {
+	num, den, ok := strings.Cut(strings.TrimSpace(raw), "/")
+	if !ok {
+		value, _ := strconv.ParseFloat(num, 64)
+		return value
+	}
+	n, nErr := strconv.ParseFloat(num, 64)
+	d, dErr := strconv.ParseFloat(den, 64)
+	if nErr != nil || dErr != nil || d == 0 {
+		return 0
+	}
+	return math.Round(n/d*100) / 100
+}
+
 func runCombined(ctx context.Context, bin string, args ...string) (string, error) {
 	if ctx == nil {
 		tmpCtx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -23,6 +23,7 @@ import (
 	"unicode"
 
 	"bilibililivetools/gover/backend/config"
+	"bilibililivetools/gover/backend/service/events"
 	"bilibililivetools/gover/backend/store"
 )
 
@@ -82,7 +83,8 @@ type tcpPeer struct {
 }
 
 type Service struct {
-	store *store.Store
+	store  *store.Store
+	events *events.Hub
 
 	mu          sync.RWMutex
 	cfg         config.Config
@@ -121,6 +123,11 @@ func New(storeDB *store.Store, cfg config.Config) *Service {
 	}
 }
 
+// SetEvents makes device registrations go to hub. Call it before Start.
+func (s *Service) SetEvents(hub *events.Hub) {
+	s.events = hub
+}
+
 func (s *Service) UpdateConfig(cfg config.Config) {
 	s.mu.Lock()
 	oldCfg := s.cfg
@@ -1003,6 +1010,13 @@ func (s *Service) handleREGISTER(ctx context.Context, msg *sipMessage, remote st
 	}); err != nil {
 		s.setLastError(err)
 	}
+	s.events.Publish(events.TopicGB28181Register, 0, map[string]any{
+		"deviceId":   deviceID,
+		"transport":  transport,
+		"remoteAddr": remote,
+		"expires":    expires,
+		"status":     status,
+	})
 
 	if transport == "tcp" {
 		s.bindDeviceToPeer(deviceID, remote)
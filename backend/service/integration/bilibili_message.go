@@ -0,0 +1,234 @@
+package integration
+
+import (
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// bilibiliLiveMessageCommands are the message-stream commands decoded besides DANMU_MSG.
+var bilibiliLiveMessageCommands = map[string]bool{
+	"SEND_GIFT":          true,
+	"SUPER_CHAT_MESSAGE": true,
+	"GUARD_BUY":          true,
+	"INTERACT_WORD":      true,
+	"LIKE_INFO_V3_CLICK": true,
+	"WATCHED_CHANGE":     true,
+	"ONLINE_RANK_COUNT":  true,
+	"ROOM_CHANGE":        true,
+}
+
+// BilibiliLiveMessage is a decoded message-stream event other than danmaku. The typed field matching
+// Type is set; Text is what rule keywords match and Gold the paid value in gold seeds.
+type BilibiliLiveMessage struct {
+	Type        string                 `json:"type"`
+	Command     string                 `json:"cmd"`
+	RoomID      int64                  `json:"roomId"`
+	UID         int64                  `json:"uid"`
+	Uname       string                 `json:"uname"`
+	Text        string                 `json:"text"`
+	Gold        int64                  `json:"gold"`
+	Gift        *store.LiveGift        `json:"gift,omitempty"`
+	SuperChat   *store.LiveSuperChat   `json:"superChat,omitempty"`
+	Guard       *store.LiveGuardBuy    `json:"guard,omitempty"`
+	Interaction *store.LiveInteraction `json:"interaction,omitempty"`
+	RoomChange  *BilibiliRoomChange    `json:"roomChange,omitempty"`
+	Stats       *BilibiliRoomStats     `json:"stats,omitempty"`
+}
+
+// BilibiliRoomChange is a ROOM_CHANGE: the streamer edited the title or the area.
+type BilibiliRoomChange struct {
+	Title          string `json:"title"`
+	AreaID         int64  `json:"areaId"`
+	AreaName       string `json:"areaName"`
+	ParentAreaID   int64  `json:"parentAreaId"`
+	ParentAreaName string `json:"parentAreaName"`
+}
+
+// BilibiliRoomStats holds the latest audience figures of the consumed room: Watched from WATCHED_CHANGE
+// (people who watched this live), OnlineRank and Online from ONLINE_RANK_COUNT.
+type BilibiliRoomStats struct {
+	RoomID     int64     `json:"roomId"`
+	Watched    int64     `json:"watched"`
+	OnlineRank int64     `json:"onlineRank"`
+	Online     int64     `json:"online"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// decodeBilibiliLiveMessage decodes the commands of bilibiliLiveMessageCommands.
+func decodeBilibiliLiveMessage(payload map[string]any, fallbackRoomID int64) (BilibiliLiveMessage, bool) {
+	command := normalizeBilibiliCommand(anyToString(payload["cmd"]))
+	data, ok := payload["data"].(map[string]any)
+	if !ok || !bilibiliLiveMessageCommands[command] {
+		return BilibiliLiveMessage{}, false
+	}
+	roomID := fallbackRoomID
+	if parsed := anyToInt64(pickField(data, "roomid", "room_id")); parsed > 0 {
+		roomID = parsed
+	} else if parsed := anyToInt64(payload["roomid"]); parsed > 0 {
+		roomID = parsed
+	}
+	if roomID <= 0 {
+		return BilibiliLiveMessage{}, false
+	}
+
+	msg := BilibiliLiveMessage{Command: command, RoomID: roomID}
+	switch command {
+	case "SEND_GIFT":
+		ok = decodeBilibiliGift(&msg, data)
+	case "SUPER_CHAT_MESSAGE":
+		ok = decodeBilibiliSuperChat(&msg, data)
+	case "GUARD_BUY":
+		ok = decodeBilibiliGuardBuy(&msg, data)
+	case "INTERACT_WORD":
+		ok = decodeBilibiliInteraction(&msg, data, bilibiliInteractKind(anyToInt64(data["msg_type"])))
+	case "LIKE_INFO_V3_CLICK":
+		ok = decodeBilibiliInteraction(&msg, data, store.LiveMessageLike)
+	case "WATCHED_CHANGE", "ONLINE_RANK_COUNT":
+		msg.Type = store.LiveMessageStats
+		msg.Stats = &BilibiliRoomStats{RoomID: roomID, UpdatedAt: time.Now().UTC()}
+		if command == "WATCHED_CHANGE" {
+			msg.Stats.Watched = anyToInt64(data["num"])
+		} else {
+			msg.Stats.OnlineRank = anyToInt64(data["count"])
+			msg.Stats.Online = anyToInt64(data["online_count"])
+		}
+	case "ROOM_CHANGE":
+		msg.Type = store.LiveMessageRoomChange
+		msg.RoomChange = &BilibiliRoomChange{
+			Title:          anyToString(data["title"]),
+			AreaID:         anyToInt64(data["area_id"]),
+			AreaName:       anyToString(data["area_name"]),
+			ParentAreaID:   anyToInt64(data["parent_area_id"]),
+			ParentAreaName: anyToString(data["parent_area_name"]),
+		}
+		msg.Text = msg.RoomChange.Title
+	}
+	return msg, ok
+}
+
+func decodeBilibiliGift(msg *BilibiliLiveMessage, data map[string]any) bool {
+	gift := &store.LiveGift{
+		RoomID:    msg.RoomID,
+		UID:       anyToInt64(data["uid"]),
+		Uname:     anyToString(data["uname"]),
+		GiftID:    anyToInt64(pickField(data, "giftId", "gift_id")),
+		GiftName:  anyToString(pickField(data, "giftName", "gift_name")),
+		Num:       anyToInt64(data["num"]),
+		CoinType:  strings.ToLower(anyToString(data["coin_type"])),
+		Price:     anyToInt64(data["price"]),
+		TotalCoin: anyToInt64(data["total_coin"]),
+		CreatedAt: bilibiliMessageTime(data, "timestamp"),
+	}
+	if gift.GiftName == "" {
+		return false
+	}
+	if gift.Num <= 0 {
+		gift.Num = 1
+	}
+	if gift.CoinType == "" {
+		gift.CoinType = "gold"
+	}
+	if gift.TotalCoin <= 0 {
+		gift.TotalCoin = gift.Price * gift.Num
+	}
+	msg.Type = store.LiveMessageGift
+	msg.UID, msg.Uname, msg.Text = gift.UID, gift.Uname, gift.GiftName
+	if gift.CoinType == "gold" {
+		msg.Gold = gift.TotalCoin
+	}
+	msg.Gift = gift
+	return true
+}
+
+func decodeBilibiliSuperChat(msg *BilibiliLiveMessage, data map[string]any) bool {
+	superChat := &store.LiveSuperChat{
+		RoomID:      msg.RoomID,
+		SuperChatID: anyToInt64(data["id"]),
+		UID:         anyToInt64(data["uid"]),
+		Message:     anyToString(data["message"]),
+		Price:       anyToInt64(data["price"]),
+		DurationSec: anyToInt64(data["time"]),
+		CreatedAt:   bilibiliMessageTime(data, "start_time", "ts"),
+	}
+	if user, ok := data["user_info"].(map[string]any); ok {
+		superChat.Uname = anyToString(user["uname"])
+	}
+	if superChat.Price <= 0 {
+		return false
+	}
+	msg.Type = store.LiveMessageSuperChat
+	msg.UID, msg.Uname, msg.Text = superChat.UID, superChat.Uname, superChat.Message
+	msg.Gold = superChat.Price * 1000
+	msg.SuperChat = superChat
+	return true
+}
+
+func decodeBilibiliGuardBuy(msg *BilibiliLiveMessage, data map[string]any) bool {
+	guard := &store.LiveGuardBuy{
+		RoomID:     msg.RoomID,
+		UID:        anyToInt64(data["uid"]),
+		Uname:      anyToString(pickField(data, "username", "uname")),
+		GuardLevel: int(anyToInt64(data["guard_level"])),
+		GiftName:   anyToString(data["gift_name"]),
+		Num:        anyToInt64(data["num"]),
+		Price:      anyToInt64(data["price"]),
+		CreatedAt:  bilibiliMessageTime(data, "start_time"),
+	}
+	if guard.GuardLevel < 1 || guard.GuardLevel > 3 {
+		return false
+	}
+	if guard.Num <= 0 {
+		guard.Num = 1
+	}
+	if guard.GiftName == "" {
+		guard.GiftName = [...]string{"", "总督", "提督", "舰长"}[guard.GuardLevel]
+	}
+	msg.Type = store.LiveMessageGuard
+	msg.UID, msg.Uname, msg.Text = guard.UID, guard.Uname, guard.GiftName
+	msg.Gold = guard.Price * guard.Num
+	msg.Guard = guard
+	return true
+}
+
+func decodeBilibiliInteraction(msg *BilibiliLiveMessage, data map[string]any, kind string) bool {
+	if kind == "" {
+		return false
+	}
+	interaction := &store.LiveInteraction{
+		RoomID:    msg.RoomID,
+		UID:       anyToInt64(data["uid"]),
+		Uname:     anyToString(data["uname"]),
+		Kind:      kind,
+		CreatedAt: bilibiliMessageTime(data, "timestamp"),
+	}
+	msg.Type = kind
+	msg.UID, msg.Uname, msg.Text = interaction.UID, interaction.Uname, interaction.Uname
+	msg.Interaction = interaction
+	return true
+}
+
+// bilibiliInteractKind maps the msg_type of INTERACT_WORD; special and mutual follows count as follows.
+func bilibiliInteractKind(msgType int64) string {
+	switch msgType {
+	case 1:
+		return store.LiveMessageEnter
+	case 2, 4, 5:
+		return store.LiveMessageFollow
+	case 3:
+		return store.LiveMessageShare
+	default:
+		return ""
+	}
+}
+
+// bilibiliMessageTime reads the first positive unix time (seconds) of keys, zero when there is none.
+func bilibiliMessageTime(data map[string]any, keys ...string) time.Time {
+	for _, key := range keys {
+		if value := anyToInt64(data[key]); value > 0 {
+			return time.Unix(value, 0).UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -1091,18 +1091,33 @@ loop:
 				if !allowBilibiliCommand(cfg, cmd) {
 					continue
 				}
-				item, ok := parseBilibiliDanmakuPayload(event, roomID, strings.TrimSpace(setting.Provider))
-				if !ok {
-					continue
+				var dispatchResult *DanmakuDispatchResult
+				var dispatchErr error
+				failure := map[string]any{"cmd": cmd}
+				if strings.HasPrefix(cmd, "DANMU_MSG") {
+					item, ok := parseBilibiliDanmakuPayload(event, roomID, strings.TrimSpace(setting.Provider))
+					if !ok {
+						continue
+					}
+					failure["roomId"], failure["content"] = item.RoomID, item.Content
+					fetched++
+					dispatchResult, dispatchErr = s.DispatchDanmaku(ctx, item)
+				} else {
+					message, ok := decodeBilibiliLiveMessage(event, roomID)
+					if !ok {
+						continue
+					}
+					if message.Type == store.LiveMessageStats {
+						s.updateRoomStats(ctx, message)
+						continue
+					}
+					failure["roomId"], failure["content"] = message.RoomID, message.Text
+					fetched++
+					dispatchResult, dispatchErr = s.DispatchLiveMessage(ctx, message, "consumer."+normalizeDanmakuProvider(setting.Provider))
 				}
-				fetched++
-				dispatchResult, dispatchErr := s.DispatchDanmaku(ctx, item)
 				if dispatchErr != nil {
-					failed = append(failed, map[string]any{
-						"roomId":  item.RoomID,
-						"content": item.Content,
-						"error":   dispatchErr.Error(),
-					})
+					failure["error"] = dispatchErr.Error()
+					failed = append(failed, failure)
 					continue
 				}
 				processed++
@@ -1601,7 +1616,7 @@ func allowBilibiliCommand(cfg bilibiliMessageStreamConfig, cmd string) bool {
 			return false
 		}
 	}
-	return strings.HasPrefix(cmd, "DANMU_MSG")
+	return strings.HasPrefix(cmd, "DANMU_MSG") || bilibiliLiveMessageCommands[cmd]
 }
 
 func parseBilibiliDanmakuPayload(payload map[string]any, fallbackRoomID int64, provider string) (DanmakuDispatchRequest, bool) {
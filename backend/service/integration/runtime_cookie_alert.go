@@ -0,0 +1,43 @@
+package integration
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+const cookieAlertEvent = "bilibili.cookie.alert"
+
+// NotifyCookieAlert sends a failed login check of a Bilibili account to the webhooks subscribed to
+// bilibili.cookie.alert and to the bot provider of the setting, as enabled there. The check itself is
+// already recorded as bilibili.cookie.check with alerted set.
+func (s *Service) NotifyCookieAlert(check store.CookieHealthCheck, setting store.CookieHealthSetting, title string, content string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if setting.WebhookAlert {
+		payload := map[string]any{
+			"eventType": cookieAlertEvent,
+			"time":      check.CheckedAt.Format(time.RFC3339),
+			"data":      check,
+		}
+		webhooks, err := s.store.ListWebhooks(ctx, 1000, 0)
+		if err != nil {
+			log.Printf("[integration][warn] cookie alert: list webhooks failed: %v", err)
+		}
+		for _, item := range webhooks {
+			if !item.Enabled || !item.Accepts(cookieAlertEvent) {
+				continue
+			}
+			if _, err := s.EnqueueWebhookTask(ctx, item, cookieAlertEvent, payload, 3); err != nil {
+				log.Printf("[integration][warn] cookie alert: enqueue webhook %s failed: %v", item.Name, err)
+			}
+		}
+	}
+	if setting.Provider != "" {
+		if _, err := s.sendProviderMessage(ctx, setting.Provider, setting.ProviderParams, title, content); err != nil {
+			log.Printf("[integration][warn] cookie alert: provider %s failed: %v", setting.Provider, err)
+		}
+	}
+}
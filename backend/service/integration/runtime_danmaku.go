@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"strconv"
 	"strings"
 	"time"
 
+	"bilibililivetools/gover/backend/service/bilibili"
 	"bilibililivetools/gover/backend/service/onvif"
 	"bilibililivetools/gover/backend/store"
 )
@@ -31,12 +34,18 @@ func (s *Service) DispatchDanmaku(ctx context.Context, req DanmakuDispatchReques
 		return nil, err
 	}
 
-	rules, err := s.store.ListDanmakuRules(ctx, 2000, 0)
-	if err != nil {
-		return nil, err
+	channelID := int64(0)
+	if s.stream != nil {
+		if resolved, resolveErr := s.stream.ChannelForRoom(ctx, req.RoomID); resolveErr != nil {
+			log.Printf("[integration][warn] danmaku of room %d has no push channel to show on: %v", req.RoomID, resolveErr)
+		} else {
+			channelID = resolved
+			if showErr := s.stream.ShowDanmaku(ctx, channelID, req.UID, record.Uname, req.Content); showErr != nil {
+				log.Printf("[integration][warn] show danmaku on channel %d failed: %v", channelID, showErr)
+			}
+		}
 	}
-	pushSetting, _ := s.store.GetPushSetting(ctx)
-	contentLower := strings.ToLower(req.Content)
+	pushSetting, _ := s.store.GetPushSettingByID(ctx, channelID)
 
 	result := &DanmakuDispatchResult{
 		RoomID:   req.RoomID,
@@ -44,12 +53,26 @@ func (s *Service) DispatchDanmaku(ctx context.Context, req DanmakuDispatchReques
 		Executed: make([]map[string]any, 0, 8),
 		Failed:   make([]map[string]any, 0, 4),
 	}
+	if err := s.runRules(ctx, store.LiveMessageDanmaku, req, 0, pushSetting, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runRules executes the enabled rules of eventType whose keyword the content contains ("*" matching
+// anything) and whose MinGold the paid value reaches, collecting the outcomes in result.
+func (s *Service) runRules(ctx context.Context, eventType string, req DanmakuDispatchRequest, gold int64, pushSetting *store.PushSetting, result *DanmakuDispatchResult) error {
+	rules, err := s.store.ListDanmakuRules(ctx, 2000, 0)
+	if err != nil {
+		return err
+	}
+	contentLower := strings.ToLower(req.Content)
 	for _, rule := range rules {
-		if !rule.Enabled {
+		if !rule.Enabled || ruleEventType(rule) != eventType || gold < rule.MinGold {
 			continue
 		}
 		keyword := strings.ToLower(strings.TrimSpace(rule.Keyword))
-		if keyword == "" || !strings.Contains(contentLower, keyword) {
+		if keyword == "" || (keyword != "*" && !strings.Contains(contentLower, keyword)) {
 			continue
 		}
 		result.MatchedCount++
@@ -57,6 +80,7 @@ func (s *Service) DispatchDanmaku(ctx context.Context, req DanmakuDispatchReques
 		execResult, execErr := s.executeRule(ctx, rule, req, pushSetting)
 		eventPayload := map[string]any{
 			"ruleId":       rule.ID,
+			"eventType":    eventType,
 			"keyword":      rule.Keyword,
 			"action":       rule.Action,
 			"ptzDirection": rule.PTZDirection,
@@ -74,7 +98,7 @@ func (s *Service) DispatchDanmaku(ctx context.Context, req DanmakuDispatchReques
 				"keyword": rule.Keyword,
 				"error":   execErr.Error(),
 			})
-			_ = s.SaveLiveEventJSON(ctx, "danmaku.rule.error", eventPayload)
+			_ = s.SaveLiveEventJSON(ctx, eventType+".rule.error", eventPayload)
 			continue
 		}
 		result.Executed = append(result.Executed, map[string]any{
@@ -83,9 +107,17 @@ func (s *Service) DispatchDanmaku(ctx context.Context, req DanmakuDispatchReques
 			"action":  rule.Action,
 			"result":  execResult,
 		})
-		_ = s.SaveLiveEventJSON(ctx, "danmaku.rule.executed", eventPayload)
+		_ = s.SaveLiveEventJSON(ctx, eventType+".rule.executed", eventPayload)
 	}
-	return result, nil
+	return nil
+}
+
+// ruleEventType returns the event type a rule reacts to; rules saved before event types matched danmaku.
+func ruleEventType(rule store.DanmakuPTZRule) string {
+	if eventType := strings.ToLower(strings.TrimSpace(rule.EventType)); eventType != "" {
+		return eventType
+	}
+	return store.LiveMessageDanmaku
 }
 
 func (s *Service) executeRule(ctx context.Context, rule store.DanmakuPTZRule, req DanmakuDispatchRequest, pushSetting *store.PushSetting) (map[string]any, error) {
@@ -121,27 +153,39 @@ func (s *Service) executeRule(ctx context.Context, rule store.DanmakuPTZRule, re
 		if s.stream == nil {
 			return nil, errors.New("stream runtime is unavailable")
 		}
-		if err := s.stream.Start(ctx, false); err != nil {
+		channelID := pushSettingID(pushSetting)
+		if err := s.stream.Start(ctx, channelID, false); err != nil {
 			return nil, err
 		}
-		return map[string]any{"started": true}, nil
+		return map[string]any{"started": true, "channelId": channelID}, nil
 	case "stop_live":
-		if s.stream != nil {
-			_ = s.stream.Stop(ctx)
+		channelID := pushSettingID(pushSetting)
+		s.stopPushChannel(ctx, channelID)
+		return map[string]any{"stopped": true, "channelId": channelID}, nil
+	case "scene":
+		if s.stream == nil {
+			return nil, errors.New("stream runtime is unavailable")
 		}
-		if s.bili != nil {
-			if room, err := s.store.GetLiveSetting(ctx); err == nil && room.RoomID > 0 {
-				_ = s.bili.StopLive(ctx, room.RoomID)
-			}
+		scene, err := s.store.GetSceneByID(ctx, rule.SceneID)
+		if err != nil {
+			return nil, errors.New("scene rule failed: scene not found")
 		}
-		return map[string]any{"stopped": true}, nil
+		if pushSetting == nil || scene.ChannelID != pushSetting.ID {
+			return nil, errors.New("scene rule failed: scene belongs to another channel than this room")
+		}
+		active, err := s.stream.ActivateScene(ctx, scene.ID, "danmaku", false)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"scene": active}, nil
 	case "webhook":
 		webhooks, err := s.store.ListWebhooks(ctx, 1000, 0)
 		if err != nil {
 			return nil, err
 		}
+		eventType := ruleEventType(rule) + ".rule.webhook"
 		payload := map[string]any{
-			"eventType": "danmaku.rule.webhook",
+			"eventType": eventType,
 			"time":      time.Now().Format(time.RFC3339),
 			"source":    defaultString(req.Source, "manual"),
 			"data": map[string]any{
@@ -156,10 +200,10 @@ func (s *Service) executeRule(ctx context.Context, rule store.DanmakuPTZRule, re
 		taskIDs := make([]int64, 0, len(webhooks))
 		failed := make([]string, 0)
 		for _, item := range webhooks {
-			if !item.Enabled {
+			if !item.Enabled || !item.Accepts(eventType) {
 				continue
 			}
-			taskID, queueErr := s.EnqueueWebhookTask(ctx, item, "danmaku.rule.webhook", payload, 3)
+			taskID, queueErr := s.EnqueueWebhookTask(ctx, item, eventType, payload, 3)
 			if queueErr != nil {
 				failed = append(failed, item.Name+": "+queueErr.Error())
 				continue
@@ -193,30 +237,27 @@ func (s *Service) executeBotCommandNow(ctx context.Context, provider string, com
 		"command":  command,
 		"status":   "accepted",
 	}
+	channelID := int64(onvif.ParseFloatOrDefault(paramsMap["channelId"], 0))
 	switch command {
 	case "start_live":
 		if s.stream == nil {
 			return nil, errors.New("stream runtime is unavailable")
 		}
-		if err := s.stream.Start(ctx, false); err != nil {
+		if err := s.stream.Start(ctx, channelID, false); err != nil {
 			return nil, err
 		}
-		commandResult["live"] = map[string]any{"started": true}
+		commandResult["live"] = map[string]any{"started": true, "channelId": channelID}
 	case "stop_live":
-		if s.stream != nil {
-			_ = s.stream.Stop(ctx)
-		}
-		if s.bili != nil {
-			if room, err := s.store.GetLiveSetting(ctx); err == nil && room.RoomID > 0 {
-				_ = s.bili.StopLive(ctx, room.RoomID)
-			}
-		}
-		commandResult["live"] = map[string]any{"stopped": true}
+		s.stopPushChannel(ctx, channelID)
+		commandResult["live"] = map[string]any{"stopped": true, "channelId": channelID}
 	case "ptz":
 		if s.onvif == nil {
 			return nil, errors.New("ptz runtime is unavailable")
 		}
-		pushSetting, _ := s.store.GetPushSetting(ctx)
+		pushSetting, err := s.store.GetPushSettingByID(ctx, channelID)
+		if err != nil {
+			return nil, err
+		}
 		commandReq := onvif.CommandRequest{
 			Endpoint:     defaultString(asString(paramsMap["endpoint"]), pushSetting.ONVIFEndpoint),
 			Username:     defaultString(asString(paramsMap["username"]), pushSetting.ONVIFUsername),
@@ -235,6 +276,46 @@ func (s *Service) executeBotCommandNow(ctx context.Context, provider string, com
 			return nil, err
 		}
 		commandResult["ptz"] = ptzResult
+	case "scene":
+		if s.stream == nil {
+			return nil, errors.New("stream runtime is unavailable")
+		}
+		sceneID := parseInt64(paramsMap["sceneId"])
+		if sceneID <= 0 {
+			name := asString(paramsMap["name"])
+			if name == "" {
+				return nil, fmt.Errorf("%w: sceneId or name is required", store.ErrSceneNotFound)
+			}
+			scene, err := s.store.FindSceneByName(ctx, channelID, name)
+			if err != nil {
+				return nil, err
+			}
+			sceneID = scene.ID
+		}
+		active, err := s.stream.ActivateScene(ctx, sceneID, defaultString("bot:"+provider, "bot"), asBool(paramsMap["force"], false))
+		if err != nil {
+			return nil, err
+		}
+		commandResult["scene"] = active
+	case "audio":
+		if s.stream == nil {
+			return nil, errors.New("stream runtime is unavailable")
+		}
+		var gainDB *float64
+		if _, ok := paramsMap["gainDb"]; ok {
+			gain := onvif.ParseFloatOrDefault(paramsMap["gainDb"], 0)
+			gainDB = &gain
+		}
+		var muted *bool
+		if _, ok := paramsMap["muted"]; ok {
+			value := asBool(paramsMap["muted"], false)
+			muted = &value
+		}
+		mix, err := s.stream.SetAudioMixSource(ctx, channelID, asString(paramsMap["source"]), gainDB, muted)
+		if err != nil {
+			return nil, err
+		}
+		commandResult["audioMix"] = mix
 	case "send_danmaku":
 		if s.bili == nil {
 			return nil, errors.New("bilibili runtime is unavailable")
@@ -250,7 +331,7 @@ func (s *Service) executeBotCommandNow(ctx context.Context, provider string, com
 			return nil, errors.New("message is required for send_danmaku")
 		}
 		roomID := parseInt64(paramsMap["roomId"])
-		result, err := s.bili.SendDanmaku(ctx, roomID, message)
+		result, err := s.bili.SendDanmaku(s.danmakuAccountContext(ctx, paramsMap), roomID, message)
 		if err != nil {
 			if provider != "" && shouldNotifyProvider(provider, command, paramsMap) {
 				_, _ = s.sendProviderMessage(ctx, provider, paramsMap,
@@ -315,6 +396,39 @@ func defaultString(value string, fallback string) string {
 	return strings.TrimSpace(value)
 }
 
+// danmakuAccountContext picks the account send_danmaku sends as: params.accountId when given (0 is the
+// main account), else the bot account, else the main account.
+func (s *Service) danmakuAccountContext(ctx context.Context, paramsMap map[string]any) context.Context {
+	if _, ok := paramsMap["accountId"]; ok {
+		return bilibili.WithAccount(ctx, parseInt64(paramsMap["accountId"]))
+	}
+	if bot, err := s.store.GetBotBilibiliAccount(ctx); err == nil {
+		return bilibili.WithAccount(ctx, bot.ID)
+	}
+	return bilibili.WithAccount(ctx, 0)
+}
+
+// stopPushChannel stops the ffmpeg loop of a channel and closes the Bilibili room it pushes to.
+func (s *Service) stopPushChannel(ctx context.Context, channelID int64) {
+	if s.stream == nil {
+		return
+	}
+	_ = s.stream.Stop(ctx, channelID)
+	if s.bili == nil {
+		return
+	}
+	if roomID, err := s.stream.RoomID(ctx, channelID); err == nil && roomID > 0 {
+		_ = s.bili.StopLive(s.stream.AccountContext(ctx, channelID), roomID)
+	}
+}
+
+func pushSettingID(setting *store.PushSetting) int64 {
+	if setting == nil {
+		return 0
+	}
+	return setting.ID
+}
+
 func asString(value any) string {
 	switch v := value.(type) {
 	case string:
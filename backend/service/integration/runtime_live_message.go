@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"bilibililivetools/gover/backend/service/events"
+	"bilibililivetools/gover/backend/service/stream"
+	"bilibililivetools/gover/backend/store"
+)
+
+// DispatchLiveMessage records a decoded message-stream event in its table, publishes it as
+// live.<type>, hands it to the webhooks subscribed to that type and runs the rules of the type.
+func (s *Service) DispatchLiveMessage(ctx context.Context, msg BilibiliLiveMessage, source string) (*DanmakuDispatchResult, error) {
+	if msg.RoomID <= 0 {
+		return nil, errors.New("roomId is required")
+	}
+	if msg.Type == store.LiveMessageStats {
+		s.updateRoomStats(ctx, msg)
+		return &DanmakuDispatchResult{RoomID: msg.RoomID, Executed: []map[string]any{}, Failed: []map[string]any{}}, nil
+	}
+	eventType := store.LiveMessageEventPrefix + msg.Type
+	var err error
+	switch {
+	case msg.Gift != nil:
+		err = s.store.InsertLiveGift(ctx, *msg.Gift)
+	case msg.SuperChat != nil:
+		err = s.store.InsertLiveSuperChat(ctx, *msg.SuperChat)
+	case msg.Guard != nil:
+		err = s.store.InsertLiveGuardBuy(ctx, *msg.Guard)
+	case msg.Interaction != nil:
+		err = s.store.InsertLiveInteraction(ctx, *msg.Interaction)
+	case msg.RoomChange != nil:
+		err = s.SaveLiveEventJSON(ctx, eventType, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	channelID := s.channelForRoom(ctx, msg.RoomID)
+	s.events.Publish(eventType, channelID, msg)
+	s.deliverLiveMessage(ctx, eventType, msg, source)
+
+	req := DanmakuDispatchRequest{
+		RoomID:  msg.RoomID,
+		UID:     msg.UID,
+		Uname:   msg.Uname,
+		Content: msg.Text,
+		Source:  source,
+	}
+	pushSetting, _ := s.store.GetPushSettingByID(ctx, channelID)
+	result := &DanmakuDispatchResult{
+		RoomID:   msg.RoomID,
+		Content:  msg.Text,
+		Executed: make([]map[string]any, 0, 4),
+		Failed:   make([]map[string]any, 0, 2),
+	}
+	if err := s.runRules(ctx, msg.Type, req, msg.Gold, pushSetting, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deliverLiveMessage queues the message for every enabled webhook that subscribed to its type.
+func (s *Service) deliverLiveMessage(ctx context.Context, eventType string, msg BilibiliLiveMessage, source string) {
+	webhooks, err := s.store.ListWebhooks(ctx, 1000, 0)
+	if err != nil {
+		log.Printf("[integration][warn] %s: list webhooks failed: %v", eventType, err)
+		return
+	}
+	payload := map[string]any{
+		"eventType": eventType,
+		"time":      time.Now().Format(time.RFC3339),
+		"source":    defaultString(source, "manual"),
+		"data":      msg,
+	}
+	for _, item := range webhooks {
+		if !item.Enabled || !item.Accepts(eventType) {
+			continue
+		}
+		if _, err := s.EnqueueWebhookTask(ctx, item, eventType, payload, 3); err != nil {
+			log.Printf("[integration][warn] %s: enqueue webhook %s failed: %v", eventType, item.Name, err)
+		}
+	}
+}
+
+// updateRoomStats merges a WATCHED_CHANGE or ONLINE_RANK_COUNT into the consumer runtime, publishes
+// the figures as live.stats and feeds them to the live_value overlays of the room's channel. They
+// change every few seconds, so only the overlay values are stored.
+func (s *Service) updateRoomStats(ctx context.Context, msg BilibiliLiveMessage) {
+	if msg.Stats == nil {
+		return
+	}
+	s.consumerMu.Lock()
+	stats := BilibiliRoomStats{}
+	if current := s.consumerState.Room; current != nil && current.RoomID == msg.RoomID {
+		stats = *current
+	}
+	stats.RoomID = msg.RoomID
+	stats.UpdatedAt = msg.Stats.UpdatedAt
+	if msg.Command == "WATCHED_CHANGE" {
+		stats.Watched = msg.Stats.Watched
+	} else {
+		stats.OnlineRank = msg.Stats.OnlineRank
+		stats.Online = msg.Stats.Online
+	}
+	s.consumerState.Room = &stats
+	s.consumerMu.Unlock()
+	channelID := s.channelForRoom(ctx, msg.RoomID)
+	s.events.Publish(events.TopicLiveStats, channelID, stats)
+	if channelID <= 0 {
+		return
+	}
+	values := map[string]int64{stream.LiveValueWatched: stats.Watched}
+	if msg.Command != "WATCHED_CHANGE" {
+		values = map[string]int64{stream.LiveValueOnline: stats.Online, stream.LiveValueOnlineRank: stats.OnlineRank}
+	}
+	for key, value := range values {
+		if err := s.stream.SetLiveValue(ctx, channelID, key, strconv.FormatInt(value, 10)); err != nil {
+			log.Printf("[integration][warn] live value %s of channel %d: %v", key, channelID, err)
+		}
+	}
+}
+
+// channelForRoom returns the push channel bound to a room, 0 when there is none.
+func (s *Service) channelForRoom(ctx context.Context, roomID int64) int64 {
+	if s.stream == nil {
+		return 0
+	}
+	channelID, err := s.stream.ChannelForRoom(ctx, roomID)
+	if err != nil {
+		return 0
+	}
+	return channelID
+}
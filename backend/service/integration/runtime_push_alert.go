@@ -0,0 +1,34 @@
+package integration
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// NotifyPushAlert records a push loop abort and fans it out to every enabled webhook.
+func (s *Service) NotifyPushAlert(alert store.PushAlert) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	payload := map[string]any{
+		"eventType": "push.alert",
+		"time":      alert.Time.Format(time.RFC3339),
+		"data":      alert,
+	}
+	_ = s.SaveLiveEventJSON(ctx, "push.alert", alert)
+	webhooks, err := s.store.ListWebhooks(ctx, 1000, 0)
+	if err != nil {
+		log.Printf("[integration][warn] push alert: list webhooks failed: %v", err)
+		return
+	}
+	for _, item := range webhooks {
+		if !item.Enabled || !item.Accepts("push.alert") {
+			continue
+		}
+		if _, err := s.EnqueueWebhookTask(ctx, item, "push.alert", payload, 3); err != nil {
+			log.Printf("[integration][warn] push alert: enqueue webhook %s failed: %v", item.Name, err)
+		}
+	}
+}
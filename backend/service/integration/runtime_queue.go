@@ -16,6 +16,8 @@ import (
 	"strings"
 	"time"
 
+	"bilibililivetools/gover/backend/service/events"
+	"bilibililivetools/gover/backend/service/stream"
 	"bilibililivetools/gover/backend/store"
 )
 
@@ -94,7 +96,7 @@ func (s *Service) EnqueueWebhookTask(ctx context.Context, target store.WebhookSe
 	if err != nil {
 		return 0, err
 	}
-	_ = s.SaveLiveEventJSON(ctx, "integration.task.queued", map[string]any{
+	s.recordTaskEvent(ctx, "integration.task.queued", map[string]any{
 		"taskId":    taskID,
 		"taskType":  integrationTaskTypeWebhook,
 		"eventType": eventType,
@@ -140,7 +142,7 @@ func (s *Service) EnqueueBotTask(ctx context.Context, provider string, command s
 	if err != nil {
 		return 0, err
 	}
-	_ = s.SaveLiveEventJSON(ctx, "integration.task.queued", map[string]any{
+	s.recordTaskEvent(ctx, "integration.task.queued", map[string]any{
 		"taskId":   taskID,
 		"taskType": integrationTaskTypeBot,
 		"provider": provider,
@@ -238,14 +240,26 @@ func (s *Service) processTask(task store.IntegrationTask) {
 
 	s.applyRateLimit(ctx, task.RateKey, s.queueRateGap(task.TaskType))
 
+	s.events.Publish(events.TopicTaskQueue, 0, map[string]any{
+		"event":    "integration.task.running",
+		"taskId":   task.ID,
+		"taskType": task.TaskType,
+		"attempt":  attempt,
+	})
 	retryable, err := s.executeTask(ctx, task, attempt)
 	if err == nil {
 		_ = s.store.MarkIntegrationTaskSucceeded(context.Background(), task.ID, attempt)
+		s.events.Publish(events.TopicTaskQueue, 0, map[string]any{
+			"event":    "integration.task.succeeded",
+			"taskId":   task.ID,
+			"taskType": task.TaskType,
+			"attempt":  attempt,
+		})
 		return
 	}
 	if !retryable || attempt >= task.MaxAttempts {
 		_ = s.store.MarkIntegrationTaskDead(context.Background(), task.ID, attempt, err.Error())
-		_ = s.SaveLiveEventJSON(context.Background(), "integration.task.dead", map[string]any{
+		s.recordTaskEvent(context.Background(), "integration.task.dead", map[string]any{
 			"taskId":      task.ID,
 			"taskType":    task.TaskType,
 			"attempt":     attempt,
@@ -256,7 +270,7 @@ func (s *Service) processTask(task store.IntegrationTask) {
 	}
 	nextRun := time.Now().UTC().Add(nextRetryDelay(attempt))
 	_ = s.store.MarkIntegrationTaskRetry(context.Background(), task.ID, attempt, nextRun, err.Error())
-	_ = s.SaveLiveEventJSON(context.Background(), "integration.task.retry", map[string]any{
+	s.recordTaskEvent(context.Background(), "integration.task.retry", map[string]any{
 		"taskId":   task.ID,
 		"taskType": task.TaskType,
 		"attempt":  attempt,
@@ -265,6 +279,17 @@ func (s *Service) processTask(task store.IntegrationTask) {
 	})
 }
 
+// recordTaskEvent saves a task transition as a live event and publishes it on the task queue topic.
+func (s *Service) recordTaskEvent(ctx context.Context, eventType string, fields map[string]any) {
+	_ = s.SaveLiveEventJSON(ctx, eventType, fields)
+	data := make(map[string]any, len(fields)+1)
+	for key, value := range fields {
+		data[key] = value
+	}
+	data["event"] = eventType
+	s.events.Publish(events.TopicTaskQueue, 0, data)
+}
+
 func (s *Service) queueRateGap(taskType string) time.Duration {
 	setting := s.queueSettingCached(context.Background())
 	if setting == nil {
@@ -469,5 +494,10 @@ func isRetryableBotError(err error) bool {
 	if strings.Contains(lower, "unsupported bot command") || strings.Contains(lower, "invalid ptz params") {
 		return false
 	}
+	// A scene that is missing or still within its dwell time, or an audio mix change that was refused, must
+	// not take effect minutes later on retry.
+	if errors.Is(err, store.ErrSceneNotFound) || errors.Is(err, stream.ErrSceneDwell) || errors.Is(err, stream.ErrAudioMixChange) {
+		return false
+	}
 	return true
 }
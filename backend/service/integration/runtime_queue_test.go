@@ -0,0 +1,33 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"bilibililivetools/gover/backend/service/stream"
+	"bilibililivetools/gover/backend/store"
+)
+
+func TestIsRetryableBotError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no error", err: nil, want: false},
+		{name: "unknown scene", err: fmt.Errorf("%w: %q", store.ErrSceneNotFound, "wide"), want: false},
+		{name: "scene dwell", err: fmt.Errorf("%w: %q stays on air for another 20s", stream.ErrSceneDwell, "wide"), want: false},
+		{name: "network error while switching scene", err: errors.New("switch scene: dial tcp 10.0.0.2:554: i/o timeout"), want: true},
+		{name: "unknown audio mix source", err: fmt.Errorf("%w: unknown source %q", stream.ErrAudioMixChange, "drums"), want: false},
+		{name: "audio mix save failed", err: errors.New("save audio mix: database is locked"), want: true},
+		{name: "unsupported command", err: errors.New("unsupported bot command: dance"), want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableBotError(tc.err); got != tc.want {
+				t.Fatalf("isRetryableBotError(%v) = %t, want %t", tc.err, got, tc.want)
+			}
+		})
+	}
+}
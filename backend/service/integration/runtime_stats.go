@@ -69,6 +69,11 @@ func (s *Service) BuildAdvancedStats(ctx context.Context, hours int, granularity
 		return nil, err
 	}
 
+	revenue, revenueSections, err := s.buildRevenueStats(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
 	keywordStats := buildKeywordStats(ctx, s, cutoff)
 	queueSummary, _ := s.store.IntegrationTaskSummary(ctx)
 	deadTasks, _ := s.store.ListIntegrationTasks(ctx, 20, string(store.IntegrationTaskStatusDead), "")
@@ -79,7 +84,7 @@ func (s *Service) BuildAdvancedStats(ctx context.Context, hours int, granularity
 		hitRate = float64(ruleExecuted) / float64(matchedTotal)
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"hours":       hours,
 		"granularity": granularity,
 		"cutoff":      cutoff.Format(time.RFC3339),
@@ -94,6 +99,8 @@ func (s *Service) BuildAdvancedStats(ctx context.Context, hours int, granularity
 			"alertSentCount":   alertCount,
 			"taskQueuePending": queueSummary.Pending,
 			"taskQueueDead":    queueSummary.Dead,
+			"revenueGold":      revenue.TotalGold,
+			"revenueBattery":   revenue.Battery,
 		},
 		"eventTypeTop":  eventTypeTop,
 		"hourlyEvents":  hourlyEvents,
@@ -104,7 +111,53 @@ func (s *Service) BuildAdvancedStats(ctx context.Context, hours int, granularity
 		"queueSummary":  queueSummary,
 		"deadLetter":    deadTasks,
 		"consumerState": s.ConsumerRuntime(),
+		"revenue":       revenue,
 		"now":           now.Format(time.RFC3339),
+	}
+	for key, value := range revenueSections {
+		result[key] = value
+	}
+	return result, nil
+}
+
+// buildRevenueStats sums up the gifts, super chats and guard purchases since the cutoff and lists them
+// per UTC day, per stream session, by supporter and by gift type, plus the paid messages themselves.
+func (s *Service) buildRevenueStats(ctx context.Context, cutoff time.Time) (store.LiveRevenueSummary, map[string]any, error) {
+	revenue, err := s.store.LiveRevenueBetween(ctx, 0, cutoff, time.Time{})
+	if err != nil {
+		return revenue, nil, err
+	}
+	daily, err := s.store.ListLiveRevenueByDay(ctx, 0, cutoff)
+	if err != nil {
+		return revenue, nil, err
+	}
+	sessions, err := s.store.ListLiveSessionRevenue(ctx, 0, cutoff, 200)
+	if err != nil {
+		return revenue, nil, err
+	}
+	supporters, err := s.store.ListTopLiveSupporters(ctx, 0, cutoff, 20)
+	if err != nil {
+		return revenue, nil, err
+	}
+	gifts, err := s.store.ListLiveGiftStats(ctx, 0, cutoff, 50)
+	if err != nil {
+		return revenue, nil, err
+	}
+	superChats, err := s.store.ListLiveSuperChats(ctx, 0, cutoff, 1000)
+	if err != nil {
+		return revenue, nil, err
+	}
+	guards, err := s.store.ListLiveGuardBuys(ctx, 0, cutoff, 1000)
+	if err != nil {
+		return revenue, nil, err
+	}
+	return revenue, map[string]any{
+		"revenueDaily":     daily,
+		"revenueBySession": sessions,
+		"topSupporters":    supporters,
+		"giftBreakdown":    gifts,
+		"superChats":       superChats,
+		"guardHistory":     guards,
 	}, nil
 }
 
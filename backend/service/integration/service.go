@@ -7,13 +7,22 @@ import (
 	"sync"
 	"time"
 
+	"bilibililivetools/gover/backend/service/events"
 	"bilibililivetools/gover/backend/service/onvif"
 	"bilibililivetools/gover/backend/store"
 )
 
+// StreamController addresses push channels by id; channel 0 is the default channel.
 type StreamController interface {
-	Start(ctx context.Context, startup bool) error
-	Stop(ctx context.Context) error
+	Start(ctx context.Context, channelID int64, startup bool) error
+	Stop(ctx context.Context, channelID int64) error
+	RoomID(ctx context.Context, channelID int64) (int64, error)
+	ChannelForRoom(ctx context.Context, roomID int64) (int64, error)
+	AccountContext(ctx context.Context, channelID int64) context.Context
+	ShowDanmaku(ctx context.Context, channelID int64, uid int64, uname string, content string) error
+	SetLiveValue(ctx context.Context, channelID int64, key string, value string) error
+	ActivateScene(ctx context.Context, sceneID int64, source string, force bool) (*store.ActiveScene, error)
+	SetAudioMixSource(ctx context.Context, channelID int64, source string, gainDB *float64, muted *bool) (*store.PushAudioMix, error)
 }
 
 type LiveStopper interface {
@@ -43,14 +52,15 @@ type DanmakuDispatchResult struct {
 }
 
 type DanmakuConsumerRuntime struct {
-	Running       bool       `json:"running"`
-	LastPollAt    *time.Time `json:"lastPollAt,omitempty"`
-	LastCursor    string     `json:"lastCursor"`
-	LastError     string     `json:"lastError"`
-	LastFetched   int        `json:"lastFetched"`
-	LastProcessed int        `json:"lastProcessed"`
-	LastMatched   int        `json:"lastMatched"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
+	Running       bool               `json:"running"`
+	LastPollAt    *time.Time         `json:"lastPollAt,omitempty"`
+	LastCursor    string             `json:"lastCursor"`
+	LastError     string             `json:"lastError"`
+	LastFetched   int                `json:"lastFetched"`
+	LastProcessed int                `json:"lastProcessed"`
+	LastMatched   int                `json:"lastMatched"`
+	Room          *BilibiliRoomStats `json:"room,omitempty"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
 }
 
 type Service struct {
@@ -58,6 +68,7 @@ type Service struct {
 	stream StreamController
 	bili   LiveStopper
 	onvif  PTZCommander
+	events *events.Hub
 
 	runMu         sync.Mutex
 	running       bool
@@ -172,11 +183,19 @@ func (s *Service) stopChannel() <-chan struct{} {
 	return s.stopCh
 }
 
+// SetEvents makes the consumer publish its state changes and the task queue its task transitions to
+// hub. Call it before Start.
+func (s *Service) SetEvents(hub *events.Hub) {
+	s.events = hub
+}
+
 func (s *Service) markConsumerState(update func(*DanmakuConsumerRuntime)) {
 	s.consumerMu.Lock()
-	defer s.consumerMu.Unlock()
 	update(&s.consumerState)
 	s.consumerState.UpdatedAt = time.Now().UTC()
+	state := s.consumerState
+	s.consumerMu.Unlock()
+	s.events.Publish(events.TopicConsumerState, 0, state)
 }
 
 func (s *Service) ConsumerRuntime() DanmakuConsumerRuntime {
@@ -282,6 +301,22 @@ func (s *Service) ListDanmakuRecords(ctx context.Context, roomID int64, limit in
 	return s.store.ListDanmakuRecords(ctx, roomID, limit)
 }
 
+func (s *Service) ListLiveGifts(ctx context.Context, roomID int64, since time.Time, limit int) ([]store.LiveGift, error) {
+	return s.store.ListLiveGifts(ctx, roomID, since, limit)
+}
+
+func (s *Service) ListLiveSuperChats(ctx context.Context, roomID int64, since time.Time, limit int) ([]store.LiveSuperChat, error) {
+	return s.store.ListLiveSuperChats(ctx, roomID, since, limit)
+}
+
+func (s *Service) ListLiveGuardBuys(ctx context.Context, roomID int64, since time.Time, limit int) ([]store.LiveGuardBuy, error) {
+	return s.store.ListLiveGuardBuys(ctx, roomID, since, limit)
+}
+
+func (s *Service) ListLiveInteractions(ctx context.Context, roomID int64, kind string, since time.Time, limit int) ([]store.LiveInteraction, error) {
+	return s.store.ListLiveInteractions(ctx, roomID, kind, since, limit)
+}
+
 func (s *Service) CountDanmakuRecordsSince(ctx context.Context, roomID int64, since time.Time) (int64, error) {
 	return s.store.CountDanmakuRecordsSince(ctx, roomID, since)
 }
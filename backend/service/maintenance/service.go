@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -59,10 +61,11 @@ type queueRequest struct {
 }
 
 type Service struct {
-	store      *store.Store
-	interval   time.Duration
-	maxHistory int
-	queue      chan queueRequest
+	store        *store.Store
+	recordingDir string
+	interval     time.Duration
+	maxHistory   int
+	queue        chan queueRequest
 
 	mu            sync.RWMutex
 	cancel        context.CancelFunc
@@ -72,13 +75,14 @@ type Service struct {
 	seq           uint64
 }
 
-func New(storeDB *store.Store) *Service {
+func New(storeDB *store.Store, recordingDir string) *Service {
 	return &Service{
-		store:      storeDB,
-		interval:   30 * time.Minute,
-		maxHistory: 40,
-		queue:      make(chan queueRequest, 16),
-		history:    make([]JobStatus, 0, 40),
+		store:        storeDB,
+		recordingDir: recordingDir,
+		interval:     30 * time.Minute,
+		maxHistory:   40,
+		queue:        make(chan queueRequest, 16),
+		history:      make([]JobStatus, 0, 40),
 	}
 }
 
@@ -358,6 +362,10 @@ func (s *Service) runCleanupJob(ctx context.Context, req queueRequest) error {
 	if err != nil {
 		return err
 	}
+	s.updateCurrentProgress(60, "cleanup_recordings", "applying recording retention")
+	if cleanup.Recordings, cleanup.RecordingBytes, err = s.cleanupRecordings(ctx); err != nil {
+		return err
+	}
 	_ = s.store.MarkMaintenanceCleanup(context.Background(), time.Now())
 	s.updateCurrentCleanup(cleanup)
 	s.updateCurrentProgress(75, "cleanup_done", "cleanup completed")
@@ -372,6 +380,82 @@ func (s *Service) runCleanupJob(ctx context.Context, req queueRequest) error {
 	return nil
 }
 
+// cleanupRecordings applies the recording limits of the maintenance setting: segments past the age
+// limit first, then the oldest ones until the total size fits.
+func (s *Service) cleanupRecordings(ctx context.Context) (int64, int64, error) {
+	setting, err := s.store.GetMaintenanceSetting(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	var count, size int64
+	if setting.RecordingRetentionDays > 0 {
+		cutoff := time.Now().Add(-time.Duration(setting.RecordingRetentionDays) * 24 * time.Hour)
+		for {
+			items, listErr := s.store.ListRecordingsEndedBefore(ctx, cutoff, 200)
+			if listErr != nil {
+				return count, size, listErr
+			}
+			if len(items) == 0 {
+				break
+			}
+			removed, bytes, removeErr := s.removeRecordings(ctx, items)
+			count, size = count+removed, size+bytes
+			if removeErr != nil {
+				return count, size, removeErr
+			}
+		}
+	}
+	if setting.RecordingMaxSizeMB > 0 {
+		limit := setting.RecordingMaxSizeMB * 1024 * 1024
+		total, totalErr := s.store.RecordingsTotalSize(ctx)
+		if totalErr != nil {
+			return count, size, totalErr
+		}
+		for total > limit {
+			items, listErr := s.store.ListOldestRecordings(ctx, 50)
+			if listErr != nil {
+				return count, size, listErr
+			}
+			if len(items) == 0 {
+				break
+			}
+			batch := make([]store.Recording, 0, len(items))
+			for _, item := range items {
+				if total <= limit {
+					break
+				}
+				batch = append(batch, item)
+				total -= item.SizeBytes
+			}
+			removed, bytes, removeErr := s.removeRecordings(ctx, batch)
+			count, size = count+removed, size+bytes
+			if removeErr != nil {
+				return count, size, removeErr
+			}
+		}
+	}
+	return count, size, nil
+}
+
+func (s *Service) removeRecordings(ctx context.Context, items []store.Recording) (int64, int64, error) {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	deleted, err := s.store.DeleteRecordings(ctx, ids)
+	if err != nil {
+		return 0, 0, err
+	}
+	var size int64
+	for _, item := range deleted {
+		size += item.SizeBytes
+		if removeErr := os.Remove(filepath.Join(s.recordingDir, filepath.FromSlash(item.Path))); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("[maintenance][warn] remove recording %s failed: %v", item.Path, removeErr)
+		}
+	}
+	return int64(len(deleted)), size, nil
+}
+
 func (s *Service) setCurrent(job JobStatus, cancel context.CancelFunc) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
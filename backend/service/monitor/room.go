@@ -0,0 +1,324 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/service/events"
+	"bilibililivetools/gover/backend/store"
+)
+
+// roomWatch is what the room monitor knows of the watched room between polls. A transition is only
+// confirmed once the new status or title was seen on DebounceCount polls in a row.
+type roomWatch struct {
+	roomID       int64
+	enabled      bool
+	known        bool
+	status       int
+	title        string
+	statusSeen   int
+	pendingTitle string
+	titleSeen    int
+	lastPollAt   time.Time
+	lastError    string
+	failures     int
+	notifiedAt   map[string]time.Time
+}
+
+// RoomState is the runtime view of the room monitor. Known is false until the first successful poll
+// set the baseline, which is never reported as a change.
+type RoomState struct {
+	Enabled    bool                 `json:"enabled"`
+	RoomID     int64                `json:"roomId"`
+	Known      bool                 `json:"known"`
+	LiveStatus int                  `json:"liveStatus"`
+	Title      string               `json:"title"`
+	Pending    string               `json:"pending,omitempty"`
+	LastPollAt *time.Time           `json:"lastPollAt,omitempty"`
+	LastError  string               `json:"lastError,omitempty"`
+	Failures   int                  `json:"failures"`
+	NotifiedAt map[string]time.Time `json:"notifiedAt"`
+}
+
+func (s *Service) RoomState() RoomState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	watch := s.room
+	state := RoomState{
+		Enabled:    watch.enabled,
+		RoomID:     watch.roomID,
+		Known:      watch.known,
+		LiveStatus: watch.status,
+		Title:      watch.title,
+		LastError:  watch.lastError,
+		Failures:   watch.failures,
+		NotifiedAt: make(map[string]time.Time, len(watch.notifiedAt)),
+	}
+	if watch.statusSeen > 0 {
+		state.Pending = fmt.Sprintf("status seen %d time(s)", watch.statusSeen)
+	} else if watch.titleSeen > 0 {
+		state.Pending = fmt.Sprintf("title %q seen %d time(s)", watch.pendingTitle, watch.titleSeen)
+	}
+	if !watch.lastPollAt.IsZero() {
+		lastPollAt := watch.lastPollAt
+		state.LastPollAt = &lastPollAt
+	}
+	for kind, at := range watch.notifiedAt {
+		state.NotifiedAt[kind] = at
+	}
+	return state
+}
+
+// PollRoomNow makes the monitor poll at once, e.g. after its setting changed.
+func (s *Service) PollRoomNow() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) roomLoop(ctx context.Context) {
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+		timer.Reset(s.pollRoom(ctx))
+	}
+}
+
+// pollRoom checks the watched room once and returns when to poll next.
+func (s *Service) pollRoom(ctx context.Context) time.Duration {
+	setting, err := s.store.GetMonitorSetting(ctx)
+	if err != nil {
+		s.Errorf("load monitor setting failed: %v", err)
+		return time.Minute
+	}
+	interval := time.Duration(setting.PollIntervalSec) * time.Second
+	if interval < 10*time.Second {
+		interval = time.Minute
+	}
+	enabled := setting.IsEnabled && setting.RoomID > 0
+	s.mu.Lock()
+	if !enabled || s.room.roomID != setting.RoomID || !s.room.enabled {
+		// Another room, or one watched again, starts from a new baseline.
+		s.room = roomWatch{roomID: setting.RoomID, enabled: enabled, notifiedAt: map[string]time.Time{}}
+	}
+	s.mu.Unlock()
+	if !enabled || s.bili == nil {
+		return interval
+	}
+
+	info, err := s.bili.GetRoomInfo(ctx, setting.RoomID)
+	now := time.Now().UTC()
+	if err != nil {
+		s.mu.Lock()
+		s.room.lastPollAt = now
+		s.room.lastError = err.Error()
+		s.room.failures++
+		failures := s.room.failures
+		s.mu.Unlock()
+		// A failed poll says nothing about the room; only report it now and then.
+		if failures == 1 || failures%10 == 0 {
+			s.Warnf("poll room %d failed (%d in a row): %v", setting.RoomID, failures, err)
+		}
+		return interval
+	}
+	known := s.RoomState().Known
+	changes := s.observeRoom(setting.RoomID, setting.DebounceCount, info.LiveStatus, info.Title, now)
+	if !known {
+		s.Infof("watching room %d: status %s, title %q", setting.RoomID, liveStatusText(info.LiveStatus), info.Title)
+	}
+	for _, change := range changes {
+		s.recordChange(ctx, *setting, change)
+	}
+	return interval
+}
+
+// observeRoom folds one poll into the watch and returns the transitions it confirmed. Only going live
+// (status 1) or leaving it is a status change; offline and rotating replays (0 and 2) count the same.
+func (s *Service) observeRoom(roomID int64, debounce int, status int, title string, now time.Time) []store.MonitorStateChange {
+	if debounce < 1 {
+		debounce = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	watch := &s.room
+	watch.lastPollAt = now
+	watch.lastError = ""
+	watch.failures = 0
+	if !watch.known {
+		watch.known, watch.status, watch.title = true, status, title
+		return nil
+	}
+
+	changes := make([]store.MonitorStateChange, 0, 1)
+	if (status == 1) == (watch.status == 1) {
+		watch.status = status
+		watch.statusSeen = 0
+	} else if watch.statusSeen++; watch.statusSeen >= debounce {
+		change := store.MonitorStateChange{
+			RoomID:        roomID,
+			Kind:          store.MonitorChangeOffline,
+			FromStatus:    watch.status,
+			ToStatus:      status,
+			PreviousTitle: watch.title,
+			Title:         title,
+			CreatedAt:     now,
+		}
+		if status == 1 {
+			// Going live reports the title it went live with; it is not a title change of its own.
+			change.Kind = store.MonitorChangeStarted
+			watch.title = title
+			watch.titleSeen = 0
+		}
+		watch.status = status
+		watch.statusSeen = 0
+		changes = append(changes, change)
+	}
+
+	switch {
+	case title == watch.title:
+		watch.titleSeen = 0
+	case watch.titleSeen > 0 && title == watch.pendingTitle:
+		watch.titleSeen++
+	default:
+		watch.pendingTitle = title
+		watch.titleSeen = 1
+	}
+	if watch.titleSeen >= debounce {
+		changes = append(changes, store.MonitorStateChange{
+			RoomID:        roomID,
+			Kind:          store.MonitorChangeTitle,
+			FromStatus:    watch.status,
+			ToStatus:      watch.status,
+			PreviousTitle: watch.title,
+			Title:         title,
+			CreatedAt:     now,
+		})
+		watch.title = title
+		watch.titleSeen = 0
+	}
+	return changes
+}
+
+// recordChange stores a confirmed transition, emails it when it is worth it and publishes it.
+func (s *Service) recordChange(ctx context.Context, setting store.MonitorSetting, change store.MonitorStateChange) {
+	if change.Kind == store.MonitorChangeOffline {
+		change.Unexpected = true
+		if s.pushProbe != nil {
+			bound, pushing := s.pushProbe(ctx, change.RoomID)
+			change.Unexpected = !bound || pushing
+		}
+	}
+	saved, err := s.store.InsertMonitorStateChange(ctx, change)
+	if err != nil {
+		s.Errorf("save room %d state change failed: %v", change.RoomID, err)
+		saved = &change
+	}
+	s.Infof("room %d %s: status %s -> %s, title %q", change.RoomID, describeKind(change),
+		liveStatusText(change.FromStatus), liveStatusText(change.ToStatus), change.Title)
+
+	// Stops made through this app are history only.
+	if change.Kind != store.MonitorChangeOffline || change.Unexpected {
+		saved.Notified, saved.NotifyError = s.notifyChange(ctx, setting, *saved)
+		if saved.ID > 0 {
+			if err := s.store.UpdateMonitorStateChangeNotice(ctx, saved.ID, saved.Notified, saved.NotifyError); err != nil {
+				s.Warnf("save room %d notice result failed: %v", change.RoomID, err)
+			}
+		}
+	}
+	s.events.Publish(events.TopicMonitorState, 0, saved)
+}
+
+// notifyChange emails a change unless email is off or the same kind was emailed within the cooldown.
+// It returns whether a mail went out and, when not, why.
+func (s *Service) notifyChange(ctx context.Context, setting store.MonitorSetting, change store.MonitorStateChange) (bool, string) {
+	if !setting.IsEnableEmailNotice {
+		return false, "email notice is disabled"
+	}
+	cooldown := time.Duration(setting.CooldownSec) * time.Second
+	s.mu.RLock()
+	last := s.room.notifiedAt[change.Kind]
+	s.mu.RUnlock()
+	if cooldown > 0 && !last.IsZero() && change.CreatedAt.Sub(last) < cooldown {
+		s.Infof("room %d %s not emailed, last mail %s ago", change.RoomID, describeKind(change), change.CreatedAt.Sub(last).Round(time.Second))
+		return false, "suppressed by cooldown"
+	}
+	receivers := normalizeReceivers(setting.Receivers)
+	if len(receivers) == 0 {
+		s.Warnf("room %d %s not emailed: no email receivers configured", change.RoomID, describeKind(change))
+		return false, "no email receivers configured"
+	}
+	subject, body := describeChange(setting, change)
+	if err := s.sendEmailSMTP(ctx, setting, subject, body, receivers); err != nil {
+		s.Errorf("email room %d %s failed smtp=%s:%d err=%v", change.RoomID, describeKind(change), setting.SMTPServer, setting.SMTPPort, err)
+		return false, err.Error()
+	}
+	s.mu.Lock()
+	if s.room.notifiedAt != nil {
+		s.room.notifiedAt[change.Kind] = change.CreatedAt
+	}
+	s.mu.Unlock()
+	return true, ""
+}
+
+func describeChange(setting store.MonitorSetting, change store.MonitorStateChange) (string, string) {
+	roomURL := strings.TrimSpace(setting.RoomURL)
+	if roomURL == "" {
+		roomURL = fmt.Sprintf("https://live.bilibili.com/%d", change.RoomID)
+	}
+	summary := fmt.Sprintf("Room %d %s", change.RoomID, describeKind(change))
+	lines := []string{
+		summary + ".",
+		"",
+		"Room: " + roomURL,
+		fmt.Sprintf("Status: %s -> %s", liveStatusText(change.FromStatus), liveStatusText(change.ToStatus)),
+		"Title: " + change.Title,
+	}
+	if change.PreviousTitle != change.Title {
+		lines = append(lines, "Previous title: "+change.PreviousTitle)
+	}
+	if change.Unexpected {
+		lines = append(lines, "No channel of this app stopped the stream.")
+	}
+	lines = append(lines, "Time: "+change.CreatedAt.Local().Format("2006-01-02 15:04:05 MST"))
+	return "[Gover] " + summary, strings.Join(lines, "\n")
+}
+
+func describeKind(change store.MonitorStateChange) string {
+	switch change.Kind {
+	case store.MonitorChangeStarted:
+		return "went live"
+	case store.MonitorChangeTitle:
+		return "changed its title"
+	}
+	if change.Unexpected {
+		return "went offline unexpectedly"
+	}
+	return "went offline"
+}
+
+// liveStatusText names a live_status of the room info API.
+func liveStatusText(status int) string {
+	switch status {
+	case 1:
+		return "live"
+	case 2:
+		return "replaying"
+	default:
+		return "offline"
+	}
+}
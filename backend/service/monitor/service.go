@@ -14,6 +14,8 @@ import (
 	"sync"
 	"time"
 
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/events"
 	"bilibililivetools/gover/backend/store"
 )
 
@@ -24,21 +26,65 @@ type RuntimeLog struct {
 }
 
 type Service struct {
-	store  *store.Store
-	buffer int
+	store     *store.Store
+	bili      bilibili.Service
+	buffer    int
+	events    *events.Hub
+	pushProbe func(ctx context.Context, roomID int64) (bool, bool)
+	wake      chan struct{}
 
-	mu   sync.RWMutex
-	logs []RuntimeLog
+	mu     sync.RWMutex
+	logs   []RuntimeLog
+	cancel context.CancelFunc
+	room   roomWatch
 }
 
-func New(storeDB *store.Store, logBuffer int) *Service {
+func New(storeDB *store.Store, bili bilibili.Service, logBuffer int) *Service {
 	if logBuffer <= 0 {
 		logBuffer = 200
 	}
 	return &Service{
 		store:  storeDB,
+		bili:   bili,
 		buffer: logBuffer,
+		wake:   make(chan struct{}, 1),
 		logs:   make([]RuntimeLog, 0, logBuffer),
+		room:   roomWatch{notifiedAt: map[string]time.Time{}},
+	}
+}
+
+// SetEvents makes every log line also go to hub. Call it before the service is used.
+func (s *Service) SetEvents(hub *events.Hub) {
+	s.events = hub
+}
+
+// SetPushProbe tells the room monitor whether channels of this app push to a room (bound) and whether
+// one of them is pushing right now, which tells a deliberate stop from an unexpected offline. Without a
+// probe every offline is unexpected. Call it before Start.
+func (s *Service) SetPushProbe(fn func(ctx context.Context, roomID int64) (bool, bool)) {
+	s.pushProbe = fn
+}
+
+func (s *Service) Start() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	go s.roomLoop(ctx)
+}
+
+func (s *Service) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
 	}
 }
 
@@ -71,6 +117,7 @@ func (s *Service) logf(level string, format string, args ...any) {
 		s.logs = s.logs[len(s.logs)-s.buffer:]
 	}
 	s.mu.Unlock()
+	s.events.Publish(events.TopicMonitorLog, 0, entry)
 
 	switch level {
 	case "ERROR":
@@ -158,6 +205,27 @@ func (s *Service) SendTestEmail(ctx context.Context, subject string, body string
 	}, nil
 }
 
+// SendNotice emails the configured receivers with the monitor's SMTP setting, for other services that
+// alert by mail.
+func (s *Service) SendNotice(ctx context.Context, subject string, body string) error {
+	setting, err := s.store.GetMonitorSetting(ctx)
+	if err != nil {
+		return err
+	}
+	if !setting.IsEnableEmailNotice {
+		return errors.New("email notice is disabled")
+	}
+	receivers := normalizeReceivers(setting.Receivers)
+	if len(receivers) == 0 {
+		return errors.New("no email receivers configured")
+	}
+	if err := s.sendEmailSMTP(ctx, *setting, subject, body, receivers); err != nil {
+		s.Errorf("email %q failed smtp=%s:%d err=%v", subject, setting.SMTPServer, setting.SMTPPort, err)
+		return err
+	}
+	return nil
+}
+
 func (s *Service) sendEmailSMTP(ctx context.Context, setting store.MonitorSetting, subject string, body string, receivers []string) error {
 	host := strings.TrimSpace(setting.SMTPServer)
 	if host == "" {
@@ -0,0 +1,256 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// cronSearchLimit bounds Next for expressions that can never match (e.g. "0 0 31 2 *").
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// cronSpec is a parsed five-field expression: minute hour day-of-month month day-of-week.
+type cronSpec struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+	spec := &cronSpec{}
+	var err error
+	if spec.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("cron minute: %w", err)
+	}
+	if spec.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("cron hour: %w", err)
+	}
+	if spec.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("cron day of month: %w", err)
+	}
+	if spec.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("cron month: %w", err)
+	}
+	// 7 is accepted as Sunday, like most cron implementations.
+	if spec.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("cron day of week: %w", err)
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domStar = strings.HasPrefix(fields[2], "*")
+	spec.dowStar = strings.HasPrefix(fields[4], "*")
+	return spec, nil
+}
+
+// parseCronField supports "*", "a", "a-b", "*/n", "a-b/n" and comma separated lists of them.
+func parseCronField(field string, min int, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = value
+		}
+		low, high := min, max
+		if rangePart != "*" {
+			lowText, highText, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(lowText, names); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseCronValue(highText, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(text string, names map[string]int) (int, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if value, ok := names[text]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	return value, nil
+}
+
+// Next returns the first matching minute after t in t's location, or the zero time when none exists.
+func (c *cronSpec) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows classic cron: when both day fields are restricted, either one may match.
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseClock parses "HH:MM".
+func parseClock(text string) (int, int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q, expected HH:MM", text)
+	}
+	return parsed.Hour(), parsed.Minute(), nil
+}
+
+// windowEvents lists window start/end edges in (from, to], oldest first.
+func windowEvents(windows []store.PushScheduleWindow, from time.Time, to time.Time, loc *time.Location) []occurrence {
+	events := make([]occurrence, 0)
+	localFrom := from.In(loc)
+	// Start one day early so a window that began yesterday still reports today's end edge.
+	day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day()-1, 0, 0, 0, 0, loc)
+	for !day.After(to) {
+		for _, window := range windows {
+			start, end, ok := windowBounds(window, day)
+			if !ok {
+				continue
+			}
+			if start.After(from) && !start.After(to) {
+				events = append(events, occurrence{At: start, Trigger: triggerWindowStart})
+			}
+			if !end.IsZero() && end.After(from) && !end.After(to) {
+				events = append(events, occurrence{At: end, Trigger: triggerWindowEnd})
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return events
+}
+
+// openWindowStart returns the start of the latest window with an end that is open at now. Windows
+// without an end only mark a moment, so they are never open.
+func openWindowStart(windows []store.PushScheduleWindow, now time.Time, loc *time.Location) (time.Time, bool) {
+	local := now.In(loc)
+	var latest time.Time
+	for offset := -1; offset <= 0; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		for _, window := range windows {
+			start, end, ok := windowBounds(window, day)
+			if !ok || end.IsZero() || start.After(now) || !end.After(now) {
+				continue
+			}
+			if start.After(latest) {
+				latest = start
+			}
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// windowBounds places a window on day (midnight in the schedule's location). end is zero for a window
+// without an end and falls on the next day when it is not after the start. ok is false when the window
+// does not run on that weekday or its times do not parse.
+func windowBounds(window store.PushScheduleWindow, day time.Time) (start time.Time, end time.Time, ok bool) {
+	if !containsDay(window.Days, int(day.Weekday())) {
+		return time.Time{}, time.Time{}, false
+	}
+	startHour, startMinute, err := parseClock(window.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start = time.Date(day.Year(), day.Month(), day.Day(), startHour, startMinute, 0, 0, day.Location())
+	if strings.TrimSpace(window.End) == "" {
+		return start, time.Time{}, true
+	}
+	endHour, endMinute, err := parseClock(window.End)
+	if err != nil {
+		// A window whose end does not parse still fires its start.
+		return start, time.Time{}, true
+	}
+	end = time.Date(day.Year(), day.Month(), day.Day(), endHour, endMinute, 0, 0, day.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, true
+}
+
+func containsDay(days []int, day int) bool {
+	for _, item := range days {
+		if item == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+var everyDay = []int{0, 1, 2, 3, 4, 5, 6}
+
+func TestParseCron(t *testing.T) {
+	// 2026-10-16 is a Friday.
+	from := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	cases := []struct {
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{expr: "0 9 * * *", want: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2026, 10, 16, 12, 45, 0, 0, time.UTC)},
+		{expr: "0 20 * * mon-fri", want: time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)},
+		{expr: "0 10 * * 7", want: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 jan *", want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 8 13 * 1", want: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)},
+		{expr: "0 0 31 2 *", want: time.Time{}},
+		{expr: "0 9 * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "0 9 * * funday", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			spec, err := parseCron(tc.expr)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseCron(%q) succeeded", tc.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tc.expr, err)
+			}
+			if got := spec.Next(from); !got.Equal(tc.want) {
+				t.Fatalf("Next = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNextOccurrenceSkipsExceptions(t *testing.T) {
+	from := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	window := []store.PushScheduleWindow{{Days: everyDay, Start: "20:00", End: "22:00"}}
+	cases := []struct {
+		name   string
+		item   store.PushSchedule
+		action store.PushScheduleAction
+		want   time.Time
+	}{
+		{
+			name: "no exception",
+			item: store.PushSchedule{Action: store.PushScheduleActionStart, Windows: window, Timezone: "UTC"},
+			want: time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "window on an exception date",
+			item: store.PushSchedule{Action: store.PushScheduleActionStart, Windows: window, Timezone: "UTC", SkipDates: []string{"2026-10-16"}},
+			want: time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "cron on consecutive exception dates",
+			item: store.PushSchedule{Action: store.PushScheduleActionSwitchCamera, CronExpr: "0 9 * * *", Timezone: "UTC", SkipDates: []string{"2026-10-17", "2026-10-18"}},
+			want: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "skip count",
+			item: store.PushSchedule{Action: store.PushScheduleActionUpdateRoom, CronExpr: "0 9 * * *", Timezone: "UTC", SkipCount: 2},
+			want: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "next start passes the window end",
+			item:   store.PushSchedule{Action: store.PushScheduleActionStart, Windows: window, Timezone: "UTC"},
+			action: store.PushScheduleActionStart,
+			want:   time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "exception date in the schedule's timezone",
+			item: store.PushSchedule{Action: store.PushScheduleActionStart, Windows: []store.PushScheduleWindow{{Days: everyDay, Start: "02:00", End: "03:00"}}, Timezone: "Asia/Shanghai", SkipDates: []string{"2026-10-17"}},
+			// 2026-10-18 02:00 in Shanghai.
+			want: time.Date(2026, 10, 17, 18, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := nextOccurrence(tc.item, from, tc.action)
+			if !ok {
+				t.Fatalf("no next occurrence")
+			}
+			if !got.At.Equal(tc.want) {
+				t.Fatalf("next = %s (%s), want %s", got.At, got.Trigger, tc.want)
+			}
+			if got.Skipped {
+				t.Fatalf("next occurrence is skipped: %s", got.Reason)
+			}
+		})
+	}
+}
+
+func TestOpenWindowStart(t *testing.T) {
+	// 2026-10-16 is a Friday.
+	day := func(hour int, minute int) time.Time {
+		return time.Date(2026, 10, 16, hour, minute, 0, 0, time.UTC)
+	}
+	evening := store.PushScheduleWindow{Days: everyDay, Start: "20:00", End: "22:00"}
+	cases := []struct {
+		name     string
+		windows  []store.PushScheduleWindow
+		now      time.Time
+		want     time.Time
+		wantOpen bool
+	}{
+		{name: "booted inside the window", windows: []store.PushScheduleWindow{evening}, now: day(21, 15), want: day(20, 0), wantOpen: true},
+		{name: "booted at the start", windows: []store.PushScheduleWindow{evening}, now: day(20, 0), want: day(20, 0), wantOpen: true},
+		{name: "booted before the window", windows: []store.PushScheduleWindow{evening}, now: day(19, 59)},
+		{name: "booted at the end", windows: []store.PushScheduleWindow{evening}, now: day(22, 0)},
+		{name: "overnight window from yesterday", windows: []store.PushScheduleWindow{{Days: []int{4}, Start: "23:00", End: "02:00"}}, now: day(1, 30), want: time.Date(2026, 10, 15, 23, 0, 0, 0, time.UTC), wantOpen: true},
+		{name: "window on another weekday", windows: []store.PushScheduleWindow{{Days: []int{1}, Start: "20:00", End: "22:00"}}, now: day(21, 0)},
+		{name: "window without end", windows: []store.PushScheduleWindow{{Days: everyDay, Start: "20:00"}}, now: day(21, 0)},
+		{name: "latest of overlapping windows", windows: []store.PushScheduleWindow{evening, {Days: everyDay, Start: "21:00", End: "23:00"}}, now: day(21, 30), want: day(21, 0), wantOpen: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, open := openWindowStart(tc.windows, tc.now, time.UTC)
+			if open != tc.wantOpen {
+				t.Fatalf("open = %t, want %t", open, tc.wantOpen)
+			}
+			if open && !got.Equal(tc.want) {
+				t.Fatalf("start = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
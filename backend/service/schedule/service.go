@@ -0,0 +1,581 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	// Windows builds have no system zoneinfo; schedules must still resolve IANA timezones.
+	_ "time/tzdata"
+
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/stream"
+	"bilibililivetools/gover/backend/store"
+)
+
+const (
+	triggerCron        = "cron"
+	triggerWindowStart = "window_start"
+	triggerWindowEnd   = "window_end"
+	triggerManual      = "manual"
+
+	tickInterval = 15 * time.Second
+	// maxCatchUp caps how many missed firings of one schedule run after a clock jump.
+	maxCatchUp = 3
+	// nextRunLookahead is how many firings are searched for one that no exception skips.
+	nextRunLookahead = 100
+)
+
+type occurrence struct {
+	At      time.Time `json:"at"`
+	Trigger string    `json:"trigger"`
+}
+
+// Occurrence is one upcoming firing returned by Preview.
+type Occurrence struct {
+	At      time.Time                `json:"at"`
+	Trigger string                   `json:"trigger"`
+	Action  store.PushScheduleAction `json:"action"`
+	Skipped bool                     `json:"skipped"`
+	Reason  string                   `json:"reason,omitempty"`
+}
+
+// Service fires push schedules: start/stop a channel, switch its input or camera, and update the room.
+type Service struct {
+	store    *store.Store
+	stream   *stream.Registry
+	bilibili bilibili.Service
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	lastTick time.Time
+}
+
+func New(storeDB *store.Store, streamReg *stream.Registry, bili bilibili.Service) *Service {
+	return &Service{store: storeDB, stream: streamReg, bilibili: bili}
+}
+
+// Start begins firing schedules from now on. Firings missed while the service was down are not
+// replayed, except that a start window that is still open starts its channel.
+func (s *Service) Start() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	now := time.Now()
+	s.lastTick = now
+	s.mu.Unlock()
+	go func() {
+		s.resumeWindows(ctx, now)
+		s.loop(ctx)
+	}()
+}
+
+// resumeWindows fires the start edge of every open window of start schedules, unless the schedule
+// already fired for it or the channel is pushing anyway.
+func (s *Service) resumeWindows(ctx context.Context, now time.Time) {
+	items, err := s.store.ListPushSchedules(ctx)
+	if err != nil {
+		log.Printf("[schedule][warn] load schedules failed: %v", err)
+		return
+	}
+	for _, item := range items {
+		if !item.Enabled || item.Action != store.PushScheduleActionStart || strings.TrimSpace(item.CronExpr) != "" {
+			continue
+		}
+		loc, err := resolveLocation(item.Timezone)
+		if err != nil {
+			continue
+		}
+		start, open := openWindowStart(item.Windows, now, loc)
+		if !open || (item.LastRunAt != nil && !item.LastRunAt.Before(start)) {
+			continue
+		}
+		if manager, err := s.stream.Channel(ctx, item.ChannelID); err == nil && manager.Status() != store.PushStatusStopped {
+			continue
+		}
+		s.fire(ctx, item, occurrence{At: start, Trigger: triggerWindowStart})
+	}
+}
+
+func (s *Service) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (s *Service) loop(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			from := s.lastTick
+			s.lastTick = now
+			s.mu.Unlock()
+			s.tick(ctx, from, now)
+		}
+	}
+}
+
+func (s *Service) tick(ctx context.Context, from time.Time, to time.Time) {
+	items, err := s.store.ListPushSchedules(ctx)
+	if err != nil {
+		log.Printf("[schedule][warn] load schedules failed: %v", err)
+		return
+	}
+	for _, item := range items {
+		if !item.Enabled {
+			continue
+		}
+		due, err := dueOccurrences(item, from, to)
+		if err != nil {
+			log.Printf("[schedule][warn] schedule %d (%s): %v", item.ID, item.Name, err)
+			continue
+		}
+		if len(due) > maxCatchUp {
+			due = due[len(due)-maxCatchUp:]
+		}
+		for _, event := range due {
+			current, getErr := s.store.GetPushScheduleByID(ctx, item.ID)
+			if getErr != nil {
+				break
+			}
+			s.fire(ctx, *current, event)
+		}
+	}
+}
+
+// dueOccurrences lists the firings of a schedule in (from, to].
+func dueOccurrences(item store.PushSchedule, from time.Time, to time.Time) ([]occurrence, error) {
+	loc, err := resolveLocation(item.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(item.CronExpr) == "" {
+		events := windowEvents(item.Windows, from, to, loc)
+		if item.Action == store.PushScheduleActionStart {
+			return events, nil
+		}
+		// Only a start action has a meaningful window end (stop); other actions fire at the start.
+		starts := events[:0]
+		for _, event := range events {
+			if event.Trigger == triggerWindowStart {
+				starts = append(starts, event)
+			}
+		}
+		return starts, nil
+	}
+	spec, err := parseCron(item.CronExpr)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]occurrence, 0, 1)
+	for next := spec.Next(from.In(loc)); !next.IsZero() && !next.After(to); next = spec.Next(next) {
+		events = append(events, occurrence{At: next, Trigger: triggerCron})
+	}
+	return events, nil
+}
+
+func resolveLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// skipReason returns why an automatic firing is skipped, and the skip counter left afterwards.
+func skipReason(item store.PushSchedule, event occurrence, loc *time.Location) (string, int) {
+	if event.Trigger == triggerManual {
+		return "", item.SkipCount
+	}
+	date := event.At.In(loc).Format("2006-01-02")
+	for _, skipDate := range item.SkipDates {
+		if skipDate == date {
+			return "exception date " + date, item.SkipCount
+		}
+	}
+	if item.SkipCount > 0 && event.Trigger != triggerWindowEnd {
+		return fmt.Sprintf("skip next runs (%d left)", item.SkipCount-1), item.SkipCount - 1
+	}
+	return "", item.SkipCount
+}
+
+func (s *Service) fire(ctx context.Context, item store.PushSchedule, event occurrence) store.PushScheduleRun {
+	action := item.Action
+	if event.Trigger == triggerWindowEnd {
+		action = store.PushScheduleActionStop
+	}
+	run := store.PushScheduleRun{
+		ScheduleID:  item.ID,
+		Name:        item.Name,
+		ChannelID:   item.ChannelID,
+		Action:      action,
+		Trigger:     event.Trigger,
+		ScheduledAt: event.At,
+	}
+	loc, err := resolveLocation(item.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	reason, skipCount := skipReason(item, event, loc)
+	if reason != "" {
+		run.Status = "skipped"
+		run.Message = reason
+	} else if execErr := s.execute(ctx, item, action); execErr != nil {
+		run.Status = "failed"
+		run.Message = execErr.Error()
+	} else {
+		run.Status = "success"
+	}
+	run.FinishedAt = time.Now()
+	if err := s.store.MarkPushScheduleRun(ctx, item.ID, run.FinishedAt, skipCount); err != nil {
+		log.Printf("[schedule][warn] mark schedule %d failed: %v", item.ID, err)
+	}
+	if body, err := json.Marshal(run); err == nil {
+		if err := s.store.CreateLiveEvent(ctx, store.PushScheduleRunEvent, string(body)); err != nil {
+			log.Printf("[schedule][warn] save run history failed: %v", err)
+		}
+	}
+	log.Printf("[schedule] %s (#%d) %s on channel %d: %s %s", item.Name, item.ID, action, item.ChannelID, run.Status, run.Message)
+	return run
+}
+
+func (s *Service) execute(ctx context.Context, item store.PushSchedule, action store.PushScheduleAction) error {
+	switch action {
+	case store.PushScheduleActionStart:
+		if strings.TrimSpace(item.Params.Title) != "" || item.Params.AreaID > 0 {
+			if err := s.updateRoom(ctx, item); err != nil {
+				return err
+			}
+		}
+		return s.stream.Start(ctx, item.ChannelID, false)
+	case store.PushScheduleActionStop:
+		if err := s.stream.Stop(ctx, item.ChannelID); err != nil {
+			return err
+		}
+		if roomID, err := s.stream.RoomID(ctx, item.ChannelID); err == nil && roomID > 0 {
+			_ = s.bilibili.StopLive(s.stream.AccountContext(ctx, item.ChannelID), roomID)
+		}
+		return nil
+	case store.PushScheduleActionSwitchCamera:
+		return s.switchCamera(ctx, item)
+	case store.PushScheduleActionSwitchInput:
+		return s.switchInput(ctx, item)
+	case store.PushScheduleActionUpdateRoom:
+		return s.updateRoom(ctx, item)
+	default:
+		return fmt.Errorf("unsupported schedule action: %s", action)
+	}
+}
+
+func (s *Service) switchCamera(ctx context.Context, item store.PushSchedule) error {
+	camera, err := s.store.GetCameraSourceByID(ctx, item.Params.CameraID)
+	if err != nil {
+		return fmt.Errorf("camera %d not found: %w", item.Params.CameraID, err)
+	}
+	setting, err := s.store.GetPushSettingByID(ctx, item.ChannelID)
+	if err != nil {
+		return err
+	}
+	req := store.NewPushSettingUpdateRequest(setting)
+	if err := store.ApplyCameraSourceToUpdateRequest(&req, camera); err != nil {
+		return err
+	}
+	return s.savePushAndRestart(ctx, setting.ID, req)
+}
+
+func (s *Service) switchInput(ctx context.Context, item store.PushSchedule) error {
+	params := item.Params
+	if strings.TrimSpace(params.InputType) == "" {
+		return errors.New("switch_input needs params.inputType")
+	}
+	setting, err := s.store.GetPushSettingByID(ctx, item.ChannelID)
+	if err != nil {
+		return err
+	}
+	req := store.NewPushSettingUpdateRequest(setting)
+	req.InputType = params.InputType
+	if params.VideoMaterialID > 0 {
+		req.VideoID = params.VideoMaterialID
+	}
+	if strings.TrimSpace(params.RTSPURL) != "" {
+		req.RTSPURL = params.RTSPURL
+	}
+	if strings.TrimSpace(params.MJPEGURL) != "" {
+		req.MJPEGURL = params.MJPEGURL
+	}
+	if strings.TrimSpace(params.RTMPURL) != "" {
+		req.RTMPURL = params.RTMPURL
+	}
+	return s.savePushAndRestart(ctx, setting.ID, req)
+}
+
+// savePushAndRestart saves the new input and restarts the channel only when it is pushing.
+func (s *Service) savePushAndRestart(ctx context.Context, channelID int64, req store.PushSettingUpdateRequest) error {
+	if _, err := s.store.UpdatePushSettingByID(ctx, channelID, req); err != nil {
+		return err
+	}
+	manager, err := s.stream.Channel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if manager.Status() == store.PushStatusStopped {
+		return nil
+	}
+	return s.stream.Restart(ctx, channelID)
+}
+
+// updateRoom changes title and/or area of the channel's room; empty params keep the current value.
+func (s *Service) updateRoom(ctx context.Context, item store.PushSchedule) error {
+	setting, err := s.store.GetPushSettingByID(ctx, item.ChannelID)
+	if err != nil {
+		return err
+	}
+	live, err := s.store.GetLiveSetting(ctx)
+	if err != nil {
+		return err
+	}
+	bound := stream.ApplyChannelRoom(live, setting)
+	if bound.RoomID <= 0 {
+		return errors.New("channel has no live room configured")
+	}
+	title := strings.TrimSpace(item.Params.Title)
+	if title == "" {
+		title = bound.RoomName
+	}
+	areaID := item.Params.AreaID
+	if areaID <= 0 {
+		areaID = bound.AreaID
+	}
+	if err := s.bilibili.UpdateLiveRoomInfo(bilibili.WithAccount(ctx, setting.AccountID), bound.RoomID, title, areaID); err != nil {
+		return err
+	}
+	if setting.RoomID > 0 {
+		_, err = s.store.SavePushChannel(ctx, store.PushChannelSaveRequest{
+			ID:        setting.ID,
+			Name:      setting.Name,
+			RoomID:    setting.RoomID,
+			AreaID:    areaID,
+			RoomTitle: title,
+		})
+		return err
+	}
+	_, err = s.store.UpdateLiveSetting(ctx, store.RoomInfoUpdateRequest{AreaID: areaID, RoomName: title, RoomID: bound.RoomID})
+	return err
+}
+
+// Validate checks the trigger and action arguments of a schedule before it is stored.
+func Validate(req store.PushScheduleSaveRequest) error {
+	if _, err := resolveLocation(req.Timezone); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.CronExpr) != "" {
+		if _, err := parseCron(req.CronExpr); err != nil {
+			return err
+		}
+	}
+	for _, window := range req.Windows {
+		if _, _, err := parseClock(window.Start); err != nil {
+			return err
+		}
+		if strings.TrimSpace(window.End) != "" {
+			if _, _, err := parseClock(window.End); err != nil {
+				return err
+			}
+		}
+	}
+	switch store.NormalizePushScheduleAction(req.Action) {
+	case store.PushScheduleActionSwitchCamera:
+		if req.Params.CameraID <= 0 {
+			return errors.New("switch_camera needs params.cameraId")
+		}
+	case store.PushScheduleActionSwitchInput:
+		if strings.TrimSpace(req.Params.InputType) == "" {
+			return errors.New("switch_input needs params.inputType")
+		}
+	case store.PushScheduleActionUpdateRoom:
+		if strings.TrimSpace(req.Params.Title) == "" && req.Params.AreaID <= 0 {
+			return errors.New("update_room needs params.title or params.areaId")
+		}
+	}
+	return nil
+}
+
+func (s *Service) List(ctx context.Context) ([]store.PushSchedule, error) {
+	items, err := s.store.ListPushSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range items {
+		s.fillNextRun(&items[idx])
+	}
+	return items, nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*store.PushSchedule, error) {
+	item, err := s.store.GetPushScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.fillNextRun(item)
+	return item, nil
+}
+
+func (s *Service) Save(ctx context.Context, req store.PushScheduleSaveRequest) (*store.PushSchedule, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetPushSettingByID(ctx, req.ChannelID); err != nil {
+		return nil, fmt.Errorf("push channel %d not found: %w", req.ChannelID, err)
+	}
+	item, err := s.store.SavePushSchedule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.fillNextRun(item)
+	return item, nil
+}
+
+func (s *Service) Delete(ctx context.Context, ids []int64) (int64, error) {
+	return s.store.DeletePushSchedules(ctx, ids)
+}
+
+// RunNow fires a schedule immediately, ignoring exceptions.
+func (s *Service) RunNow(ctx context.Context, id int64) (store.PushScheduleRun, error) {
+	item, err := s.store.GetPushScheduleByID(ctx, id)
+	if err != nil {
+		return store.PushScheduleRun{}, err
+	}
+	return s.fire(ctx, *item, occurrence{At: time.Now(), Trigger: triggerManual}), nil
+}
+
+// Preview lists the next count firings of a (possibly unsaved) schedule, marking the ones exceptions skip.
+func (s *Service) Preview(req store.PushScheduleSaveRequest, count int) ([]Occurrence, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		count = 10
+	}
+	if count > 100 {
+		count = 100
+	}
+	item := store.PushSchedule{
+		Action:    store.NormalizePushScheduleAction(req.Action),
+		CronExpr:  req.CronExpr,
+		Windows:   req.Windows,
+		Timezone:  req.Timezone,
+		SkipDates: req.SkipDates,
+		SkipCount: req.SkipCount,
+	}
+	return previewOccurrences(item, time.Now(), count)
+}
+
+// previewOccurrences lists the next count firings of item after from.
+func previewOccurrences(item store.PushSchedule, from time.Time, count int) ([]Occurrence, error) {
+	loc, err := resolveLocation(item.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Occurrence, 0, count)
+	// Walk forward a week at a time so sparse cron expressions and windows use the same code path.
+	for horizon := 0; len(result) < count && horizon < 53*5; horizon++ {
+		to := from.AddDate(0, 0, 7)
+		events, err := dueOccurrences(item, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if len(result) >= count {
+				break
+			}
+			action := item.Action
+			if event.Trigger == triggerWindowEnd {
+				action = store.PushScheduleActionStop
+			}
+			reason, skipCount := skipReason(item, event, loc)
+			item.SkipCount = skipCount
+			result = append(result, Occurrence{
+				At:      event.At.In(loc),
+				Trigger: event.Trigger,
+				Action:  action,
+				Skipped: reason != "",
+				Reason:  reason,
+			})
+		}
+		from = to
+	}
+	return result, nil
+}
+
+// nextOccurrence returns the first firing of item after from that no exception skips; action, when set,
+// only accepts firings performing it.
+func nextOccurrence(item store.PushSchedule, from time.Time, action store.PushScheduleAction) (Occurrence, bool) {
+	occurrences, err := previewOccurrences(item, from, nextRunLookahead)
+	if err != nil {
+		return Occurrence{}, false
+	}
+	for _, occurrence := range occurrences {
+		if occurrence.Skipped || (action != "" && occurrence.Action != action) {
+			continue
+		}
+		return occurrence, true
+	}
+	return Occurrence{}, false
+}
+
+// NextStart returns when an enabled schedule next starts a channel, for services that prepare for it.
+func (s *Service) NextStart(ctx context.Context) (time.Time, bool) {
+	items, err := s.store.ListPushSchedules(ctx)
+	if err != nil {
+		return time.Time{}, false
+	}
+	now := time.Now()
+	var next time.Time
+	for _, item := range items {
+		if !item.Enabled || item.Action != store.PushScheduleActionStart {
+			continue
+		}
+		occurrence, ok := nextOccurrence(item, now, store.PushScheduleActionStart)
+		if ok && (next.IsZero() || occurrence.At.Before(next)) {
+			next = occurrence.At
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// History returns the newest run records, optionally of a single schedule and/or channel.
+func (s *Service) History(ctx context.Context, scheduleID int64, channelID int64, limit int) ([]store.PushScheduleRun, error) {
+	return s.store.ListPushScheduleRuns(ctx, scheduleID, channelID, limit)
+}
+
+// fillNextRun sets NextRunAt to the first firing that exceptions do not skip.
+func (s *Service) fillNextRun(item *store.PushSchedule) {
+	if item == nil || !item.Enabled {
+		return
+	}
+	if next, ok := nextOccurrence(*item, time.Now(), ""); ok {
+		item.NextRunAt = &next.At
+	}
+}
@@ -0,0 +1,320 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// ErrAudioMixChange is returned for an audio mix change that can not apply: an unknown source, neither a
+// gain nor a mute switch, or a running push without the local relay, which would have to drop the
+// Bilibili connection to apply it.
+var ErrAudioMixChange = errors.New("invalid audio mix change")
+
+// audioMixRestartDelay collects the changes of a quick series (a fader, repeated bot commands) into a
+// single input restart.
+const audioMixRestartDelay = 2 * time.Second
+
+// audioMixFormat is the common format every mix source is converted to before mixing.
+const audioMixFormat = "aresample=48000,aformat=sample_fmts=fltp:channel_layouts=stereo"
+
+// audioMixEnabled reports whether the normal-mode command mixes its audio instead of picking one source.
+func audioMixEnabled(ctx BuildContext) bool {
+	return ctx.Setting != nil && ctx.Setting.Model != store.ConfigModelAdvance && ctx.Setting.AudioMix.Enabled
+}
+
+// audioDeviceInputArgs captures an audio device with dshow on Windows and ALSA elsewhere.
+func audioDeviceInputArgs(deviceName string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"-f", "dshow", "-i", fmt.Sprintf("audio=%q", deviceName)}
+	}
+	return []string{"-f", "alsa", "-i", deviceName}
+}
+
+// appendAudioMix turns the audio the builder picked for the input (its -map and a playlist's -af) into
+// one source of a mix with the music playlist and the capture device, mapped as [aout]. It returns
+// whether the command still carries audio. A filter graph can not take an optional stream, so an input
+// without audio (hasAudio false) or a muted one is stood in for by silence.
+func appendAudioMix(args []string, ctx BuildContext, hasAudio bool) ([]string, bool) {
+	mix := ctx.Setting.AudioMix
+	inputFilter := ""
+	if at := indexOfArg(args, "-af"); at >= 0 && at+1 < len(args) {
+		inputFilter = args[at+1] + ","
+		args = append(args[:at], args[at+2:]...)
+	}
+	inputLabel := ""
+	audioMapAt := -1
+	for idx := 0; idx+1 < len(args); idx++ {
+		if args[idx] == "-map" && strings.Contains(args[idx+1], ":a") {
+			audioMapAt = idx
+			break
+		}
+	}
+	if audioMapAt >= 0 {
+		if hasAudio {
+			inputLabel = "[" + strings.TrimSuffix(args[audioMapAt+1], "?") + "]"
+		}
+		args = append(args[:audioMapAt], args[audioMapAt+2:]...)
+	} else if !containsArg(args, "-map") {
+		// ffmpeg picked the streams itself; the mix needs them spelled out.
+		args = append(args, "-map", "0:v:0")
+		if hasAudio {
+			inputLabel = "[0:a:0]"
+		}
+	}
+	if mix.Input.Muted {
+		inputLabel = ""
+	}
+
+	useDevice := mix.DeviceName != "" && !mix.Device.Muted
+	useMusic := ctx.MusicList != "" && !mix.Music.Muted
+	if inputLabel == "" && !useDevice && !useMusic {
+		return args, false
+	}
+
+	nextInput := countArg(args, "-i")
+	extraInputs := make([]string, 0, 16)
+	parts := make([]string, 0, 8)
+	voices := make([]string, 0, 2)
+	if inputLabel != "" {
+		parts = append(parts, fmt.Sprintf("%s%svolume=%sdB,%s[amin]", inputLabel, inputFilter, formatFilterNumber(mix.Input.GainDB), audioMixFormat))
+	} else {
+		extraInputs = append(extraInputs, "-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=48000")
+		parts = append(parts, fmt.Sprintf("[%d:a:0]%s[amin]", nextInput, audioMixFormat))
+		nextInput++
+	}
+	voices = append(voices, "[amin]")
+	if useDevice {
+		extraInputs = append(extraInputs, audioDeviceInputArgs(mix.DeviceName)...)
+		parts = append(parts, fmt.Sprintf("[%d:a:0]volume=%sdB,%s[amdev]", nextInput, formatFilterNumber(mix.Device.GainDB), audioMixFormat))
+		voices = append(voices, "[amdev]")
+		nextInput++
+	}
+	music := ""
+	if useMusic {
+		extraInputs = append(extraInputs, "-stream_loop", "-1", "-f", "concat", "-safe", "0", "-i", ctx.MusicList)
+		parts = append(parts, fmt.Sprintf("[%d:a:0]volume=%sdB,%s[ammus]", nextInput, formatFilterNumber(mix.Music.GainDB), audioMixFormat))
+		music = "[ammus]"
+	}
+	voice := voices[0]
+	if len(voices) == 2 {
+		parts = append(parts, "[amin][amdev]amix=inputs=2:duration=first:dropout_transition=0:normalize=0[amvoice]")
+		voice = "[amvoice]"
+	}
+	mixed := voice
+	if music != "" {
+		if mix.Ducking {
+			// The voice keys a compressor on the music, which sinks while someone talks.
+			parts = append(parts,
+				voice+"asplit=2[amvmix][amvkey]",
+				fmt.Sprintf("%s[amvkey]sidechaincompress=threshold=%s:ratio=%s:attack=20:release=400[amduck]",
+					music, formatFilterNumber(math.Pow(10, mix.DuckThresholdDB/20)), formatFilterNumber(mix.DuckRatio)))
+			voice, music = "[amvmix]", "[amduck]"
+		}
+		parts = append(parts, voice+music+"amix=inputs=2:duration=first:dropout_transition=0:normalize=0[ammix]")
+		mixed = "[ammix]"
+	}
+	final := "anull"
+	if mix.TargetLUFS != 0 {
+		// loudnorm upsamples to 192kHz internally.
+		final = fmt.Sprintf("loudnorm=I=%s:TP=-1.5:LRA=11,aresample=48000", formatFilterNumber(mix.TargetLUFS))
+	}
+	parts = append(parts, mixed+final+"[aout]")
+	graph := strings.Join(parts, ";")
+
+	// Input options must come before the first output option.
+	if len(extraInputs) > 0 {
+		mapAt := indexOfArg(args, "-map")
+		args = append(args[:mapAt], append(extraInputs, args[mapAt:]...)...)
+	}
+	if at := indexOfArg(args, "-filter_complex"); at >= 0 && at+1 < len(args) {
+		args[at+1] += ";" + graph
+	} else {
+		mapAt := indexOfArg(args, "-map")
+		args = append(args[:mapAt], append([]string{"-filter_complex", graph}, args[mapAt:]...)...)
+	}
+	return append(args, "-map", "[aout]"), true
+}
+
+func formatFilterNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// prepareMusicList writes the ffmpeg concat list of the audio mix's music playlist and returns its
+// path, "" when the mix plays no music. A preview writes its own list under the temp directory, so the
+// list of a running push is never replaced.
+func (m *Manager) prepareMusicList(ctx context.Context, setting *store.PushSetting, preview bool) (string, error) {
+	if setting.Model == store.ConfigModelAdvance || !setting.AudioMix.Enabled || setting.AudioMix.Music.Muted {
+		return "", nil
+	}
+	ids := setting.AudioMix.MusicMaterialIDs
+	if len(ids) == 0 && setting.AudioMaterialID != nil && *setting.AudioMaterialID > 0 {
+		ids = []int64{*setting.AudioMaterialID}
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	var list strings.Builder
+	for _, id := range ids {
+		material, err := m.store.GetMaterialByID(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("music material %d not found: %w", id, err)
+		}
+		path, err := filepath.Abs(filepath.Join(m.mediaDir, filepath.FromSlash(material.Path)))
+		if err != nil {
+			return "", err
+		}
+		list.WriteString("file '" + strings.ReplaceAll(filepath.ToSlash(path), "'", `'\''`) + "'\n")
+	}
+	dir, name := os.TempDir(), fmt.Sprintf("music-%d.txt", m.channelID)
+	if preview {
+		name = fmt.Sprintf("gover-preview-music-%d.txt", m.channelID)
+	} else if m.dataDir != "" {
+		dir = filepath.Join(m.dataDir, "audio-mix")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	listPath := filepath.Join(dir, name)
+	if err := os.WriteFile(listPath, []byte(list.String()), 0o644); err != nil {
+		return "", err
+	}
+	return listPath, nil
+}
+
+// probeInputSilent asks ffprobe whether the input the audio mix takes its voice from has no audio
+// stream. The mix can only reference streams that exist, while the plain command maps the input's audio
+// optionally. MJPEG never carries audio; devices, the test card and inputs that can not be probed count
+// as having audio. The answer is kept per input until the channel is started again, so retries and
+// failover switches do not open another session on the camera. A preview probes without logging.
+func (m *Manager) probeInputSilent(ctx context.Context, setting *store.PushSetting, videoMaterial *store.Material, preview bool) bool {
+	if !audioMixEnabled(BuildContext{Setting: setting}) || setting.IsMute || setting.AudioMix.Input.Muted || setting.MultiInputEnabled ||
+		setting.InputType == store.InputTypeMJPEG {
+		return false
+	}
+	source, options, remote := remoteInputSource(setting)
+	if !remote {
+		if setting.InputType != store.InputTypeVideo || videoMaterial == nil {
+			return false
+		}
+		source = filepath.Join(m.mediaDir, filepath.FromSlash(videoMaterial.Path))
+	}
+	if source == "" {
+		return false
+	}
+	m.mu.RLock()
+	silent, cached := m.silentInputs[source]
+	m.mu.RUnlock()
+	if cached {
+		return silent
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, primaryProbeTimeout)
+	defer cancel()
+	result, err := m.ffmpeg.ProbeSource(probeCtx, source, options...)
+	if err != nil {
+		// Not cached: the input may be down, and the next attempt should look again.
+		return false
+	}
+	silent = true
+	for _, stream := range result.Streams {
+		if stream.CodecType == "audio" {
+			silent = false
+			break
+		}
+	}
+	m.mu.Lock()
+	if m.silentInputs == nil {
+		m.silentInputs = make(map[string]bool)
+	}
+	m.silentInputs[source] = silent
+	m.mu.Unlock()
+	if silent && !preview {
+		m.addLog("Info", "audio mix: the input has no audio stream, mixing silence in its place")
+	}
+	return silent
+}
+
+// SetAudioMixSource changes the gain and/or mute switch of one source of a channel's audio mix.
+func (r *Registry) SetAudioMixSource(ctx context.Context, channelID int64, source string, gainDB *float64, muted *bool) (*store.PushAudioMix, error) {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.SetAudioMixSource(ctx, source, gainDB, muted)
+}
+
+// SetAudioMixSource saves a new gain and/or mute switch for one source of the audio mix. ffmpeg can not
+// change its filter graph while running, so a running push restarts its input once the changes settle;
+// the local relay keeps the Bilibili connection up meanwhile. Without the relay a running push refuses
+// the change.
+func (m *Manager) SetAudioMixSource(ctx context.Context, source string, gainDB *float64, muted *bool) (*store.PushAudioMix, error) {
+	if gainDB == nil && muted == nil {
+		return nil, fmt.Errorf("%w: gainDb or muted is required", ErrAudioMixChange)
+	}
+	setting, err := m.loadSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	live := m.cmd != nil && m.relay == nil && setting.AudioMix.Enabled
+	m.mu.RUnlock()
+	if live {
+		return nil, fmt.Errorf("%w: the push runs without the local relay and would drop the live connection, enable the relay or stop the push first", ErrAudioMixChange)
+	}
+	req := store.NewPushSettingUpdateRequest(setting)
+	mix := setting.AudioMix
+	mix.MusicMaterialIDs = append([]int64(nil), mix.MusicMaterialIDs...)
+	target := mix.Source(source)
+	if target == nil {
+		return nil, fmt.Errorf("%w: unknown source %q (input, music or device)", ErrAudioMixChange, source)
+	}
+	if gainDB != nil {
+		target.GainDB = *gainDB
+	}
+	if muted != nil {
+		target.Muted = *muted
+	}
+	req.AudioMix = &mix
+	saved, err := m.store.UpdatePushSettingByID(ctx, setting.ID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := saved.AudioMix.Source(source)
+	m.addLog("Info", fmt.Sprintf("audio mix: %s gain %sdB, muted %t", strings.ToLower(strings.TrimSpace(source)), formatFilterNumber(applied.GainDB), applied.Muted))
+	if saved.AudioMix.Enabled {
+		m.mu.Lock()
+		if m.cmd != nil {
+			if m.mixRestart != nil {
+				m.mixRestart.Stop()
+			}
+			m.mixRestart = time.AfterFunc(audioMixRestartDelay, m.restartForAudioMix)
+		}
+		m.mu.Unlock()
+	}
+	return &saved.AudioMix, nil
+}
+
+// restartForAudioMix ends the running input ffmpeg so the loop starts it again with the saved mix.
+func (m *Manager) restartForAudioMix() {
+	m.mu.Lock()
+	m.mixRestart = nil
+	cmd := m.cmd
+	if cmd != nil {
+		m.sourceSwitched = true
+	}
+	m.mu.Unlock()
+	if cmd != nil && cmd.Process != nil {
+		m.addLog("Info", "audio mix: restarting the input to apply the mix")
+		_ = cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+func TestBuildCommandAudioMixInputAudio(t *testing.T) {
+	cases := []struct {
+		name        string
+		inputType   store.InputType
+		silent      bool
+		wantInput   bool
+		wantSilence bool
+	}{
+		{name: "rtsp with audio", inputType: store.InputTypeRTSP, wantInput: true},
+		{name: "silent rtsp", inputType: store.InputTypeRTSP, silent: true, wantSilence: true},
+		{name: "rtmp with audio", inputType: store.InputTypeRTMP, wantInput: true},
+		{name: "silent rtmp", inputType: store.InputTypeRTMP, silent: true, wantSilence: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setting := store.PushSetting{
+				InputType: tc.inputType,
+				RTSPURL:   "rtsp://camera/stream",
+				RTMPURL:   "rtmp://origin/live",
+				AudioMix:  store.PushAudioMix{Enabled: true},
+			}
+			_, args, err := BuildCommand(BuildContext{
+				Setting:     &setting,
+				StreamURL:   "rtmp://bilibili/live",
+				FFmpegPath:  "ffmpeg",
+				MusicList:   "music.txt",
+				InputSilent: tc.silent,
+			})
+			if err != nil {
+				t.Fatalf("BuildCommand: %v", err)
+			}
+			at := indexOfArg(args, "-filter_complex")
+			if at < 0 {
+				t.Fatalf("no filter graph: %q", args)
+			}
+			graph := args[at+1]
+			if got := strings.Contains(graph, "[0:a:0]"); got != tc.wantInput {
+				t.Fatalf("graph uses the input audio = %t, want %t: %s", got, tc.wantInput, graph)
+			}
+			if got := containsArg(args, "anullsrc=channel_layout=stereo:sample_rate=48000"); got != tc.wantSilence {
+				t.Fatalf("command has the silence input = %t, want %t: %q", got, tc.wantSilence, args)
+			}
+			for idx, arg := range args {
+				if arg == "-map" && strings.HasSuffix(args[idx+1], "?") {
+					t.Fatalf("optional map left next to the mix: %q", args)
+				}
+			}
+			if !containsArg(args, "[aout]") {
+				t.Fatalf("mix output is not mapped: %q", args)
+			}
+		})
+	}
+}
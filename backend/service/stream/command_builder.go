@@ -21,19 +21,37 @@ type BuildContext struct {
 	VideoMaterial *store.Material
 	AudioMaterial *store.Material
 	FFmpegPath    string
+	// RelayURL turns the normal-mode command into the input stage of the local relay.
+	RelayURL string
+	// Overlays are drawn over the video in normal mode, bottom layer first.
+	Overlays []OverlayLayer
+	// RecordingDir adds the DVR segment output when the setting records.
+	RecordingDir string
+	// EncoderProfile replaces the built-in quality levels when the setting references one.
+	EncoderProfile *store.EncoderProfile
+	// FFprobePath, DataDir and Cameras are only read by advanced-mode command templates.
+	FFprobePath string
+	DataDir     string
+	Cameras     map[int64]*store.CameraSource
+	// MusicList is the concat list of the audio mix's background music, see Manager.prepareMusicList.
+	MusicList string
+	// InputSilent is set when the audio mix found no audio stream in the input, see Manager.probeInputSilent.
+	InputSilent bool
 }
 
 func BuildCommand(ctx BuildContext) (string, []string, error) {
 	if ctx.Setting == nil {
 		return "", nil, errors.New("missing push setting")
 	}
-	if strings.TrimSpace(ctx.StreamURL) == "" {
-		return "", nil, errors.New("missing stream url")
-	}
-
 	if ctx.Setting.Model == store.ConfigModelAdvance {
+		if strings.TrimSpace(ctx.StreamURL) == "" {
+			return "", nil, errors.New("missing stream url")
+		}
 		return buildAdvanceCommand(ctx)
 	}
+	if strings.TrimSpace(ctx.StreamURL) == "" && ctx.RelayURL == "" {
+		return "", nil, errors.New("missing stream url")
+	}
 	return buildNormalCommand(ctx)
 }
 
@@ -42,7 +60,10 @@ func buildAdvanceCommand(ctx BuildContext) (string, []string, error) {
 	if cmdLine == "" {
 		return "", nil, errors.New("ffmpeg command is empty")
 	}
-	cmdLine = strings.ReplaceAll(cmdLine, "{URL}", ctx.StreamURL)
+	cmdLine, err := renderCommandTemplate(ctx, cmdLine)
+	if err != nil {
+		return "", nil, err
+	}
 	parts, err := splitCommandLine(cmdLine)
 	if err != nil {
 		return "", nil, err
@@ -62,58 +83,28 @@ func buildNormalCommand(ctx BuildContext) (string, []string, error) {
 	args = append(args, "-hide_banner")
 	forceVideoTranscode := false
 	addOutput := func(hasAudio bool) {
-		codec := strings.TrimSpace(ctx.Setting.CustomVideoCodec)
-		if codec == "" {
-			codec = "libx264"
+		if len(ctx.Overlays) > 0 {
+			forceVideoTranscode = true
+			args = appendOverlayFilters(args, ctx)
 		}
-		useCopy := ctx.Setting.OutputQuality == store.OutputQualityOriginal && !forceVideoTranscode
-		if useCopy {
-			args = append(args, "-c:v", "copy")
-		} else {
-			targetQuality := ctx.Setting.OutputQuality
-			if targetQuality == store.OutputQualityOriginal {
-				// MJPEG/USB/desktop/mosaic pipelines cannot stream-copy to FLV reliably.
-				targetQuality = store.OutputQualityMedium
-			}
-			quality := qualityPreset(targetQuality)
-			bitrateKbps := quality.BitrateKbps
-			if customBitrate := normalizeBitrateKbps(ctx.Setting.OutputBitrateKbps); customBitrate > 0 {
-				bitrateKbps = customBitrate
-			} else {
-				bitrateKbps = clampPresetBitrateForResolution(bitrateKbps, ctx.Setting.OutputResolution)
-			}
-			bitrate := fmt.Sprintf("%dk", bitrateKbps)
-			bufSize := fmt.Sprintf("%dk", bitrateKbps*2)
-			args = append(args,
-				"-vcodec", codec,
-				"-pix_fmt", "yuv420p",
-				"-r", "30",
-				"-g", "30",
-				"-keyint_min", "30",
-				"-sc_threshold", "0",
-				"-b:v", bitrate,
-				"-maxrate", bitrate,
-				"-bufsize", bufSize,
-				"-preset", quality.Preset,
-				"-crf", quality.CRF,
-				"-tune", "zerolatency",
-			)
-		}
-		if !useCopy && strings.TrimSpace(ctx.Setting.OutputResolution) != "" {
-			args = append(args, "-s", ctx.Setting.OutputResolution)
-		}
-		if strings.TrimSpace(ctx.Setting.CustomOutputParams) != "" {
-			parts, _ := splitCommandLine(ctx.Setting.CustomOutputParams)
-			args = append(args, parts...)
+		if ctx.RelayURL != "" {
+			args = appendRelayInputStageOutput(args, ctx, hasAudio)
+			return
 		}
+		args = appendVideoEncodeArgs(args, ctx.Setting, ctx.EncoderProfile, forceVideoTranscode)
 		if hasAudio {
-			args = append(args, "-acodec", "aac", "-ac", "2", "-ar", "44100", "-b:a", "128k")
+			args = appendAudioEncodeArgs(args, ctx.EncoderProfile)
 		} else {
 			args = append(args, "-an")
 		}
-		args = append(args, "-f", "flv", ctx.StreamURL)
+		args = appendOutputTargets(args, ResolveOutputTargets(ctx), hasAudio)
 	}
 
+	mixing := audioMixEnabled(ctx)
+	if mixing {
+		// The mix plays the audio material as music instead of letting it replace the input's audio.
+		ctx.AudioMaterial = nil
+	}
 	hasAudio := false
 	if ctx.Setting.MultiInputEnabled && (len(ctx.Setting.MultiInputURLs) >= 2 || len(ctx.Setting.MultiInputMeta) >= 2) {
 		forceVideoTranscode = true
@@ -123,6 +114,9 @@ func buildNormalCommand(ctx BuildContext) (string, []string, error) {
 		if ctx.Setting.IsMute && ctx.AudioMaterial == nil {
 			hasAudio = false
 		}
+		if mixing {
+			args, hasAudio = appendAudioMix(args, ctx, hasAudio)
+		}
 		addOutput(hasAudio)
 		return ctx.FFmpegPath, args, nil
 	}
@@ -163,11 +157,7 @@ func buildNormalCommand(ctx BuildContext) (string, []string, error) {
 			args = append(args, "-f", "v4l2", "-video_size", res, "-framerate", fps, "-i", deviceName)
 		}
 		if ctx.Setting.InputAudioSource == store.InputAudioSourceDevice && strings.TrimSpace(ctx.Setting.InputAudioDeviceName) != "" {
-			if runtime.GOOS == "windows" {
-				args = append(args, "-f", "dshow", "-i", fmt.Sprintf("audio=%q", ctx.Setting.InputAudioDeviceName))
-			} else {
-				args = append(args, "-f", "alsa", "-i", ctx.Setting.InputAudioDeviceName)
-			}
+			args = append(args, audioDeviceInputArgs(ctx.Setting.InputAudioDeviceName)...)
 			args = append(args, "-map", "0:v:0", "-map", "1:a:0")
 			hasAudio = true
 		} else if ctx.AudioMaterial != nil {
@@ -184,11 +174,7 @@ func buildNormalCommand(ctx BuildContext) (string, []string, error) {
 			args = append(args, "-f", "x11grab", "-framerate", "30", "-i", ":0.0")
 		}
 		if ctx.Setting.InputAudioSource == store.InputAudioSourceDevice && strings.TrimSpace(ctx.Setting.InputAudioDeviceName) != "" {
-			if runtime.GOOS == "windows" {
-				args = append(args, "-f", "dshow", "-i", fmt.Sprintf("audio=%q", ctx.Setting.InputAudioDeviceName))
-			} else {
-				args = append(args, "-f", "alsa", "-i", ctx.Setting.InputAudioDeviceName)
-			}
+			args = append(args, audioDeviceInputArgs(ctx.Setting.InputAudioDeviceName)...)
 			args = append(args, "-map", "0:v:0", "-map", "1:a:0")
 			hasAudio = true
 		} else if ctx.AudioMaterial != nil {
@@ -279,6 +265,33 @@ func buildNormalCommand(ctx BuildContext) (string, []string, error) {
 			hasAudio = true
 		}
 
+	case store.InputTypePlaylist:
+		if ctx.Setting.PlaylistID <= 0 {
+			return "", nil, errors.New("playlist is required")
+		}
+		// The playlist feeder writes item after item to stdin. Every item starts its own clock, so
+		// timestamps are regenerated from frame/sample counts like the relay output stage does.
+		forceVideoTranscode = true
+		args = append(args, "-fflags", "+genpts+discardcorrupt", "-f", "mpegts", "-i", "pipe:0")
+		if ctx.AudioMaterial != nil {
+			audioPath := filepath.Join(ctx.MediaDir, filepath.FromSlash(ctx.AudioMaterial.Path))
+			args = append(args, "-stream_loop", "-1", "-i", audioPath, "-map", "0:v:0", "-map", "1:a:0")
+			hasAudio = true
+		} else {
+			args = append(args, "-map", "0:v:0")
+			if !ctx.Setting.IsMute {
+				args = append(args, "-map", "0:a:0", "-af", "asetpts=N/SR/TB")
+				hasAudio = true
+			}
+		}
+		args = append(args, "-vf", fmt.Sprintf("setpts=N/%d/TB", outputFrameRate(ctx.EncoderProfile)))
+
+	case store.InputTypeTestCard:
+		forceVideoTranscode = true
+		args = append(args, testCardInputArgs(ctx.Setting.OutputResolution)...)
+		args = append(args, "-map", "0:v:0", "-map", "1:a:0")
+		hasAudio = true
+
 	default:
 		return "", nil, fmt.Errorf("unsupported input type: %s", ctx.Setting.InputType)
 	}
@@ -286,10 +299,146 @@ func buildNormalCommand(ctx BuildContext) (string, []string, error) {
 	if ctx.Setting.IsMute && ctx.AudioMaterial == nil && ctx.Setting.InputAudioSource != store.InputAudioSourceDevice {
 		hasAudio = false
 	}
+	if mixing {
+		if ctx.InputSilent {
+			hasAudio = false
+		}
+		args, hasAudio = appendAudioMix(args, ctx, hasAudio)
+	}
 	addOutput(hasAudio)
 	return ctx.FFmpegPath, args, nil
 }
 
+// appendVideoEncodeArgs adds the video codec, rate control, output size and custom output params of setting.
+// An encoder profile takes over codec and rate control; the channel's own bitrate still wins when set.
+func appendVideoEncodeArgs(args []string, setting *store.PushSetting, profile *store.EncoderProfile, forceVideoTranscode bool) []string {
+	codec := strings.TrimSpace(setting.CustomVideoCodec)
+	if codec == "" {
+		codec = "libx264"
+	}
+	useCopy := setting.OutputQuality == store.OutputQualityOriginal && !forceVideoTranscode
+	if useCopy && profile == nil {
+		args = append(args, "-c:v", "copy")
+	} else if profile != nil {
+		useCopy = false
+		args = appendProfileVideoArgs(args, setting, profile)
+	} else {
+		targetQuality := setting.OutputQuality
+		if targetQuality == store.OutputQualityOriginal {
+			// MJPEG/USB/desktop/mosaic pipelines cannot stream-copy to FLV reliably.
+			targetQuality = store.OutputQualityMedium
+		}
+		quality := qualityPreset(targetQuality)
+		bitrateKbps := quality.BitrateKbps
+		if customBitrate := normalizeBitrateKbps(setting.OutputBitrateKbps); customBitrate > 0 {
+			bitrateKbps = customBitrate
+		} else {
+			bitrateKbps = clampPresetBitrateForResolution(bitrateKbps, setting.OutputResolution)
+		}
+		bitrate := fmt.Sprintf("%dk", bitrateKbps)
+		bufSize := fmt.Sprintf("%dk", bitrateKbps*2)
+		args = append(args,
+			"-vcodec", codec,
+			"-pix_fmt", "yuv420p",
+			"-r", "30",
+			"-g", "30",
+			"-keyint_min", "30",
+			"-sc_threshold", "0",
+			"-b:v", bitrate,
+			"-maxrate", bitrate,
+			"-bufsize", bufSize,
+			"-preset", quality.Preset,
+			"-crf", quality.CRF,
+			"-tune", "zerolatency",
+		)
+	}
+	if !useCopy && strings.TrimSpace(setting.OutputResolution) != "" {
+		args = append(args, "-s", setting.OutputResolution)
+	}
+	if strings.TrimSpace(setting.CustomOutputParams) != "" {
+		parts, _ := splitCommandLine(setting.CustomOutputParams)
+		args = append(args, parts...)
+	}
+	return args
+}
+
+// appendProfileVideoArgs encodes with an encoder profile. Without a bitrate in the channel or the profile
+// the quality level of the channel still picks one, so CBR/VBR always have a target.
+func appendProfileVideoArgs(args []string, setting *store.PushSetting, profile *store.EncoderProfile) []string {
+	bitrateKbps := normalizeBitrateKbps(setting.OutputBitrateKbps)
+	if bitrateKbps == 0 {
+		bitrateKbps = profile.BitrateKbps
+	}
+	if bitrateKbps == 0 {
+		targetQuality := setting.OutputQuality
+		if targetQuality == store.OutputQualityOriginal {
+			targetQuality = store.OutputQualityMedium
+		}
+		bitrateKbps = clampPresetBitrateForResolution(qualityPreset(targetQuality).BitrateKbps, setting.OutputResolution)
+	}
+	gop := int(math.Round(float64(profile.FrameRate) * profile.GOPSec))
+	if gop < 1 {
+		gop = 1
+	}
+	args = append(args,
+		"-vcodec", profile.VideoCodec,
+		"-pix_fmt", profile.PixelFormat,
+		"-r", strconv.Itoa(profile.FrameRate),
+		"-g", strconv.Itoa(gop),
+		"-keyint_min", strconv.Itoa(gop),
+		"-sc_threshold", "0",
+		"-bf", strconv.Itoa(profile.BFrames),
+	)
+	switch profile.RateControl {
+	case store.EncoderRateControlCRF:
+		args = append(args, "-crf", strconv.Itoa(profile.CRF),
+			"-maxrate", fmt.Sprintf("%dk", bitrateKbps), "-bufsize", fmt.Sprintf("%dk", bitrateKbps*2))
+	case store.EncoderRateControlVBR:
+		args = append(args, "-b:v", fmt.Sprintf("%dk", bitrateKbps),
+			"-maxrate", fmt.Sprintf("%dk", bitrateKbps*3/2), "-bufsize", fmt.Sprintf("%dk", bitrateKbps*3))
+	default:
+		bitrate := fmt.Sprintf("%dk", bitrateKbps)
+		args = append(args, "-b:v", bitrate, "-minrate", bitrate, "-maxrate", bitrate, "-bufsize", fmt.Sprintf("%dk", bitrateKbps*2))
+	}
+	for _, option := range []struct{ flag, value string }{
+		{"-preset", profile.Preset},
+		{"-tune", profile.Tune},
+		{"-profile:v", profile.Profile},
+		{"-level", profile.Level},
+	} {
+		if option.value != "" {
+			args = append(args, option.flag, option.value)
+		}
+	}
+	if profile.ExtraArgs != "" {
+		parts, _ := splitCommandLine(profile.ExtraArgs)
+		args = append(args, parts...)
+	}
+	return args
+}
+
+// appendAudioEncodeArgs adds the audio codec settings of profile, or the AAC 44.1k/128k default.
+func appendAudioEncodeArgs(args []string, profile *store.EncoderProfile) []string {
+	if profile == nil {
+		return append(args, "-acodec", "aac", "-ac", "2", "-ar", "44100", "-b:a", "128k")
+	}
+	args = append(args,
+		"-acodec", profile.AudioCodec,
+		"-ac", "2",
+		"-ar", strconv.Itoa(profile.AudioSampleRate),
+		"-b:a", fmt.Sprintf("%dk", profile.AudioBitrateKbps),
+	)
+	return args
+}
+
+// outputFrameRate is the frame rate the output is encoded at.
+func outputFrameRate(profile *store.EncoderProfile) int {
+	if profile == nil || profile.FrameRate <= 0 {
+		return 30
+	}
+	return profile.FrameRate
+}
+
 type quality struct {
 	BitrateKbps int
 	Preset      string
@@ -711,9 +860,14 @@ func normalizeMultiSources(meta []store.MultiInputSource, urls []string, max int
 		if value == "" {
 			return
 		}
+		key := item.SourceKey()
+		if _, ok := seen[key]; ok {
+			return
+		}
 		if _, ok := seen[value]; ok {
 			return
 		}
+		seen[key] = struct{}{}
 		seen[value] = struct{}{}
 		item.URL = value
 		item.Title = strings.TrimSpace(item.Title)
@@ -829,6 +983,15 @@ func parseOutputResolution(raw string) (int, int) {
 	return width, height
 }
 
+// testCardInputArgs generates SMPTE bars with a silent stereo track, so the room never goes black or mute.
+func testCardInputArgs(outputResolution string) []string {
+	width, height := parseOutputResolution(outputResolution)
+	return []string{
+		"-re", "-f", "lavfi", "-i", fmt.Sprintf("smptehdbars=size=%dx%d:rate=30", width, height),
+		"-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=44100",
+	}
+}
+
 func looksLikeMJPEG(sourceURL string) bool {
 	lower := strings.ToLower(strings.TrimSpace(sourceURL))
 	if strings.Contains(lower, "mjpeg") || strings.Contains(lower, "mjpg") {
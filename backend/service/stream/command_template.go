@@ -0,0 +1,273 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// CommandTemplateVariables documents the fields an advanced-mode command can use as {{.Name}}. The legacy
+// {URL} placeholder still works and is the same as {{.URL}}.
+var CommandTemplateVariables = map[string]string{
+	"URL":         "Bilibili push URL",
+	"FFmpeg":      "ffmpeg binary path",
+	"FFprobe":     "ffprobe binary path",
+	"DataDir":     "data directory",
+	"MediaDir":    "material directory",
+	"VideoPath":   "full path of the selected video material",
+	"AudioPath":   "full path of the selected audio material",
+	"RTSPURL":     "RTSP URL of the push setting",
+	"MJPEGURL":    "MJPEG URL of the push setting",
+	"RTMPURL":     "RTMP URL of the push setting",
+	"GBPullURL":   "GB28181 pull URL of the push setting",
+	"Resolution":  "output resolution, e.g. 1280x720",
+	"Width":       "output width",
+	"Height":      "output height",
+	"BitrateKbps": "output bitrate in kbps, 0 when unset",
+	"ChannelID":   "push channel id",
+	"RoomID":      "Bilibili room id",
+}
+
+// commandTemplateFuncs are the functions besides the text/template builtins; camera takes a camera
+// source id and yields its stream URL.
+var commandTemplateFuncs = template.FuncMap{
+	"camera": func(int64) (string, error) { return "", nil },
+}
+
+// parseCommandTemplate parses an advanced-mode command with {URL} mapped to {{.URL}}.
+func parseCommandTemplate(cmdLine string, funcs template.FuncMap) (*template.Template, error) {
+	cmdLine = strings.ReplaceAll(cmdLine, "{URL}", "{{.URL}}")
+	tmpl, err := template.New("ffmpegCommand").Option("missingkey=error").Funcs(funcs).Parse(cmdLine)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ValidateCommandTemplate checks the syntax of an advanced-mode command and reports every variable that
+// does not exist.
+func ValidateCommandTemplate(cmdLine string) error {
+	tmpl, err := parseCommandTemplate(cmdLine, commandTemplateFuncs)
+	if err != nil {
+		return err
+	}
+	unknown := make([]string, 0)
+	for _, name := range commandTemplateRefs(tmpl).Fields {
+		if _, ok := CommandTemplateVariables[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown template variables: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func init() {
+	// Camera sources referenced by a command must not be deleted; the store can not parse templates.
+	store.CommandCameraIDs = CommandTemplateCameraIDs
+}
+
+// CommandTemplateCameraIDs returns the camera source ids a command references through camera.
+func CommandTemplateCameraIDs(cmdLine string) []int64 {
+	tmpl, err := parseCommandTemplate(cmdLine, commandTemplateFuncs)
+	if err != nil {
+		return nil
+	}
+	return commandTemplateRefs(tmpl).CameraIDs
+}
+
+// renderCommandTemplate fills an advanced-mode command. Values are escaped for splitCommandLine, so a path
+// with spaces or backslashes stays one argument. A variable without a value, such as VideoPath when no
+// material is selected, is an error when printed outside if/with/range; inside them it is up to the
+// condition.
+func renderCommandTemplate(ctx BuildContext, cmdLine string) (string, error) {
+	if err := ValidateCommandTemplate(cmdLine); err != nil {
+		return "", err
+	}
+	values := commandTemplateValues(ctx)
+	tmpl, err := parseCommandTemplate(cmdLine, template.FuncMap{
+		"camera": func(id int64) (string, error) {
+			camera, ok := ctx.Cameras[id]
+			if !ok || camera == nil {
+				return "", fmt.Errorf("camera source %d not found", id)
+			}
+			streamURL := camera.StreamURL()
+			if streamURL == "" {
+				return "", fmt.Errorf("camera source %d has no stream url", id)
+			}
+			return escapeCommandArg(streamURL), nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, name := range commandTemplateRefs(tmpl).Required {
+		if values[name] == "" {
+			return "", fmt.Errorf("template variable %s has no value for this channel", name)
+		}
+	}
+	// Every known variable is present, empty when unset, so if/with can test it.
+	data := make(map[string]string, len(CommandTemplateVariables))
+	for name := range CommandTemplateVariables {
+		data[name] = escapeCommandArg(values[name])
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render command template: %w", err)
+	}
+	return out.String(), nil
+}
+
+func commandTemplateValues(ctx BuildContext) map[string]string {
+	setting := ctx.Setting
+	values := map[string]string{
+		"URL":       ctx.StreamURL,
+		"FFmpeg":    ctx.FFmpegPath,
+		"FFprobe":   ctx.FFprobePath,
+		"DataDir":   ctx.DataDir,
+		"MediaDir":  ctx.MediaDir,
+		"RTSPURL":   strings.TrimSpace(setting.RTSPURL),
+		"MJPEGURL":  strings.TrimSpace(setting.MJPEGURL),
+		"RTMPURL":   strings.TrimSpace(setting.RTMPURL),
+		"GBPullURL": strings.TrimSpace(setting.GBPullURL),
+		// 0 means "no custom bitrate" and is rendered as is.
+		"BitrateKbps": strconv.Itoa(setting.OutputBitrateKbps),
+		"ChannelID":   strconv.FormatInt(setting.ID, 10),
+	}
+	width, height := parseOutputResolution(setting.OutputResolution)
+	values["Resolution"] = fmt.Sprintf("%dx%d", width, height)
+	values["Width"] = strconv.Itoa(width)
+	values["Height"] = strconv.Itoa(height)
+	if ctx.VideoMaterial != nil {
+		values["VideoPath"] = filepath.Join(ctx.MediaDir, filepath.FromSlash(ctx.VideoMaterial.Path))
+	}
+	if ctx.AudioMaterial != nil {
+		values["AudioPath"] = filepath.Join(ctx.MediaDir, filepath.FromSlash(ctx.AudioMaterial.Path))
+	}
+	if ctx.Live != nil && ctx.Live.RoomID > 0 {
+		values["RoomID"] = strconv.FormatInt(ctx.Live.RoomID, 10)
+	}
+	return values
+}
+
+type commandTemplateRefSet struct {
+	// Fields are all variables the template reads.
+	Fields []string
+	// Required are the variables printed outside any if/with/range block.
+	Required []string
+	// CameraIDs are the literal ids passed to camera.
+	CameraIDs []int64
+}
+
+// commandTemplateRefs walks the parsed template; every list comes back sorted and without duplicates.
+func commandTemplateRefs(tmpl *template.Template) commandTemplateRefSet {
+	fields := map[string]bool{}
+	required := map[string]bool{}
+	cameras := map[int64]bool{}
+	var walk func(node parse.Node, guarded bool)
+	walkBranch := func(pipe *parse.PipeNode, list *parse.ListNode, elseList *parse.ListNode) {
+		walk(pipe, true)
+		walk(list, true)
+		walk(elseList, true)
+	}
+	walk = func(node parse.Node, guarded bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, guarded)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, guarded)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, guarded)
+			}
+		case *parse.CommandNode:
+			if len(n.Args) == 2 {
+				if ident, ok := n.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "camera" {
+					if number, ok := n.Args[1].(*parse.NumberNode); ok && number.IsInt {
+						cameras[number.Int64] = true
+					}
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg, guarded)
+			}
+		case *parse.FieldNode:
+			if len(n.Ident) > 0 {
+				fields[n.Ident[0]] = true
+				if !guarded {
+					required[n.Ident[0]] = true
+				}
+			}
+		case *parse.ChainNode:
+			walk(n.Node, guarded)
+		case *parse.IfNode:
+			walkBranch(n.Pipe, n.List, n.ElseList)
+		case *parse.RangeNode:
+			walkBranch(n.Pipe, n.List, n.ElseList)
+		case *parse.WithNode:
+			walkBranch(n.Pipe, n.List, n.ElseList)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root, false)
+	}
+	cameraIDs := make([]int64, 0, len(cameras))
+	for id := range cameras {
+		cameraIDs = append(cameraIDs, id)
+	}
+	sort.Slice(cameraIDs, func(i, j int) bool { return cameraIDs[i] < cameraIDs[j] })
+	return commandTemplateRefSet{Fields: sortedKeys(fields), Required: sortedKeys(required), CameraIDs: cameraIDs}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapeCommandArg backslash-escapes the characters splitCommandLine treats specially.
+func escapeCommandArg(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch r {
+		case '\\', '"', '\'', ' ', '\t', '\n', '\r':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// loadTemplateCameras resolves the camera sources an advanced-mode command references. Missing ones are
+// left out so rendering reports them.
+func (m *Manager) loadTemplateCameras(ctx context.Context, setting *store.PushSetting) map[int64]*store.CameraSource {
+	if setting.Model != store.ConfigModelAdvance {
+		return nil
+	}
+	cameras := make(map[int64]*store.CameraSource)
+	for _, id := range CommandTemplateCameraIDs(setting.FFmpegCommand) {
+		if camera, err := m.store.GetCameraSourceByID(ctx, id); err == nil {
+			cameras[id] = camera
+		}
+	}
+	return cameras
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+func TestCommandTemplateCameraKeepsCameraSource(t *testing.T) {
+	ctx := context.Background()
+	storeDB, err := store.Open(filepath.Join(t.TempDir(), "gover.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer storeDB.Close()
+	camera, err := storeDB.SaveCameraSource(ctx, store.CameraSourceSaveRequest{Name: "stage", SourceType: "rtsp", RTSPURL: "rtsp://10.0.0.2/stream"})
+	if err != nil {
+		t.Fatalf("save camera: %v", err)
+	}
+	setting, err := storeDB.GetPushSettingByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("load channel: %v", err)
+	}
+	req := store.NewPushSettingUpdateRequest(setting)
+	req.Model = store.ConfigModelAdvance
+	req.FFmpegCommand = fmt.Sprintf("ffmpeg -rtsp_transport tcp -i {{camera %d}} -c copy -f flv {{.URL}}", camera.ID)
+	if _, err := storeDB.UpdatePushSettingByID(ctx, setting.ID, req); err != nil {
+		t.Fatalf("save channel: %v", err)
+	}
+
+	usage, err := storeDB.CameraSourceUsage(ctx, camera.ID)
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if len(usage) != 1 || usage[0].Kind != store.CameraReferenceCommand || usage[0].ChannelID != setting.ID {
+		t.Fatalf("usage = %+v, want the command of channel %d", usage, setting.ID)
+	}
+	if _, err := storeDB.DeleteCameraSources(ctx, []int64{camera.ID}); err == nil {
+		t.Fatalf("deleted a camera the command still references")
+	}
+}
@@ -0,0 +1,250 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// danmakuHistory is how many raw messages a channel keeps for its danmaku boxes; filtering happens at
+// render time so changing the blocked words re-renders what is already on screen.
+const danmakuHistory = 50
+
+type danmakuLine struct {
+	UID     int64
+	Uname   string
+	Content string
+}
+
+// danmakuFilter is the compiled blocked-word list of a danmaku box, kept until the box is saved again.
+type danmakuFilter struct {
+	updatedAt time.Time
+	patterns  []*regexp.Regexp
+}
+
+// ShowDanmaku adds a message to the danmaku boxes of the channel and rewrites their text files, which
+// a running ffmpeg picks up on the next frame.
+func (m *Manager) ShowDanmaku(ctx context.Context, uid int64, uname string, content string) error {
+	m.mu.Lock()
+	m.danmakuLines = append(m.danmakuLines, danmakuLine{UID: uid, Uname: strings.TrimSpace(uname), Content: content})
+	if len(m.danmakuLines) > danmakuHistory {
+		m.danmakuLines = append([]danmakuLine(nil), m.danmakuLines[len(m.danmakuLines)-danmakuHistory:]...)
+	}
+	m.mu.Unlock()
+
+	overlays, err := m.store.ListOverlays(ctx, m.channelID)
+	if err != nil {
+		return err
+	}
+	for _, overlay := range overlays {
+		if overlay.Type != store.OverlayTypeDanmaku || !overlay.Enabled {
+			continue
+		}
+		if err := m.RenderDanmakuOverlay(overlay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClearDanmaku forgets the recent messages of the channel and blanks its danmaku boxes.
+func (m *Manager) ClearDanmaku(ctx context.Context) error {
+	m.mu.Lock()
+	m.danmakuLines = nil
+	m.mu.Unlock()
+	setting, err := m.loadSetting(ctx)
+	if err != nil {
+		return err
+	}
+	overlays, err := m.store.ListOverlays(ctx, setting.ID)
+	if err != nil {
+		return err
+	}
+	for _, overlay := range overlays {
+		if overlay.Type == store.OverlayTypeDanmaku {
+			if err := m.RenderDanmakuOverlay(overlay); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderDanmakuOverlay writes every text file of a danmaku box from the recent messages of the channel.
+func (m *Manager) RenderDanmakuOverlay(overlay store.Overlay) error {
+	if overlay.Type != store.OverlayTypeDanmaku {
+		return nil
+	}
+	m.mu.RLock()
+	recent := append([]danmakuLine(nil), m.danmakuLines...)
+	m.mu.RUnlock()
+
+	if err := os.MkdirAll(OverlayDir(m.mediaDir), 0o755); err != nil {
+		return err
+	}
+	cfg := overlay.Danmaku
+	lines := visibleDanmakuLines(cfg, m.danmakuPatterns(overlay), recent)
+	if cfg.Mode == store.OverlayDanmakuModeScroll {
+		parts := make([]string, 0, len(lines))
+		for _, line := range lines {
+			parts = append(parts, danmakuLabel(cfg, line)+line.Content)
+		}
+		return writeTextFile(danmakuTextPath(m.mediaDir, overlay.ID, -1, -1), drawtextFileText(strings.Join(parts, "      ")))
+	}
+
+	palette := danmakuNamePalette(cfg)
+	offset := cfg.MaxLines - len(lines)
+	for slot := 0; slot < cfg.MaxLines; slot++ {
+		text, name, color := "", "", ""
+		if idx := slot - offset; idx >= 0 {
+			line := lines[idx]
+			text = danmakuLabel(cfg, line) + line.Content
+			name = line.Uname
+			color = danmakuNameColor(cfg, line.UID)
+		}
+		if err := writeTextFile(danmakuTextPath(m.mediaDir, overlay.ID, slot, -1), drawtextFileText(text)); err != nil {
+			return err
+		}
+		for colorIdx, paletteColor := range palette {
+			value := ""
+			if paletteColor == color {
+				value = name
+			}
+			if err := writeTextFile(danmakuTextPath(m.mediaDir, overlay.ID, slot, colorIdx), drawtextFileText(value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// danmakuPatterns returns the compiled blocked words of a danmaku box, compiling them again only after
+// the box was saved.
+func (m *Manager) danmakuPatterns(overlay store.Overlay) []*regexp.Regexp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, ok := m.danmakuFilters[overlay.ID]; ok && cached.updatedAt.Equal(overlay.UpdatedAt) {
+		return cached.patterns
+	}
+	patterns := make([]*regexp.Regexp, 0, len(overlay.Danmaku.BlockedWords))
+	for _, word := range overlay.Danmaku.BlockedWords {
+		patterns = append(patterns, regexp.MustCompile("(?i)"+regexp.QuoteMeta(word)))
+	}
+	if m.danmakuFilters == nil {
+		m.danmakuFilters = make(map[int64]danmakuFilter)
+	}
+	m.danmakuFilters[overlay.ID] = danmakuFilter{updatedAt: overlay.UpdatedAt, patterns: patterns}
+	return patterns
+}
+
+// visibleDanmakuLines applies the profanity filter and length limit and keeps the newest MaxLines.
+func visibleDanmakuLines(cfg store.OverlayDanmakuConfig, patterns []*regexp.Regexp, recent []danmakuLine) []danmakuLine {
+	mask := func(text string) string {
+		for _, pattern := range patterns {
+			text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+				return strings.Repeat("*", utf8.RuneCountInString(match))
+			})
+		}
+		return text
+	}
+	lines := make([]danmakuLine, 0, len(recent))
+	for _, line := range recent {
+		if cfg.DropBlocked && matchesAny(patterns, line.Content) {
+			continue
+		}
+		content := strings.Join(strings.Fields(mask(line.Content)), " ")
+		if content == "" {
+			continue
+		}
+		if runes := []rune(content); len(runes) > cfg.MaxChars {
+			content = string(runes[:cfg.MaxChars]) + "…"
+		}
+		lines = append(lines, danmakuLine{UID: line.UID, Uname: mask(line.Uname), Content: content})
+	}
+	if len(lines) > cfg.MaxLines {
+		lines = lines[len(lines)-cfg.MaxLines:]
+	}
+	return lines
+}
+
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func danmakuLabel(cfg store.OverlayDanmakuConfig, line danmakuLine) string {
+	if cfg.HideNames || line.Uname == "" {
+		return ""
+	}
+	return line.Uname + ": "
+}
+
+// danmakuNamePalette is empty when names are hidden, so no color layers are built or written.
+func danmakuNamePalette(cfg store.OverlayDanmakuConfig) []string {
+	if cfg.HideNames || cfg.Mode == store.OverlayDanmakuModeScroll {
+		return nil
+	}
+	return store.OverlayDanmakuPalette(cfg)
+}
+
+// danmakuNameColor returns the color of a user's name, or "" to keep it in the font color.
+func danmakuNameColor(cfg store.OverlayDanmakuConfig, uid int64) string {
+	if color, ok := cfg.UserColors[strconv.FormatInt(uid, 10)]; ok {
+		return color
+	}
+	if len(cfg.NameColors) == 0 || uid <= 0 {
+		return ""
+	}
+	return cfg.NameColors[uid%int64(len(cfg.NameColors))]
+}
+
+// danmakuTextPath names the files of a danmaku box: one per line (colorIdx < 0) plus one per line and
+// name color; the scroll ticker uses a single file (slot < 0).
+func danmakuTextPath(mediaDir string, id int64, slot int, colorIdx int) string {
+	name := fmt.Sprintf("danmaku-%d.txt", id)
+	if slot >= 0 && colorIdx < 0 {
+		name = fmt.Sprintf("danmaku-%d-%d.txt", id, slot)
+	} else if slot >= 0 {
+		name = fmt.Sprintf("danmaku-%d-%d-c%d.txt", id, slot, colorIdx)
+	}
+	return filepath.Join(OverlayDir(mediaDir), name)
+}
+
+// buildDanmakuFilters draws a danmaku box. Stack mode draws each line in the font color and then the
+// name again, at the same spot, in each palette color; only the file of the matching color holds the
+// name, so the user's color covers the plain one. Scroll mode moves a single ticker line from right to
+// left; it jumps when the text changes width, which is acceptable for a chat ticker.
+func buildDanmakuFilters(mediaDir string, overlay store.Overlay) []string {
+	style := overlay.Style
+	cfg := overlay.Danmaku
+	if cfg.Mode == store.OverlayDanmakuModeScroll {
+		x := fmt.Sprintf("w-mod(t*%d,w+tw)", cfg.ScrollSpeed)
+		return []string{drawtextFilter(danmakuTextPath(mediaDir, overlay.ID, -1, -1), x, style.Y, style, style.FontColor, style.Box)}
+	}
+	lineHeight := style.FontSize * 13 / 10
+	if style.Box {
+		lineHeight += 12
+	}
+	palette := danmakuNamePalette(cfg)
+	filters := make([]string, 0, cfg.MaxLines*(1+len(palette)))
+	for slot := 0; slot < cfg.MaxLines; slot++ {
+		y := fmt.Sprintf("(%s)+%d", style.Y, slot*lineHeight)
+		filters = append(filters, drawtextFilter(danmakuTextPath(mediaDir, overlay.ID, slot, -1), style.X, y, style, style.FontColor, style.Box))
+		for colorIdx, color := range palette {
+			filters = append(filters, drawtextFilter(danmakuTextPath(mediaDir, overlay.ID, slot, colorIdx), style.X, y, style, color, false))
+		}
+	}
+	return filters
+}
@@ -0,0 +1,373 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// errSourceSwitched marks an ffmpeg run that was ended on purpose to switch back to the primary input.
+var errSourceSwitched = errors.New("input source switched")
+
+// errPrimaryNotProbeable marks a local primary input (file, device, test card). It can only be tried by
+// starting it, so the probe gives up instead of failing back on every interval.
+var errPrimaryNotProbeable = errors.New("primary input is local and can not be probed, restart the channel to switch back")
+
+const primaryProbeTimeout = 15 * time.Second
+
+// failoverChain lists the sources of a channel in fallback order; the primary input is always first.
+// Advanced mode owns its whole command line, so it never fails over.
+func failoverChain(setting *store.PushSetting) []store.PushFailoverSource {
+	chain := []store.PushFailoverSource{store.PushFailoverPrimary}
+	if setting == nil || !setting.Failover.Enabled || setting.Model == store.ConfigModelAdvance {
+		return chain
+	}
+	if setting.Failover.BackupCameraID > 0 {
+		chain = append(chain, store.PushFailoverBackupCamera)
+	}
+	if setting.Failover.SlateMaterialID > 0 {
+		chain = append(chain, store.PushFailoverSlate)
+	}
+	if setting.Failover.TestCard {
+		chain = append(chain, store.PushFailoverTestCard)
+	}
+	return chain
+}
+
+// applyFailover returns the setting the next run pushes with: the stored one while the primary is active,
+// otherwise a copy whose input is the active fallback source. Sources that cannot be used are skipped.
+func (m *Manager) applyFailover(ctx context.Context, setting *store.PushSetting) *store.PushSetting {
+	chain := failoverChain(setting)
+	m.mu.Lock()
+	level := m.failoverLevel
+	m.mu.Unlock()
+	if level >= len(chain) {
+		level = len(chain) - 1
+	}
+	result := setting
+	for level > 0 {
+		applied, err := m.applyFailoverSource(ctx, setting, chain[level])
+		if err == nil {
+			result = applied
+			break
+		}
+		m.addLog("Warn", fmt.Sprintf("failover source %s skipped: %v", chain[level], err))
+		level++
+		if level >= len(chain) {
+			level = 0
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failoverLevel = level
+	if len(chain) == 1 {
+		m.failover = nil
+		return result
+	}
+	if m.failover == nil {
+		m.failover = &store.PushFailoverState{}
+	}
+	m.failover.Source = chain[level]
+	if level == 0 {
+		m.failover.Since = nil
+	}
+	return result
+}
+
+func (m *Manager) applyFailoverSource(ctx context.Context, setting *store.PushSetting, source store.PushFailoverSource) (*store.PushSetting, error) {
+	fallback := *setting
+	fallback.MultiInputEnabled = false
+	fallback.AudioMaterialID = nil
+	fallback.InputAudioSource = store.InputAudioSourceFile
+	fallback.IsMute = false
+	switch source {
+	case store.PushFailoverBackupCamera:
+		camera, err := m.store.GetCameraSourceByID(ctx, setting.Failover.BackupCameraID)
+		if err != nil {
+			return nil, fmt.Errorf("backup camera %d not found: %w", setting.Failover.BackupCameraID, err)
+		}
+		if !camera.Enabled {
+			return nil, fmt.Errorf("backup camera %d is disabled", camera.ID)
+		}
+		if err := applyCameraInput(&fallback, camera); err != nil {
+			return nil, err
+		}
+	case store.PushFailoverSlate:
+		materialID := setting.Failover.SlateMaterialID
+		if _, err := m.store.GetMaterialByID(ctx, materialID); err != nil {
+			return nil, fmt.Errorf("slate material %d not found: %w", materialID, err)
+		}
+		fallback.InputType = store.InputTypeVideo
+		fallback.VideoMaterialID = &materialID
+	case store.PushFailoverTestCard:
+		fallback.InputType = store.InputTypeTestCard
+	default:
+		return setting, nil
+	}
+	return &fallback, nil
+}
+
+// applyCameraInput points the input fields of setting at a saved camera source.
+func applyCameraInput(setting *store.PushSetting, camera *store.CameraSource) error {
+	setting.RTSPURL = ""
+	setting.MJPEGURL = ""
+	setting.RTMPURL = ""
+	setting.GBPullURL = ""
+	switch camera.SourceType {
+	case store.CameraSourceTypeRTSP:
+		setting.InputType = store.InputTypeRTSP
+		setting.RTSPURL = strings.TrimSpace(camera.RTSPURL)
+	case store.CameraSourceTypeONVIF:
+		setting.InputType = store.InputTypeONVIF
+		setting.RTSPURL = strings.TrimSpace(camera.RTSPURL)
+		setting.ONVIFEndpoint = camera.ONVIFEndpoint
+		setting.ONVIFUsername = camera.ONVIFUsername
+		setting.ONVIFPassword = camera.ONVIFPassword
+		setting.ONVIFProfileToken = camera.ONVIFProfileToken
+	case store.CameraSourceTypeMJPEG:
+		setting.InputType = store.InputTypeMJPEG
+		setting.MJPEGURL = strings.TrimSpace(camera.MJPEGURL)
+	case store.CameraSourceTypeUSB:
+		setting.InputType = store.InputTypeUSBCamera
+		setting.InputDeviceName = strings.TrimSpace(camera.USBDeviceName)
+		setting.InputDeviceResolution = camera.USBDeviceResolution
+		setting.InputDeviceFramerate = camera.USBDeviceFramerate
+	case store.CameraSourceTypeRTMP:
+		setting.InputType = store.InputTypeRTMP
+		setting.RTMPURL = strings.TrimSpace(camera.RTMPURL)
+	case store.CameraSourceTypeGB28181:
+		setting.InputType = store.InputTypeGB28181
+		setting.GBPullURL = strings.TrimSpace(camera.GBPullURL)
+	default:
+		return fmt.Errorf("unsupported camera source type: %s", camera.SourceType)
+	}
+	return nil
+}
+
+// noteSourceFailure counts input failures of the active source and moves one step down the chain once
+// the threshold is reached. It returns true when the source was switched.
+func (m *Manager) noteSourceFailure(ctx context.Context, setting *store.PushSetting, class store.PushFailureClass, runErr error) bool {
+	if class != store.PushFailureInputUnreachable && class != store.PushFailureStalled {
+		return false
+	}
+	chain := failoverChain(setting)
+	if len(chain) == 1 {
+		return false
+	}
+	m.mu.Lock()
+	if m.failover == nil {
+		m.failover = &store.PushFailoverState{Source: chain[0]}
+	}
+	m.failover.Failures++
+	if m.failover.Failures < setting.Failover.FailureThreshold || m.failoverLevel >= len(chain)-1 {
+		m.mu.Unlock()
+		return false
+	}
+	from := chain[m.failoverLevel]
+	m.failoverLevel++
+	to := chain[m.failoverLevel]
+	now := time.Now()
+	m.failover.Source = to
+	m.failover.Failures = 0
+	if m.failover.Since == nil {
+		m.failover.Since = &now
+	}
+	m.mu.Unlock()
+
+	reason := ""
+	if runErr != nil {
+		reason = runErr.Error()
+	}
+	m.addLog("Warn", fmt.Sprintf("failover: %s failed %d times, switching to %s", from, setting.Failover.FailureThreshold, to))
+	m.saveFailoverEvent("push.failover", from, to, reason)
+	m.startPrimaryProbe(ctx, time.Duration(setting.Failover.ProbeIntervalSec)*time.Second)
+	return true
+}
+
+// resetSourceFailures forgets failures after a run stayed healthy for the retry reset window.
+func (m *Manager) resetSourceFailures() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failover != nil {
+		m.failover.Failures = 0
+	}
+}
+
+// FailoverState returns nil when the channel has no fallback chain configured.
+func (m *Manager) FailoverState() *store.PushFailoverState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.failover == nil {
+		return nil
+	}
+	copied := *m.failover
+	return &copied
+}
+
+func (m *Manager) startPrimaryProbe(ctx context.Context, interval time.Duration) {
+	m.mu.Lock()
+	if m.probing {
+		m.mu.Unlock()
+		return
+	}
+	m.probing = true
+	m.mu.Unlock()
+	go m.probePrimary(ctx, interval)
+}
+
+// probePrimary checks the primary input in the background while a fallback source is live,
+// and switches back as soon as it answers again. A local primary keeps the fallback live until the
+// channel is restarted.
+func (m *Manager) probePrimary(ctx context.Context, interval time.Duration) {
+	defer func() {
+		m.mu.Lock()
+		m.probing = false
+		m.mu.Unlock()
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m.mu.RLock()
+		level := m.failoverLevel
+		m.mu.RUnlock()
+		if level == 0 {
+			return
+		}
+		setting, err := m.loadSetting(ctx)
+		if err != nil {
+			continue
+		}
+		probeErr := m.probeInput(ctx, setting)
+		now := time.Now()
+		m.mu.Lock()
+		if m.failover != nil {
+			m.failover.LastProbeAt = &now
+			m.failover.LastProbeError = ""
+			if probeErr != nil {
+				m.failover.LastProbeError = probeErr.Error()
+			}
+		}
+		m.mu.Unlock()
+		if errors.Is(probeErr, errPrimaryNotProbeable) {
+			m.addLog("Warn", "failover: "+probeErr.Error())
+			return
+		}
+		if probeErr != nil {
+			continue
+		}
+		m.failback()
+		return
+	}
+}
+
+// probeInput asks ffprobe for a video stream of the primary input. Local sources (files, devices,
+// test card) have nothing to probe remotely and return errPrimaryNotProbeable.
+func (m *Manager) probeInput(ctx context.Context, setting *store.PushSetting) error {
+	sourceURL, options, remote := remoteInputSource(setting)
+	if !remote {
+		return errPrimaryNotProbeable
+	}
+	if sourceURL == "" {
+		return errors.New("primary input url is empty")
+	}
+	args := append([]string{"-v", "error"}, options...)
+	args = append(args, "-select_streams", "v:0", "-show_entries", "stream=codec_name", "-of", "csv=p=0", sourceURL)
+	probeCtx, cancel := context.WithTimeout(ctx, primaryProbeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(probeCtx, m.ffmpeg.FFprobePath(), args...).CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	if err != nil {
+		if text != "" {
+			return fmt.Errorf("%v: %s", err, text)
+		}
+		return err
+	}
+	if text == "" {
+		return errors.New("primary input has no video stream")
+	}
+	return nil
+}
+
+// remoteInputSource returns the URL and demuxer options of a network input; remote is false for local
+// sources (files, devices, test card).
+func remoteInputSource(setting *store.PushSetting) (sourceURL string, options []string, remote bool) {
+	switch setting.InputType {
+	case store.InputTypeRTSP, store.InputTypeONVIF:
+		return strings.TrimSpace(setting.RTSPURL), []string{"-rtsp_transport", "tcp"}, true
+	case store.InputTypeMJPEG:
+		return strings.TrimSpace(setting.MJPEGURL), []string{"-f", "mjpeg"}, true
+	case store.InputTypeRTMP:
+		return strings.TrimSpace(setting.RTMPURL), nil, true
+	case store.InputTypeGB28181:
+		sourceURL = normalizeGBPullURL(strings.TrimSpace(setting.GBPullURL))
+		if isSDPSource(sourceURL) {
+			options = []string{"-protocol_whitelist", "file,udp,rtp,tcp"}
+		} else if isRTSPSource(sourceURL) {
+			options = []string{"-rtsp_transport", "tcp"}
+		}
+		return sourceURL, options, true
+	}
+	return "", nil, false
+}
+
+// failback returns to the primary input and ends the running fallback ffmpeg so the loop restarts on it.
+func (m *Manager) failback() {
+	m.mu.Lock()
+	from := store.PushFailoverPrimary
+	if m.failover != nil {
+		from = m.failover.Source
+		m.failover.Source = store.PushFailoverPrimary
+		m.failover.Failures = 0
+		m.failover.Since = nil
+	}
+	m.failoverLevel = 0
+	cmd := m.cmd
+	if cmd != nil {
+		m.sourceSwitched = true
+	}
+	m.mu.Unlock()
+
+	m.addLog("Info", fmt.Sprintf("failover: primary input recovered, switching back from %s", from))
+	m.saveFailoverEvent("push.failback", from, store.PushFailoverPrimary, "primary input recovered")
+	if cmd != nil && cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// takeSourceSwitch reports (once) whether the last run was ended by failback.
+func (m *Manager) takeSourceSwitch() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switched := m.sourceSwitched
+	m.sourceSwitched = false
+	return switched
+}
+
+func (m *Manager) saveFailoverEvent(eventType string, from store.PushFailoverSource, to store.PushFailoverSource, reason string) {
+	body, err := json.Marshal(map[string]any{
+		"channelId": m.channelID,
+		"from":      from,
+		"to":        to,
+		"reason":    reason,
+	})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.store.CreateLiveEvent(ctx, eventType, string(body)); err != nil {
+		m.addLog("Warn", "save failover event failed: "+err.Error())
+	}
+}
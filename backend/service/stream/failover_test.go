@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+func TestProbeInputLocalSources(t *testing.T) {
+	cases := []struct {
+		name      string
+		setting   store.PushSetting
+		wantLocal bool
+	}{
+		{name: "video file", setting: store.PushSetting{InputType: store.InputTypeVideo}, wantLocal: true},
+		{name: "usb camera", setting: store.PushSetting{InputType: store.InputTypeUSBCamera}, wantLocal: true},
+		{name: "test card", setting: store.PushSetting{InputType: store.InputTypeTestCard}, wantLocal: true},
+		{name: "rtsp without url", setting: store.PushSetting{InputType: store.InputTypeRTSP}},
+	}
+	m := &Manager{}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := m.probeInput(context.Background(), &tc.setting)
+			if err == nil {
+				t.Fatalf("probeInput succeeded")
+			}
+			if got := errors.Is(err, errPrimaryNotProbeable); got != tc.wantLocal {
+				t.Fatalf("not probeable = %t, want %t: %v", got, tc.wantLocal, err)
+			}
+		})
+	}
+}
+
+func TestProbePrimaryKeepsFallbackForLocalPrimary(t *testing.T) {
+	storeDB, err := store.Open(filepath.Join(t.TempDir(), "gover.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer storeDB.Close()
+	// The seeded default channel pushes a local video file.
+	m := NewManager(1, storeDB, nil, nil, t.TempDir(), t.TempDir(), "", 20, false)
+	m.failoverLevel = 1
+	m.failover = &store.PushFailoverState{Source: store.PushFailoverTestCard}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m.probePrimary(ctx, 10*time.Millisecond)
+
+	if ctx.Err() != nil {
+		t.Fatalf("probe kept running for a local primary")
+	}
+	state := m.FailoverState()
+	if m.failoverLevel != 1 || state.Source != store.PushFailoverTestCard {
+		t.Fatalf("failed back to the local primary: level %d, source %s", m.failoverLevel, state.Source)
+	}
+	if state.LastProbeError == "" {
+		t.Fatalf("probe error is not reported")
+	}
+}
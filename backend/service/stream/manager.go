@@ -14,11 +14,14 @@ import (
 	"time"
 
 	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/events"
 	ffsvc "bilibililivetools/gover/backend/service/ffmpeg"
 	"bilibililivetools/gover/backend/store"
 )
 
+// Manager runs the ffmpeg push loop of a single push channel.
 type Manager struct {
+	channelID int64
 	store     *store.Store
 	ffmpeg    *ffsvc.Service
 	bilibili  bilibili.Service
@@ -26,31 +29,218 @@ type Manager struct {
 	status    store.PushStatus
 	logBuffer int
 	debugLogs bool
+	// dataDir is only handed to advanced-mode command templates.
+	dataDir string
+	// recordingDir is the root of the DVR segments of all channels; "" disables recording.
+	recordingDir string
 
 	mu            sync.RWMutex
 	cancel        context.CancelFunc
 	cmd           *exec.Cmd
 	running       bool
 	logs          []store.FFmpegLogItem
+	outputs       []store.PushOutputStatus
+	metrics       *store.PushMetrics
+	metricSeries  []store.PushMetrics
+	retry         *store.PushRetryState
+	alertFn       func(store.PushAlert)
+	events        *events.Hub
 	hevcHintShown bool
+	// longRoomID resolves short room ids for the session records; nil keeps them as configured.
+	longRoomID func(ctx context.Context, roomID int64) int64
+
+	failover       *store.PushFailoverState
+	failoverLevel  int
+	probing        bool
+	sourceSwitched bool
+	relay          *localRelay
+	mixRestart     *time.Timer
+	// silentInputs caches probeInputSilent per input for one push, so retries do not probe again.
+	silentInputs map[string]bool
+
+	// sceneMu serializes scene switches so the dwell check and the switch happen as one step.
+	sceneMu     sync.Mutex
+	scene       *store.ActiveScene
+	switchDelay *time.Duration
+
+	playlistCursor   *playlistCursor
+	nowPlaying       *store.PlaylistNowPlaying
+	playlistFinished bool
+
+	danmakuLines   []danmakuLine
+	danmakuFilters map[int64]danmakuFilter
+
+	sessionID         int64
+	recordingIndexed  map[string]bool
+	recordingSessions map[string]int64
+
+	lastProgressAt time.Time
+	lastFrame      int64
+	lastOutTimeMS  int64
 }
 
-func NewManager(storeDB *store.Store, ff *ffsvc.Service, bili bilibili.Service, mediaDir string, logBuffer int, debugLogs bool) *Manager {
+// NewManager creates the push loop of one channel; channelID <= 0 follows the default channel.
+func NewManager(channelID int64, storeDB *store.Store, ff *ffsvc.Service, bili bilibili.Service, mediaDir string, dataDir string, recordingDir string, logBuffer int, debugLogs bool) *Manager {
 	if logBuffer <= 0 {
 		logBuffer = 300
 	}
 	return &Manager{
-		store:     storeDB,
-		ffmpeg:    ff,
-		bilibili:  bili,
-		mediaDir:  mediaDir,
-		status:    store.PushStatusStopped,
-		logBuffer: logBuffer,
-		debugLogs: debugLogs,
-		logs:      make([]store.FFmpegLogItem, 0, logBuffer),
+		channelID:    channelID,
+		store:        storeDB,
+		ffmpeg:       ff,
+		bilibili:     bili,
+		mediaDir:     mediaDir,
+		dataDir:      dataDir,
+		recordingDir: recordingDir,
+		status:       store.PushStatusStopped,
+		logBuffer:    logBuffer,
+		debugLogs:    debugLogs,
+		logs:         make([]store.FFmpegLogItem, 0, logBuffer),
 	}
 }
 
+func (m *Manager) ChannelID() int64 {
+	return m.channelID
+}
+
+func (m *Manager) loadSetting(ctx context.Context) (*store.PushSetting, error) {
+	return m.store.GetPushSettingByID(ctx, m.channelID)
+}
+
+// loadLiveSetting returns the global live room setting with the channel's own room binding applied.
+func (m *Manager) loadLiveSetting(ctx context.Context, setting *store.PushSetting) (*store.LiveSetting, error) {
+	live, err := m.store.GetLiveSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyChannelRoom(live, setting), nil
+}
+
+// previewStreamURL stands in for the push URL in previews so they never ask Bilibili for a stream key.
+const previewStreamURL = "rtmp://live-push.bilivideo.com/live-bvc/?streamname=PREVIEW"
+
+// CommandPreview is the argv a push start would run.
+type CommandPreview struct {
+	Command     string   `json:"command"`
+	Args        []string `json:"args"`
+	CommandLine string   `json:"commandLine"`
+}
+
+// PreviewCommand renders the command the next start would run without running it; the push URL is a
+// placeholder and the relay is left out. A non-empty ffmpegCommand is rendered as the advanced-mode
+// command in place of the saved setting.
+func (m *Manager) PreviewCommand(ctx context.Context, ffmpegCommand string) (*CommandPreview, error) {
+	setting, err := m.loadSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(ffmpegCommand) != "" {
+		setting.Model = store.ConfigModelAdvance
+		setting.FFmpegCommand = ffmpegCommand
+	}
+	_, preview, err := m.buildPreview(ctx, setting)
+	return preview, err
+}
+
+// buildPreview builds the command of setting with the placeholder push URL and without the relay. It
+// leaves the files and the log of the channel alone, so it is safe while the channel is streaming.
+func (m *Manager) buildPreview(ctx context.Context, setting *store.PushSetting) (BuildContext, *CommandPreview, error) {
+	live, err := m.loadLiveSetting(ctx, setting)
+	if err != nil {
+		return BuildContext{}, nil, err
+	}
+	buildCtx, err := m.newBuildContext(ctx, setting, live, true)
+	if err != nil {
+		return BuildContext{}, nil, err
+	}
+	buildCtx.StreamURL = previewStreamURL
+	if m.recordingDir != "" && setting.Model != store.ConfigModelAdvance && setting.Recording.Enabled {
+		buildCtx.RecordingDir = RecordingChannelDir(m.recordingDir, setting.ID)
+	}
+	cmdPath, args, err := BuildCommand(buildCtx)
+	if err != nil {
+		return buildCtx, nil, err
+	}
+	args = withProgressArgs(args)
+	return buildCtx, &CommandPreview{Command: cmdPath, Args: args, CommandLine: cmdPath + " " + joinArgs(args)}, nil
+}
+
+// newBuildContext resolves everything the command of a setting needs except the push URL, the relay
+// and the recording directory, which depend on how the command is going to run. A preview writes the
+// music list to a separate file, does not rewrite overlay files and logs nothing.
+func (m *Manager) newBuildContext(ctx context.Context, setting *store.PushSetting, live *store.LiveSetting, preview bool) (BuildContext, error) {
+	var videoMaterial *store.Material
+	var err error
+	if setting.VideoMaterialID != nil && *setting.VideoMaterialID > 0 {
+		videoMaterial, err = m.store.GetMaterialByID(ctx, *setting.VideoMaterialID)
+		if err != nil {
+			return BuildContext{}, fmt.Errorf("video material not found: %w", err)
+		}
+	}
+	var audioMaterial *store.Material
+	if setting.AudioMaterialID != nil && *setting.AudioMaterialID > 0 {
+		audioMaterial, err = m.store.GetMaterialByID(ctx, *setting.AudioMaterialID)
+		if err != nil {
+			return BuildContext{}, fmt.Errorf("audio material not found: %w", err)
+		}
+	}
+	encoderProfile, err := m.loadEncoderProfile(ctx, setting)
+	if err != nil {
+		return BuildContext{}, err
+	}
+	// Mosaic sources referencing cameras or materials always use the current record.
+	if setting, err = m.store.ResolveMosaicSources(ctx, setting); err != nil {
+		return BuildContext{}, err
+	}
+	musicList, err := m.prepareMusicList(ctx, setting, preview)
+	if err != nil {
+		return BuildContext{}, err
+	}
+	return BuildContext{
+		InputSilent:    m.probeInputSilent(ctx, setting, videoMaterial, preview),
+		Setting:        setting,
+		Live:           live,
+		MediaDir:       m.mediaDir,
+		VideoMaterial:  videoMaterial,
+		AudioMaterial:  audioMaterial,
+		FFmpegPath:     m.ffmpeg.BinaryPath(),
+		Overlays:       m.loadOverlayLayers(ctx, setting, preview),
+		EncoderProfile: encoderProfile,
+		FFprobePath:    m.ffmpeg.FFprobePath(),
+		DataDir:        m.dataDir,
+		Cameras:        m.loadTemplateCameras(ctx, setting),
+		MusicList:      musicList,
+	}, nil
+}
+
+// loadEncoderProfile returns the encoder profile the channel references, or nil for the built-in levels.
+func (m *Manager) loadEncoderProfile(ctx context.Context, setting *store.PushSetting) (*store.EncoderProfile, error) {
+	if setting.EncoderProfileID <= 0 {
+		return nil, nil
+	}
+	profile, err := m.store.GetEncoderProfileByID(ctx, setting.EncoderProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("encoder profile not found: %w", err)
+	}
+	return profile, nil
+}
+
+// ApplyChannelRoom overrides room, area and title of a live setting with the channel binding.
+func ApplyChannelRoom(live *store.LiveSetting, setting *store.PushSetting) *store.LiveSetting {
+	if live == nil || setting == nil || setting.RoomID <= 0 {
+		return live
+	}
+	bound := *live
+	bound.RoomID = setting.RoomID
+	if setting.AreaID > 0 {
+		bound.AreaID = setting.AreaID
+	}
+	if strings.TrimSpace(setting.RoomTitle) != "" {
+		bound.RoomName = setting.RoomTitle
+	}
+	return &bound
+}
+
 func (m *Manager) UpdateDebug(enabled bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -63,7 +253,7 @@ func (m *Manager) Start(ctx context.Context, startup bool) error {
 		m.mu.Unlock()
 		return nil
 	}
-	pushSetting, err := m.store.GetPushSetting(ctx)
+	pushSetting, err := m.loadSetting(ctx)
 	if err != nil {
 		m.mu.Unlock()
 		return err
@@ -80,9 +270,17 @@ func (m *Manager) Start(ctx context.Context, startup bool) error {
 	runCtx, cancel := context.WithCancel(context.Background())
 	m.cancel = cancel
 	m.running = true
-	m.status = store.PushStatusStarting
+	m.publishStatusLocked(store.PushStatusStarting)
 	m.logs = m.logs[:0]
+	m.retry = nil
 	m.hevcHintShown = false
+	m.failover = nil
+	m.failoverLevel = 0
+	m.sourceSwitched = false
+	m.playlistCursor = nil
+	m.nowPlaying = nil
+	m.playlistFinished = false
+	m.silentInputs = nil
 	m.mu.Unlock()
 
 	go m.runLoop(runCtx)
@@ -95,10 +293,15 @@ func (m *Manager) runLoop(ctx context.Context) {
 		m.running = false
 		m.cmd = nil
 		m.cancel = nil
-		m.status = store.PushStatusStopped
+		m.publishStatusLocked(store.PushStatusStopped)
+		m.nowPlaying = nil
 		m.mu.Unlock()
 	}()
+	if stopRelay := m.startRelay(ctx); stopRelay != nil {
+		defer stopRelay()
+	}
 
+	attempt := 0
 	for {
 		select {
 		case <-ctx.Done():
@@ -106,78 +309,121 @@ func (m *Manager) runLoop(ctx context.Context) {
 		default:
 		}
 
+		startedAt := time.Now()
 		err := m.runOnce(ctx)
-		if err != nil {
+		if err != nil && !errors.Is(err, errPlaylistFinished) {
 			m.addLog("Error", err.Error())
 		}
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errPlaylistFinished) {
+			m.addLog("Info", "playlist finished, stream loop ended")
+			return
+		}
+		if errors.Is(err, errSourceSwitched) {
+			if !m.waitRestart(ctx, m.takeSwitchDelay()) {
+				return
+			}
+			continue
+		}
 
-		setting, settingErr := m.store.GetPushSetting(context.Background())
+		setting, settingErr := m.loadSetting(context.Background())
 		if settingErr != nil {
 			m.addLog("Error", "load push setting failed: "+settingErr.Error())
 			return
 		}
+		policy := ResolveRetryPolicy(setting)
+		if time.Since(startedAt) >= policy.ResetWindow {
+			// The last run was healthy long enough; start backing off from the initial delay again.
+			attempt = 0
+			m.resetSourceFailures()
+		}
+		class := ClassifyFailure(err)
+		if m.noteSourceFailure(ctx, setting, class, err) || class == store.PushFailureStalled {
+			// A stalled or switched source restarts right away; it does not depend on auto retry.
+			if !m.waitRestart(ctx, stallRestartDelay) {
+				return
+			}
+			continue
+		}
+		if !class.Retryable() {
+			m.abortRetry(class, err.Error(), attempt+1, policy.MaxAttempts)
+			return
+		}
 		if !setting.IsAutoRetry {
 			m.addLog("Info", "auto retry disabled, stream loop ended")
 			return
 		}
-
-		m.setStatus(store.PushStatusWaiting)
-		wait := time.Duration(setting.RetryInterval)
-		if wait < 30 {
-			wait = 30
+		attempt++
+		lastError := ""
+		if err != nil {
+			lastError = err.Error()
 		}
-		timer := time.NewTimer(wait * time.Second)
-		select {
-		case <-ctx.Done():
-			timer.Stop()
+		if policy.MaxAttempts > 0 && attempt > policy.MaxAttempts {
+			m.abortRetry(class, fmt.Sprintf("gave up after %d retries: %s", policy.MaxAttempts, lastError), attempt-1, policy.MaxAttempts)
+			return
+		}
+
+		wait := policy.Delay(attempt)
+		nextRetryAt := time.Now().Add(wait)
+		m.setRetryState(&store.PushRetryState{
+			Attempt:      attempt,
+			MaxAttempts:  policy.MaxAttempts,
+			NextRetryAt:  &nextRetryAt,
+			FailureClass: class,
+			LastError:    lastError,
+		})
+		m.addLog("Info", fmt.Sprintf("retry #%d in %s", attempt, wait))
+		if !m.waitRestart(ctx, wait) {
 			return
-		case <-timer.C:
 		}
 	}
 }
 
+// waitRestart puts the channel into waiting for d; false means the loop was stopped meanwhile.
+func (m *Manager) waitRestart(ctx context.Context, d time.Duration) bool {
+	m.setStatus(store.PushStatusWaiting)
+	return sleepContext(ctx, d)
+}
+
 func (m *Manager) runOnce(ctx context.Context) error {
-	setting, err := m.store.GetPushSetting(ctx)
+	setting, err := m.loadSetting(ctx)
 	if err != nil {
 		return err
 	}
-	live, err := m.store.GetLiveSetting(ctx)
+	setting = m.applyFailover(ctx, setting)
+	live, err := m.loadLiveSetting(ctx, setting)
 	if err != nil {
 		return err
 	}
-
-	var videoMaterial *store.Material
-	if setting.VideoMaterialID != nil && *setting.VideoMaterialID > 0 {
-		videoMaterial, err = m.store.GetMaterialByID(ctx, *setting.VideoMaterialID)
-		if err != nil {
-			return fmt.Errorf("video material not found: %w", err)
-		}
+	buildCtx, err := m.newBuildContext(ctx, setting, live, false)
+	if err != nil {
+		return err
 	}
-	var audioMaterial *store.Material
-	if setting.AudioMaterialID != nil && *setting.AudioMaterialID > 0 {
-		audioMaterial, err = m.store.GetMaterialByID(ctx, *setting.AudioMaterialID)
+
+	// With the local relay the output stage owns the Bilibili connection; this run only feeds the relay.
+	relayURL := m.relayURL()
+	buildCtx.RelayURL = relayURL
+	if relayURL == "" {
+		buildCtx.StreamURL, err = m.bilibili.GetStreamURL(bilibili.WithAccount(ctx, setting.AccountID), live)
 		if err != nil {
-			return fmt.Errorf("audio material not found: %w", err)
+			return err
 		}
+		// With the relay the output stage writes the recording.
+		buildCtx.RecordingDir = m.prepareRecordingDir(setting)
 	}
-
-	streamURL, err := m.bilibili.GetStreamURL(ctx, live)
+	cmdPath, args, err := BuildCommand(buildCtx)
 	if err != nil {
 		return err
 	}
-
-	cmdPath, args, err := BuildCommand(BuildContext{
-		Setting:       setting,
-		Live:          live,
-		StreamURL:     streamURL,
-		MediaDir:      m.mediaDir,
-		VideoMaterial: videoMaterial,
-		AudioMaterial: audioMaterial,
-		FFmpegPath:    m.ffmpeg.BinaryPath(),
-	})
-	if err != nil {
-		return err
+	// The watchdog relies on our own progress channel; a custom -progress target disables it.
+	watchStall := setting.StallTimeoutSec > 0 && !containsArg(args, "-progress")
+	args = withProgressArgs(args)
+	if relayURL == "" {
+		m.resetOutputs(setting, ResolveOutputTargets(buildCtx))
 	}
+	m.resetMetrics()
 
 	cmd := exec.CommandContext(ctx, cmdPath, args...)
 	stdout, err := cmd.StdoutPipe()
@@ -188,6 +434,12 @@ func (m *Manager) runOnce(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	var playlistSink io.WriteCloser
+	if setting.InputType == store.InputTypePlaylist && !setting.MultiInputEnabled {
+		if playlistSink, err = cmd.StdinPipe(); err != nil {
+			return err
+		}
+	}
 
 	m.mu.Lock()
 	m.cmd = cmd
@@ -196,15 +448,41 @@ func (m *Manager) runOnce(ctx context.Context) error {
 	m.addLog("Info", "======================= start ffmpeg ====================")
 	m.addLog("Info", cmdPath+" "+joinArgs(args))
 	if err := cmd.Start(); err != nil {
+		if relayURL == "" {
+			m.transitionOutputs(store.PushOutputStatePending, store.PushOutputStateFailed, err.Error())
+		}
 		return err
 	}
 	m.setStatus(store.PushStatusRunning)
+	if relayURL == "" {
+		m.transitionOutputs(store.PushOutputStatePending, store.PushOutputStateRunning, "")
+	}
+	startedAt := time.Now()
+	sessionID := m.openSession(setting, live, startedAt)
+	aggregator := newMetricAggregator(m.channelID, sessionID, startedAt)
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	stalled := make(chan string, 1)
+	if watchStall {
+		go m.watchStall(watchCtx, cmd, time.Duration(setting.StallTimeoutSec)*time.Second, stalled)
+	}
+	go m.tickCountdowns(watchCtx, buildCtx.Overlays)
+	go m.watchRecordings(watchCtx, buildCtx.RecordingDir)
 
 	var wg sync.WaitGroup
+	feedCtx, stopFeed := context.WithCancel(ctx)
+	defer stopFeed()
+	if playlistSink != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.feedPlaylist(feedCtx, setting, playlistSink)
+		}()
+	}
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		m.collectPipe("Info", stdout)
+		m.collectProgress(stdout, aggregator)
 	}()
 	go func() {
 		defer wg.Done()
@@ -212,18 +490,36 @@ func (m *Manager) runOnce(ctx context.Context) error {
 	}()
 
 	err = cmd.Wait()
+	// The playlist feeder writes into this ffmpeg's stdin and ends with it.
+	stopFeed()
 	wg.Wait()
+	stopWatch()
 
 	m.mu.Lock()
 	m.cmd = nil
 	m.mu.Unlock()
-	if err != nil {
-		if summary := m.recentFailureSummary(); summary != "" {
-			return fmt.Errorf("%w: %s", err, summary)
+	if relayURL == "" {
+		m.transitionOutputs(store.PushOutputStateRunning, store.PushOutputStateStopped, "")
+	}
+	m.saveMetricSummary(aggregator)
+	m.indexRecordings(buildCtx.RecordingDir, true)
+	runErr := err
+	select {
+	case reason := <-stalled:
+		runErr = fmt.Errorf("%w: %s", errStreamStalled, reason)
+	default:
+		if m.takePlaylistFinished() {
+			runErr = errPlaylistFinished
+		} else if m.takeSourceSwitch() {
+			runErr = errSourceSwitched
+		} else if err != nil {
+			if summary := m.recentFailureSummary(); summary != "" {
+				runErr = fmt.Errorf("%w: %s", err, summary)
+			}
 		}
-		return err
 	}
-	return nil
+	m.closeSession(sessionID, cmd, runErr, ctx.Err() != nil)
+	return runErr
 }
 
 func (m *Manager) collectPipe(level string, reader io.Reader) {
@@ -237,12 +533,94 @@ func (m *Manager) collectPipe(level string, reader io.Reader) {
 		}
 		m.addLog(classifyFFmpegLogLevel(level, line), line)
 		maybeHintHEVCSource(m, line)
+		if index, ok := parseTeeSlaveFailure(line); ok {
+			m.markOutputFailed(index, line)
+		}
 	}
 	if err := scanner.Err(); err != nil {
 		m.addLog("Error", "ffmpeg log scanner error: "+err.Error())
 	}
 }
 
+// collectProgress parses the -progress channel on stdout; anything else written there is kept as a log line.
+func (m *Manager) collectProgress(reader io.Reader, aggregator *metricAggregator) {
+	parser := newProgressParser()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(splitByCRLF)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		metrics, done, ok := parser.Feed(line)
+		if !ok {
+			m.addLog("Info", line)
+			continue
+		}
+		if !done {
+			continue
+		}
+		aggregator.Add(metrics)
+		m.recordMetrics(metrics)
+	}
+	if err := scanner.Err(); err != nil {
+		m.addLog("Error", "ffmpeg progress scanner error: "+err.Error())
+	}
+}
+
+func (m *Manager) recordMetrics(metrics store.PushMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := metrics
+	m.metrics = &current
+	m.markProgress(metrics.Frame, metrics.OutTimeMS)
+	m.metricSeries = append(m.metricSeries, metrics)
+	if len(m.metricSeries) > metricSeriesLimit {
+		m.metricSeries = m.metricSeries[len(m.metricSeries)-metricSeriesLimit:]
+	}
+}
+
+func (m *Manager) resetMetrics() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metrics = nil
+	m.metricSeries = m.metricSeries[:0]
+	m.lastProgressAt = time.Now()
+	m.lastFrame = 0
+	m.lastOutTimeMS = 0
+}
+
+func (m *Manager) saveMetricSummary(aggregator *metricAggregator) {
+	summary, ok := aggregator.Summary(time.Now())
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := m.store.InsertPushMetricSummary(ctx, summary); err != nil {
+		m.addLog("Warn", "save push metric summary failed: "+err.Error())
+	}
+}
+
+// Metrics returns the latest progress report and up to limit recent samples (oldest first).
+func (m *Manager) Metrics(limit int) (*store.PushMetrics, []store.PushMetrics) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var current *store.PushMetrics
+	if m.metrics != nil {
+		copied := *m.metrics
+		current = &copied
+	}
+	series := m.metricSeries
+	if limit > 0 && len(series) > limit {
+		series = series[len(series)-limit:]
+	}
+	result := make([]store.PushMetrics, len(series))
+	copy(result, series)
+	return current, result
+}
+
 func maybeHintHEVCSource(manager *Manager, line string) {
 	lower := strings.ToLower(strings.TrimSpace(line))
 	if !strings.Contains(lower, "video: hevc") {
@@ -332,10 +710,109 @@ func (m *Manager) Logs() []store.FFmpegLogItem {
 	return result
 }
 
+// OutputStatuses reports per-destination state of the current (or last) ffmpeg run.
+func (m *Manager) OutputStatuses() []store.PushOutputStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]store.PushOutputStatus, len(m.outputs))
+	copy(result, m.outputs)
+	return result
+}
+
+func (m *Manager) resetOutputs(setting *store.PushSetting, targets []OutputTarget) {
+	now := time.Now()
+	if setting != nil && setting.Model == store.ConfigModelAdvance {
+		// Advanced commands own their outputs; only the Bilibili ingest is tracked.
+		targets = targets[:1]
+	}
+	outputs := make([]store.PushOutputStatus, 0, len(targets))
+	for index, target := range targets {
+		outputs = append(outputs, store.PushOutputStatus{
+			Index:         index,
+			Name:          target.Name,
+			URL:           MaskOutputURL(target.URL),
+			Primary:       target.Primary,
+			Enabled:       true,
+			FailurePolicy: target.FailurePolicy,
+			State:         store.PushOutputStatePending,
+			UpdatedAt:     now,
+		})
+	}
+	if setting != nil {
+		for _, item := range setting.ExtraOutputs {
+			if item.Enabled && setting.Model != store.ConfigModelAdvance {
+				continue
+			}
+			outputs = append(outputs, store.PushOutputStatus{
+				Index:         -1,
+				Name:          item.Name,
+				URL:           MaskOutputURL(item.URL),
+				Enabled:       item.Enabled,
+				FailurePolicy: item.FailurePolicy,
+				State:         store.PushOutputStateDisabled,
+				UpdatedAt:     now,
+			})
+		}
+	}
+	m.mu.Lock()
+	m.outputs = outputs
+	m.mu.Unlock()
+}
+
+func (m *Manager) transitionOutputs(from store.PushOutputState, to store.PushOutputState, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for idx := range m.outputs {
+		if m.outputs[idx].State != from {
+			continue
+		}
+		m.outputs[idx].State = to
+		if message != "" {
+			m.outputs[idx].LastError = message
+		}
+		m.outputs[idx].UpdatedAt = now
+	}
+}
+
+func (m *Manager) markOutputFailed(index int, message string) {
+	m.mu.Lock()
+	name := ""
+	policy := store.PushOutputFailureIgnore
+	for idx := range m.outputs {
+		if m.outputs[idx].Index != index {
+			continue
+		}
+		m.outputs[idx].State = store.PushOutputStateFailed
+		m.outputs[idx].LastError = message
+		m.outputs[idx].UpdatedAt = time.Now()
+		name = m.outputs[idx].Name
+		policy = m.outputs[idx].FailurePolicy
+		break
+	}
+	m.mu.Unlock()
+	if name == "" || policy == store.PushOutputFailureAbort {
+		return
+	}
+	m.addLog("Warn", fmt.Sprintf("output #%d (%s) failed, push continues with remaining outputs", index, name))
+}
+
 func (m *Manager) setStatus(status store.PushStatus) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.publishStatusLocked(status)
+}
+
+// publishStatusLocked sets the status and publishes the transition; m.mu must be held.
+func (m *Manager) publishStatusLocked(status store.PushStatus) {
+	from := m.status
 	m.status = status
+	if from != status {
+		m.events.Publish(events.TopicPushStatus, m.channelID, map[string]any{
+			"from": from,
+			"to":   status,
+		})
+	}
 }
 
 func (m *Manager) addLog(logType string, message string) {
@@ -349,8 +826,9 @@ func (m *Manager) addLog(logType string, message string) {
 	if len(m.logs) > m.logBuffer {
 		m.logs = m.logs[len(m.logs)-m.logBuffer:]
 	}
+	m.events.Publish(events.TopicPushLog, m.channelID, entry)
 	if m.debugLogs {
-		log.Printf("[ffmpeg][%s] %s", strings.ToLower(strings.TrimSpace(logType)), message)
+		log.Printf("[ffmpeg][channel-%d][%s] %s", m.channelID, strings.ToLower(strings.TrimSpace(logType)), message)
 	}
 }
 
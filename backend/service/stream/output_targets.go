@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// OutputTarget is one muxer destination of the normal-mode command. Index 0 is always the Bilibili ingest.
+type OutputTarget struct {
+	Name   string
+	URL    string
+	Format string
+	// Options are extra tee slave options, e.g. the segment muxer settings of the recording.
+	Options       string
+	FailurePolicy store.PushOutputFailurePolicy
+	Primary       bool
+}
+
+func ResolveOutputTargets(ctx BuildContext) []OutputTarget {
+	targets := []OutputTarget{{
+		Name:          "bilibili",
+		URL:           strings.TrimSpace(ctx.StreamURL),
+		Format:        "flv",
+		FailurePolicy: store.PushOutputFailureAbort,
+		Primary:       true,
+	}}
+	if ctx.Setting == nil {
+		return targets
+	}
+	for _, item := range ctx.Setting.ExtraOutputs {
+		if !item.Enabled || strings.TrimSpace(item.URL) == "" {
+			continue
+		}
+		targets = append(targets, OutputTarget{
+			Name:          item.Name,
+			URL:           strings.TrimSpace(item.URL),
+			Format:        resolveOutputFormat(item.Format, item.URL),
+			FailurePolicy: store.NormalizePushOutputFailurePolicy(string(item.FailurePolicy)),
+		})
+	}
+	if target, ok := recordingOutputTarget(ctx); ok {
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func resolveOutputFormat(format string, target string) string {
+	if value := strings.ToLower(strings.TrimSpace(format)); value != "" {
+		return value
+	}
+	lower := strings.ToLower(strings.TrimSpace(target))
+	switch {
+	case strings.HasPrefix(lower, "srt://"), strings.HasPrefix(lower, "udp://"), strings.HasPrefix(lower, "rist://"):
+		return "mpegts"
+	case strings.HasPrefix(lower, "rtsp://"):
+		return "rtsp"
+	default:
+		return "flv"
+	}
+}
+
+// appendOutputTargets writes a plain flv output for the single-destination case and
+// a tee muxer otherwise, so every destination shares one encode.
+func appendOutputTargets(args []string, targets []OutputTarget, hasAudio bool) []string {
+	if len(targets) <= 1 {
+		return append(args, "-f", "flv", targets[0].URL)
+	}
+	// The tee muxer has no default codecs, so ffmpeg would auto-select nothing without explicit maps.
+	if !containsArg(args, "-map") {
+		args = append(args, "-map", "0:v:0")
+		if hasAudio {
+			args = append(args, "-map", "0:a:0?")
+		}
+	}
+	slaves := make([]string, 0, len(targets))
+	for _, target := range targets {
+		onFail := "ignore"
+		if target.FailurePolicy == store.PushOutputFailureAbort {
+			onFail = "abort"
+		}
+		options := "f=" + target.Format + ":onfail=" + onFail
+		if target.Options != "" {
+			options += ":" + target.Options
+		}
+		slaves = append(slaves, "["+options+"]"+escapeTeeTarget(target.URL))
+	}
+	return append(args, "-flags", "+global_header", "-f", "tee", strings.Join(slaves, "|"))
+}
+
+func containsArg(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeTeeTarget(target string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `|`, `\|`)
+	return replacer.Replace(target)
+}
+
+var teeSlaveFailurePattern = regexp.MustCompile(`slave muxer #(\d+) failed`)
+
+// parseTeeSlaveFailure extracts the failing destination index from tee muxer log lines.
+func parseTeeSlaveFailure(line string) (int, bool) {
+	match := teeSlaveFailurePattern.FindStringSubmatch(strings.ToLower(line))
+	if len(match) != 2 {
+		return 0, false
+	}
+	index, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// MaskOutputURL hides stream keys and query credentials before exposing a destination in the API.
+func MaskOutputURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		if len(raw) > 12 {
+			return raw[:12] + "***"
+		}
+		return "***"
+	}
+	masked := parsed.Scheme + "://" + parsed.Host
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) > 1 {
+		masked += "/" + strings.Join(segments[:len(segments)-1], "/") + "/***"
+	} else if len(segments) == 1 && segments[0] != "" {
+		masked += "/***"
+	}
+	if parsed.RawQuery != "" {
+		masked += "?***"
+	}
+	return masked
+}
@@ -0,0 +1,319 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// OverlayLayer is an enabled overlay ready for the command builder.
+type OverlayLayer struct {
+	Overlay store.Overlay
+	// ImagePath is the absolute path of the image of an image layer.
+	ImagePath string
+}
+
+const defaultClockFormat = "%Y-%m-%d %H:%M:%S"
+
+// OverlayDir holds the text files drawtext re-reads every frame; rewriting one changes the text live.
+func OverlayDir(mediaDir string) string {
+	return filepath.Join(mediaDir, "_overlays")
+}
+
+func overlayTextPath(mediaDir string, id int64) string {
+	return filepath.Join(OverlayDir(mediaDir), fmt.Sprintf("overlay-%d.txt", id))
+}
+
+// WriteOverlayText renders the current text of a layer into its file. The file is replaced atomically
+// so ffmpeg never reads half a line.
+// Danmaku boxes are rendered by their channel's Manager instead.
+func WriteOverlayText(mediaDir string, overlay store.Overlay, now time.Time) error {
+	if overlay.Type == store.OverlayTypeImage || overlay.Type == store.OverlayTypeDanmaku {
+		return nil
+	}
+	if err := os.MkdirAll(OverlayDir(mediaDir), 0o755); err != nil {
+		return err
+	}
+	return writeTextFile(overlayTextPath(mediaDir, overlay.ID), overlayText(overlay, now))
+}
+
+// Live value keys fed by the backend itself; other keys are only set through the live-values API.
+const (
+	LiveValueWatched    = "watched"
+	LiveValueOnline     = "online"
+	LiveValueOnlineRank = "online_rank"
+	LiveValueNowPlaying = "now_playing"
+)
+
+// UpdateLiveValue stores the value of every live_value layer bound to key (on every channel when
+// channelID is 0) and rewrites their text files, so running streams show it on the next frame.
+func UpdateLiveValue(ctx context.Context, storeDB *store.Store, mediaDir string, channelID int64, key string, value string) ([]store.Overlay, error) {
+	items, err := storeDB.UpdateOverlayLiveValue(ctx, channelID, key, value)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, item := range items {
+		if err := WriteOverlayText(mediaDir, item, now); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// SetLiveValue updates the live_value layers of this channel bound to key.
+func (m *Manager) SetLiveValue(ctx context.Context, key string, value string) error {
+	_, err := UpdateLiveValue(ctx, m.store, m.mediaDir, m.channelID, key, value)
+	return err
+}
+
+func writeTextFile(target string, text string) error {
+	temp := target + ".tmp"
+	if err := os.WriteFile(temp, []byte(text), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp, target)
+}
+
+// overlayText is the drawtext source of a layer. drawtext expands "%{...}" and backslash escapes in
+// file content too, which the clock uses and plain text has to escape.
+func overlayText(overlay store.Overlay, now time.Time) string {
+	text := ""
+	switch overlay.Type {
+	case store.OverlayTypeClock:
+		format := overlay.Content
+		if strings.TrimSpace(format) == "" {
+			format = defaultClockFormat
+		}
+		replacer := strings.NewReplacer(`\`, `\\`, `:`, `\:`, `}`, `\}`)
+		return "%{localtime:" + replacer.Replace(format) + "}"
+	case store.OverlayTypeCountdown:
+		template := overlay.Content
+		if strings.TrimSpace(template) == "" {
+			template = "{remaining}"
+		}
+		remaining := time.Duration(0)
+		if overlay.TargetAt != nil {
+			remaining = overlay.TargetAt.Sub(now)
+		}
+		text = strings.ReplaceAll(template, "{remaining}", formatRemaining(remaining))
+	default:
+		text = overlay.Content
+	}
+	return drawtextFileText(text)
+}
+
+func drawtextFileText(text string) string {
+	if text == "" {
+		// drawtext refuses an empty source.
+		return " "
+	}
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`).Replace(text)
+}
+
+func formatRemaining(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	total := int64(d.Round(time.Second) / time.Second)
+	days := total / 86400
+	clock := fmt.Sprintf("%02d:%02d:%02d", total%86400/3600, total%3600/60, total%60)
+	if days > 0 {
+		return fmt.Sprintf("%dd %s", days, clock)
+	}
+	return clock
+}
+
+// loadOverlayLayers writes the text files of the enabled layers of a channel and resolves image
+// paths. Layers that can not be prepared are skipped with a warning rather than failing the push. A
+// preview only resolves the layers: the files a running push reads are left alone and nothing is logged.
+func (m *Manager) loadOverlayLayers(ctx context.Context, setting *store.PushSetting, preview bool) []OverlayLayer {
+	if setting.Model == store.ConfigModelAdvance {
+		return nil
+	}
+	warn := func(message string) {
+		if !preview {
+			m.addLog("Warn", message)
+		}
+	}
+	overlays, err := m.store.ListOverlays(ctx, setting.ID)
+	if err != nil {
+		warn("load overlays failed: " + err.Error())
+		return nil
+	}
+	now := time.Now()
+	layers := make([]OverlayLayer, 0, len(overlays))
+	for _, overlay := range overlays {
+		if !overlay.Enabled {
+			continue
+		}
+		layer := OverlayLayer{Overlay: overlay}
+		var writeErr error
+		switch {
+		case overlay.Type == store.OverlayTypeImage:
+			material, getErr := m.store.GetMaterialByID(ctx, overlay.MaterialID)
+			if getErr != nil {
+				warn(fmt.Sprintf("overlay %q skipped: image material %d not found", overlay.Name, overlay.MaterialID))
+				continue
+			}
+			layer.ImagePath = filepath.Join(m.mediaDir, filepath.FromSlash(material.Path))
+		case preview:
+			// A running push keeps reading the current files.
+		case overlay.Type == store.OverlayTypeDanmaku:
+			writeErr = m.RenderDanmakuOverlay(overlay)
+		default:
+			writeErr = WriteOverlayText(m.mediaDir, overlay, now)
+		}
+		if writeErr != nil {
+			warn(fmt.Sprintf("overlay %q skipped: %v", overlay.Name, writeErr))
+			continue
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+// tickCountdowns rewrites countdown files once a second while ffmpeg runs. The layers are read from the
+// store on every tick, so a countdown edited while streaming keeps its new target and text.
+func (m *Manager) tickCountdowns(ctx context.Context, layers []OverlayLayer) {
+	countdowns := make([]store.Overlay, 0)
+	ids := make(map[int64]bool)
+	for _, layer := range layers {
+		if layer.Overlay.Type == store.OverlayTypeCountdown {
+			countdowns = append(countdowns, layer.Overlay)
+			ids[layer.Overlay.ID] = true
+		}
+	}
+	if len(countdowns) == 0 {
+		return
+	}
+	channelID := countdowns[0].ChannelID
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if current, err := m.store.ListOverlays(ctx, channelID); err == nil {
+				countdowns = countdowns[:0]
+				for _, overlay := range current {
+					if ids[overlay.ID] && overlay.Type == store.OverlayTypeCountdown {
+						countdowns = append(countdowns, overlay)
+					}
+				}
+			}
+			for _, overlay := range countdowns {
+				_ = WriteOverlayText(m.mediaDir, overlay, now)
+			}
+		}
+	}
+}
+
+// appendOverlayFilters draws the overlay layers on top of the mapped video. The video source is taken
+// from the mosaic graph, the existing video map or the first input, and any plain -vf is folded in.
+func appendOverlayFilters(args []string, ctx BuildContext) []string {
+	if idx := indexOfArg(args, "-filter_complex"); idx >= 0 && idx+1 < len(args) {
+		if mapAt := indexOfValue(args, "-map", "[vout]"); mapAt >= 0 {
+			args[idx+1] += ";" + buildOverlayGraph("[vout]", "", ctx)
+			args[mapAt+1] = "[vovl]"
+			return args
+		}
+	}
+	vf := ""
+	if at := indexOfArg(args, "-vf"); at >= 0 && at+1 < len(args) {
+		vf = args[at+1]
+		args = append(args[:at], args[at+2:]...)
+	}
+	mapAt := -1
+	for idx := 0; idx+1 < len(args); idx++ {
+		if args[idx] == "-map" && strings.Contains(args[idx+1], ":v") {
+			mapAt = idx
+			break
+		}
+	}
+	if mapAt < 0 {
+		graph := buildOverlayGraph("[0:v:0]", vf, ctx)
+		return append(args, "-filter_complex", graph, "-map", "[vovl]", "-map", "0:a:0?")
+	}
+	source := "[" + strings.TrimSuffix(args[mapAt+1], "?") + "]"
+	args[mapAt+1] = "[vovl]"
+	graph := buildOverlayGraph(source, vf, ctx)
+	return append(args[:mapAt], append([]string{"-filter_complex", graph}, args[mapAt:]...)...)
+}
+
+func buildOverlayGraph(source string, vf string, ctx BuildContext) string {
+	parts := make([]string, 0, len(ctx.Overlays)*2+2)
+	current := source
+	if vf != "" {
+		parts = append(parts, current+vf+"[vpre]")
+		current = "[vpre]"
+	}
+	for idx, layer := range ctx.Overlays {
+		style := layer.Overlay.Style
+		out := fmt.Sprintf("[vo%d]", idx)
+		if layer.Overlay.Type == store.OverlayTypeImage {
+			image := fmt.Sprintf("[vimg%d]", idx)
+			chain := "movie=" + quoteFilterValue(filepath.ToSlash(layer.ImagePath))
+			if style.Width > 0 {
+				chain += fmt.Sprintf(",scale=%d:-1", style.Width)
+			}
+			chain += ",format=rgba"
+			if style.Opacity > 0 && style.Opacity < 1 {
+				chain += fmt.Sprintf(",colorchannelmixer=aa=%.2f", style.Opacity)
+			}
+			parts = append(parts, chain+image)
+			parts = append(parts, fmt.Sprintf("%s%soverlay=x=%s:y=%s%s", current, image, quoteFilterValue(style.X), quoteFilterValue(style.Y), out))
+		} else if layer.Overlay.Type == store.OverlayTypeDanmaku {
+			parts = append(parts, current+strings.Join(buildDanmakuFilters(ctx.MediaDir, layer.Overlay), ",")+out)
+		} else {
+			parts = append(parts, current+buildDrawtextFilter(ctx.MediaDir, layer.Overlay)+out)
+		}
+		current = out
+	}
+	parts = append(parts, current+"null[vovl]")
+	return strings.Join(parts, ";")
+}
+
+func buildDrawtextFilter(mediaDir string, overlay store.Overlay) string {
+	style := overlay.Style
+	return drawtextFilter(overlayTextPath(mediaDir, overlay.ID), style.X, style.Y, style, style.FontColor, style.Box)
+}
+
+func drawtextFilter(textPath string, x string, y string, style store.OverlayStyle, color string, box bool) string {
+	options := []string{
+		"textfile=" + quoteFilterValue(filepath.ToSlash(textPath)),
+		"reload=1",
+		"x=" + quoteFilterValue(x),
+		"y=" + quoteFilterValue(y),
+		fmt.Sprintf("fontsize=%d", style.FontSize),
+		"fontcolor=" + quoteFilterValue(color),
+	}
+	if style.FontFile != "" {
+		options = append(options, "fontfile="+quoteFilterValue(filepath.ToSlash(style.FontFile)))
+	}
+	if box {
+		options = append(options, "box=1", "boxcolor="+quoteFilterValue(style.BoxColor), "boxborderw=6")
+	}
+	return "drawtext=" + strings.Join(options, ":")
+}
+
+// quoteFilterValue quotes a filter option for the filtergraph level; the option level still needs ':' escaped.
+func quoteFilterValue(value string) string {
+	value = strings.ReplaceAll(value, ":", `\:`)
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func indexOfValue(args []string, name string, value string) int {
+	for idx := 0; idx+1 < len(args); idx++ {
+		if args[idx] == name && args[idx+1] == value {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,217 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// errPlaylistFinished ends the push loop once a sequential playlist has played every item.
+var errPlaylistFinished = errors.New("playlist finished")
+
+// playlistCursor is the play order of a channel's playlist. It survives ffmpeg restarts, so a restart
+// replays the interrupted item instead of starting the list over.
+type playlistCursor struct {
+	playlistID int64
+	count      int
+	order      []int
+	pos        int
+}
+
+// BuildPlaylistItemCommand encodes one playlist item, in real time, to the uniform MPEG-TS the main
+// ffmpeg reads. Silence is added as a second audio track so files without audio keep the stream layout.
+func BuildPlaylistItemCommand(ctx BuildContext, item store.PlaylistItem) (string, []string) {
+	videoPath := filepath.Join(ctx.MediaDir, filepath.FromSlash(ctx.VideoMaterial.Path))
+	args := []string{"-hide_banner", "-loglevel", "error", "-re"}
+	if item.InPoint > 0 {
+		args = append(args, "-ss", formatSeconds(item.InPoint))
+	}
+	if item.OutPoint > item.InPoint {
+		args = append(args, "-t", formatSeconds(item.OutPoint-item.InPoint))
+	}
+	args = append(args, "-i", videoPath, "-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=44100")
+	width, height := parseOutputResolution(ctx.Setting.OutputResolution)
+	args = append(args, "-map", "0:v:0", "-map", "0:a:0?", "-map", "1:a:0", "-shortest",
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1", width, height, width, height))
+	args = append(args, relayEncodeArgs(ctx.Setting.OutputResolution)...)
+	return ctx.FFmpegPath, append(args, "pipe:1")
+}
+
+func formatSeconds(value float64) string {
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
+
+// feedPlaylist writes the playlist items one after another into the stdin of the running ffmpeg, so
+// advancing to the next item never touches the Bilibili connection. The playlist is re-read at every
+// item boundary; that is where edits made while streaming take effect.
+func (m *Manager) feedPlaylist(ctx context.Context, setting *store.PushSetting, sink io.WriteCloser) {
+	defer sink.Close()
+	failures := 0
+	for ctx.Err() == nil {
+		playlist, index, err := m.nextPlaylistItem(ctx, setting.PlaylistID)
+		if err != nil {
+			m.addLog("Error", "playlist: "+err.Error())
+			return
+		}
+		if playlist == nil {
+			m.addLog("Info", "playlist: all items played")
+			m.mu.Lock()
+			m.playlistFinished = true
+			m.mu.Unlock()
+			return
+		}
+		item := playlist.Items[index]
+		material, err := m.store.GetMaterialByID(ctx, item.MaterialID)
+		if err == nil {
+			err = m.playPlaylistItem(ctx, setting, playlist, index, material, sink)
+		}
+		if ctx.Err() != nil || errors.Is(err, io.ErrClosedPipe) {
+			return
+		}
+		if err != nil {
+			failures++
+			m.addLog("Warn", fmt.Sprintf("playlist: item %d failed: %v", index+1, err))
+			if failures >= len(playlist.Items) {
+				m.addLog("Error", "playlist: every item failed, giving up")
+				return
+			}
+		} else {
+			failures = 0
+		}
+		m.advancePlaylist()
+	}
+}
+
+func (m *Manager) playPlaylistItem(ctx context.Context, setting *store.PushSetting, playlist *store.Playlist, index int, material *store.Material, sink io.Writer) error {
+	item := playlist.Items[index]
+	m.mu.Lock()
+	m.nowPlaying = &store.PlaylistNowPlaying{
+		PlaylistID:   playlist.ID,
+		Mode:         playlist.Mode,
+		Index:        index,
+		Count:        len(playlist.Items),
+		MaterialID:   material.ID,
+		MaterialName: material.Name,
+		InPoint:      item.InPoint,
+		OutPoint:     item.OutPoint,
+		StartedAt:    time.Now(),
+	}
+	m.mu.Unlock()
+	m.addLog("Info", fmt.Sprintf("playlist: now playing %d/%d %s", index+1, len(playlist.Items), material.Name))
+	if err := m.SetLiveValue(ctx, LiveValueNowPlaying, material.Name); err != nil {
+		m.addLog("Warn", "playlist: update now-playing overlay: "+err.Error())
+	}
+
+	cmdPath, args := BuildPlaylistItemCommand(BuildContext{
+		Setting:       setting,
+		MediaDir:      m.mediaDir,
+		VideoMaterial: material,
+		FFmpegPath:    m.ffmpeg.BinaryPath(),
+	}, item)
+	cmd := exec.CommandContext(ctx, cmdPath, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Wait closes the pipes, so stderr has to be read to the end before it is called.
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		m.collectPipe("Error", stderr)
+	}()
+	_, copyErr := io.Copy(sink, stdout)
+	if copyErr != nil {
+		// The main ffmpeg is gone; stop the item instead of encoding into the void.
+		_ = cmd.Process.Kill()
+		<-stderrDone
+		_ = cmd.Wait()
+		return io.ErrClosedPipe
+	}
+	<-stderrDone
+	return cmd.Wait()
+}
+
+// nextPlaylistItem reloads the playlist and returns the item at the cursor; a nil playlist means a
+// sequential playlist has ended.
+func (m *Manager) nextPlaylistItem(ctx context.Context, playlistID int64) (*store.Playlist, int, error) {
+	playlist, err := m.store.GetPlaylistByID(ctx, playlistID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("load playlist %d: %w", playlistID, err)
+	}
+	if len(playlist.Items) == 0 {
+		return nil, 0, errors.New("playlist is empty")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cursor := m.playlistCursor
+	if cursor == nil || cursor.playlistID != playlist.ID || cursor.count != len(playlist.Items) {
+		pos := 0
+		if cursor != nil && cursor.playlistID == playlist.ID && playlist.Mode != store.PlaylistModeShuffle {
+			// Items were added or removed; keep going from the same position.
+			pos = cursor.pos
+		}
+		cursor = &playlistCursor{playlistID: playlist.ID, count: len(playlist.Items), order: playOrder(playlist), pos: pos}
+		m.playlistCursor = cursor
+	}
+	if cursor.pos >= len(cursor.order) {
+		if playlist.Mode == store.PlaylistModeSequential {
+			return nil, 0, nil
+		}
+		cursor.order = playOrder(playlist)
+		cursor.pos = 0
+	}
+	return playlist, cursor.order[cursor.pos], nil
+}
+
+func (m *Manager) advancePlaylist() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.playlistCursor != nil {
+		m.playlistCursor.pos++
+	}
+}
+
+func playOrder(playlist *store.Playlist) []int {
+	if playlist.Mode == store.PlaylistModeShuffle {
+		return rand.Perm(len(playlist.Items))
+	}
+	order := make([]int, len(playlist.Items))
+	for idx := range order {
+		order[idx] = idx
+	}
+	return order
+}
+
+// NowPlaying returns the playlist item being sent, or nil when the channel is not playing a playlist.
+func (m *Manager) NowPlaying() *store.PlaylistNowPlaying {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.nowPlaying == nil {
+		return nil
+	}
+	copied := *m.nowPlaying
+	return &copied
+}
+
+func (m *Manager) takePlaylistFinished() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	finished := m.playlistFinished
+	m.playlistFinished = false
+	return finished
+}
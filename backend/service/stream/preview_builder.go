@@ -31,7 +31,8 @@ func BuildPreviewCommand(ctx BuildContext, options PreviewOptions) (string, []st
 		mappedVideo = true
 	} else {
 		switch ctx.Setting.InputType {
-		case store.InputTypeVideo:
+		case store.InputTypeVideo, store.InputTypePlaylist:
+			// A playlist previews its first item.
 			if ctx.VideoMaterial == nil {
 				return "", nil, errors.New("video material is required")
 			}
@@ -99,6 +100,9 @@ func BuildPreviewCommand(ctx BuildContext, options PreviewOptions) (string, []st
 				return "", nil, errors.New("onvif preview currently expects a resolved rtsp url")
 			}
 			args = appendRTSPInputArgs(args, streamURL)
+		case store.InputTypeTestCard:
+			width, height := parseOutputResolution(ctx.Setting.OutputResolution)
+			args = append(args, "-re", "-f", "lavfi", "-i", fmt.Sprintf("smptehdbars=size=%dx%d:rate=30", width, height))
 		default:
 			return "", nil, fmt.Errorf("unsupported input type: %s", ctx.Setting.InputType)
 		}
@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+const metricSeriesLimit = 600
+
+// progressKeys are the fields ffmpeg writes to the -progress channel.
+var progressKeys = map[string]bool{
+	"frame": true, "fps": true, "bitrate": true, "total_size": true,
+	"out_time_us": true, "out_time_ms": true, "out_time": true,
+	"dup_frames": true, "drop_frames": true, "speed": true, "progress": true,
+}
+
+// withProgressArgs asks ffmpeg for key=value progress reports on stdout unless the command already sets -progress.
+func withProgressArgs(args []string) []string {
+	if containsArg(args, "-progress") {
+		return args
+	}
+	result := make([]string, 0, len(args)+3)
+	result = append(result, "-progress", "pipe:1", "-nostats")
+	return append(result, args...)
+}
+
+// progressParser collects key=value lines until ffmpeg closes a report with progress=continue|end.
+type progressParser struct {
+	values map[string]string
+}
+
+func newProgressParser() *progressParser {
+	return &progressParser{values: make(map[string]string, len(progressKeys))}
+}
+
+// Feed returns ok=false for lines that are not progress fields; a completed report is returned with done=true.
+func (p *progressParser) Feed(line string) (metrics store.PushMetrics, done bool, ok bool) {
+	key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+	key = strings.TrimSpace(key)
+	if !found {
+		return store.PushMetrics{}, false, false
+	}
+	if !progressKeys[key] && !strings.HasPrefix(key, "stream_") {
+		return store.PushMetrics{}, false, false
+	}
+	p.values[key] = strings.TrimSpace(value)
+	if key != "progress" {
+		return store.PushMetrics{}, false, true
+	}
+	metrics = parseProgressValues(p.values)
+	p.values = make(map[string]string, len(progressKeys))
+	return metrics, true, true
+}
+
+func parseProgressValues(values map[string]string) store.PushMetrics {
+	metrics := store.PushMetrics{
+		Frame:       parseProgressInt(values["frame"]),
+		FPS:         parseProgressFloat(values["fps"]),
+		BitrateKbps: parseProgressFloat(strings.TrimSuffix(values["bitrate"], "kbits/s")),
+		TotalSize:   parseProgressInt(values["total_size"]),
+		Speed:       parseProgressFloat(strings.TrimSuffix(values["speed"], "x")),
+		DupFrames:   parseProgressInt(values["dup_frames"]),
+		DropFrames:  parseProgressInt(values["drop_frames"]),
+		Progress:    values["progress"],
+		UpdatedAt:   time.Now(),
+	}
+	// out_time_ms is microseconds as well in every ffmpeg release, prefer the explicit key.
+	outTimeUS := parseProgressInt(values["out_time_us"])
+	if outTimeUS <= 0 {
+		outTimeUS = parseProgressInt(values["out_time_ms"])
+	}
+	if outTimeUS > 0 {
+		metrics.OutTimeMS = outTimeUS / 1000
+	}
+	return metrics
+}
+
+func parseProgressInt(raw string) int64 {
+	value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
+
+func parseProgressFloat(raw string) float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
+
+// metricAggregator folds the progress reports of one ffmpeg run into a PushMetricSummary.
+type metricAggregator struct {
+	samples    int
+	sumFPS     float64
+	sumBitrate float64
+	sumSpeed   float64
+	summary    store.PushMetricSummary
+}
+
+func newMetricAggregator(channelID int64, sessionID int64, startedAt time.Time) *metricAggregator {
+	summary := store.PushMetricSummary{ChannelID: channelID, StartedAt: startedAt}
+	if sessionID > 0 {
+		summary.SessionID = &sessionID
+	}
+	return &metricAggregator{summary: summary}
+}
+
+func (a *metricAggregator) Add(metrics store.PushMetrics) {
+	// The first reports arrive before the encoder has output anything; they would drag the minimums to zero.
+	if metrics.Frame <= 0 {
+		return
+	}
+	a.samples++
+	a.sumFPS += metrics.FPS
+	a.sumBitrate += metrics.BitrateKbps
+	a.sumSpeed += metrics.Speed
+	if a.samples == 1 || metrics.FPS < a.summary.MinFPS {
+		a.summary.MinFPS = metrics.FPS
+	}
+	if metrics.FPS > a.summary.MaxFPS {
+		a.summary.MaxFPS = metrics.FPS
+	}
+	if a.samples == 1 || metrics.BitrateKbps < a.summary.MinBitrateKbps {
+		a.summary.MinBitrateKbps = metrics.BitrateKbps
+	}
+	if a.samples == 1 || metrics.Speed < a.summary.MinSpeed {
+		a.summary.MinSpeed = metrics.Speed
+	}
+	a.summary.Frames = metrics.Frame
+	a.summary.DupFrames = metrics.DupFrames
+	a.summary.DropFrames = metrics.DropFrames
+	a.summary.TotalSize = metrics.TotalSize
+	a.summary.OutTimeMS = metrics.OutTimeMS
+}
+
+// Summary returns false when the run never produced a frame.
+func (a *metricAggregator) Summary(endedAt time.Time) (store.PushMetricSummary, bool) {
+	if a.samples == 0 {
+		return store.PushMetricSummary{}, false
+	}
+	summary := a.summary
+	summary.EndedAt = endedAt
+	summary.Samples = a.samples
+	summary.AvgFPS = a.sumFPS / float64(a.samples)
+	summary.AvgBitrateKbps = a.sumBitrate / float64(a.samples)
+	summary.AvgSpeed = a.sumSpeed / float64(a.samples)
+	return summary, true
+}
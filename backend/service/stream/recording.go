@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// recordingTimeLayout matches the strftime pattern of the segment file names.
+const recordingTimeLayout = "20060102-150405"
+
+// RecordingChannelDir is where the DVR segments of one channel are written.
+func RecordingChannelDir(recordingDir string, channelID int64) string {
+	return filepath.Join(recordingDir, fmt.Sprintf("channel-%d", channelID))
+}
+
+// recordingOutputTarget is the DVR destination of the tee muxer: a segment muxer that never aborts the
+// push when the disk misbehaves.
+func recordingOutputTarget(ctx BuildContext) (OutputTarget, bool) {
+	if ctx.RecordingDir == "" || ctx.Setting == nil || !ctx.Setting.Recording.Enabled {
+		return OutputTarget{}, false
+	}
+	recording := ctx.Setting.Recording
+	options := fmt.Sprintf("segment_time=%d:segment_format=%s:reset_timestamps=1:strftime=1", recording.SegmentSec, recording.Format)
+	if recording.Format == store.RecordingFormatMP4 {
+		options += ":segment_format_options=movflags=+frag_keyframe+empty_moov+default_base_moof"
+	}
+	pattern := filepath.Join(ctx.RecordingDir, "%Y%m%d-%H%M%S."+recording.Format)
+	return OutputTarget{
+		Name:          "recording",
+		URL:           filepath.ToSlash(pattern),
+		Format:        "segment",
+		Options:       options,
+		FailurePolicy: store.PushOutputFailureIgnore,
+	}, true
+}
+
+// prepareRecordingDir returns the segment directory of the channel when the setting records, creating it
+// on the way; "" turns the recording output off.
+func (m *Manager) prepareRecordingDir(setting *store.PushSetting) string {
+	if m.recordingDir == "" || setting.Model == store.ConfigModelAdvance || !setting.Recording.Enabled {
+		return ""
+	}
+	dir := RecordingChannelDir(m.recordingDir, setting.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		m.addLog("Warn", "recording disabled: "+err.Error())
+		return ""
+	}
+	return dir
+}
+
+// watchRecordings indexes finished segments while ffmpeg runs; the caller does a final pass once it exits.
+func (m *Manager) watchRecordings(ctx context.Context, dir string) {
+	if dir == "" {
+		return
+	}
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.indexRecordings(dir, false)
+		}
+	}
+}
+
+// indexRecordings adds the finished segments of dir to the recordings table. While ffmpeg still runs the
+// newest file is the one being written and is left for a later pass. A segment belongs to the session
+// that was running when it was first seen.
+func (m *Manager) indexRecordings(dir string, final bool) {
+	if dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ext := strings.TrimPrefix(filepath.Ext(entry.Name()), ".")
+		if entry.IsDir() || (ext != store.RecordingFormatMP4 && ext != store.RecordingFormatFLV) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	m.mu.Lock()
+	if m.recordingIndexed == nil {
+		m.recordingIndexed = make(map[string]bool)
+		m.recordingSessions = make(map[string]int64)
+	}
+	sessionID := m.sessionID
+	pending := make([]string, 0, len(names))
+	sessions := make([]int64, 0, len(names))
+	for idx, name := range names {
+		if m.recordingIndexed[name] {
+			continue
+		}
+		if _, ok := m.recordingSessions[name]; !ok {
+			m.recordingSessions[name] = sessionID
+		}
+		if !final && idx == len(names)-1 {
+			continue
+		}
+		pending = append(pending, name)
+		sessions = append(sessions, m.recordingSessions[name])
+	}
+	m.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for idx, name := range pending {
+		info, statErr := os.Stat(filepath.Join(dir, name))
+		if statErr != nil {
+			continue
+		}
+		if info.Size() == 0 {
+			// ffmpeg gave up before writing anything; nothing worth listing.
+			m.mu.Lock()
+			m.recordingIndexed[name] = true
+			m.mu.Unlock()
+			continue
+		}
+		ext := strings.TrimPrefix(filepath.Ext(name), ".")
+		startedAt, parseErr := time.ParseInLocation(recordingTimeLayout, strings.TrimSuffix(name, "."+ext), time.Local)
+		if parseErr != nil {
+			startedAt = info.ModTime()
+		}
+		relative, _ := filepath.Rel(m.recordingDir, filepath.Join(dir, name))
+		item := store.Recording{
+			ChannelID: m.channelID,
+			SessionID: sessions[idx],
+			FileName:  name,
+			Path:      filepath.ToSlash(relative),
+			Format:    ext,
+			SizeBytes: info.Size(),
+			StartedAt: startedAt,
+			EndedAt:   info.ModTime(),
+		}
+		if err := m.store.CreateRecording(ctx, item); err != nil {
+			m.addLog("Warn", "index recording "+name+" failed: "+err.Error())
+			continue
+		}
+		m.mu.Lock()
+		m.recordingIndexed[name] = true
+		delete(m.recordingSessions, name)
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,355 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/service/events"
+	ffsvc "bilibililivetools/gover/backend/service/ffmpeg"
+	"bilibililivetools/gover/backend/store"
+)
+
+// Registry owns one Manager per push channel. Channel id 0 always addresses the default channel.
+type Registry struct {
+	store        *store.Store
+	ffmpeg       *ffsvc.Service
+	bilibili     bilibili.Service
+	mediaDir     string
+	dataDir      string
+	recordingDir string
+	logBuffer    int
+
+	// startMu makes the room conflict check and the start of a channel one step, so two channels of the
+	// same room started at once can not both pass the check.
+	startMu sync.Mutex
+
+	mu        sync.Mutex
+	debugLogs bool
+	alertFn   func(store.PushAlert)
+	events    *events.Hub
+	managers  map[int64]*Manager
+	// longRoomIDs caches what short room ids resolve to; see longRoomID.
+	longRoomIDs map[int64]int64
+}
+
+func NewRegistry(storeDB *store.Store, ff *ffsvc.Service, bili bilibili.Service, mediaDir string, dataDir string, recordingDir string, logBuffer int, debugLogs bool) *Registry {
+	return &Registry{
+		store:        storeDB,
+		ffmpeg:       ff,
+		bilibili:     bili,
+		mediaDir:     mediaDir,
+		dataDir:      dataDir,
+		recordingDir: recordingDir,
+		logBuffer:    logBuffer,
+		debugLogs:    debugLogs,
+		managers:     make(map[int64]*Manager),
+		longRoomIDs:  make(map[int64]int64),
+	}
+}
+
+// Channel resolves a push channel manager, creating it on first use.
+func (r *Registry) Channel(ctx context.Context, channelID int64) (*Manager, error) {
+	setting, err := r.store.GetPushSettingByID(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("push channel %d not found: %w", channelID, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	manager, ok := r.managers[setting.ID]
+	if !ok {
+		manager = NewManager(setting.ID, r.store, r.ffmpeg, r.bilibili, r.mediaDir, r.dataDir, r.recordingDir, r.logBuffer, r.debugLogs)
+		manager.alertFn = r.alertFn
+		manager.events = r.events
+		manager.longRoomID = r.longRoomID
+		r.managers[setting.ID] = manager
+	}
+	return manager, nil
+}
+
+// ShowDanmaku feeds a danmaku message to the danmaku boxes of a channel.
+func (r *Registry) ShowDanmaku(ctx context.Context, channelID int64, uid int64, uname string, content string) error {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	return manager.ShowDanmaku(ctx, uid, uname, content)
+}
+
+// SetLiveValue updates the live_value layers of a channel bound to key.
+func (r *Registry) SetLiveValue(ctx context.Context, channelID int64, key string, value string) error {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	return manager.SetLiveValue(ctx, key, value)
+}
+
+// Start starts one channel after checking that no other running channel pushes to the same room.
+func (r *Registry) Start(ctx context.Context, channelID int64, startup bool) error {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	if err := r.checkRoomConflict(ctx, manager.ChannelID()); err != nil {
+		return err
+	}
+	return manager.Start(ctx, startup)
+}
+
+func (r *Registry) Stop(ctx context.Context, channelID int64) error {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	return manager.Stop(ctx)
+}
+
+func (r *Registry) Restart(ctx context.Context, channelID int64) error {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if err := manager.Stop(ctx); err != nil {
+		return err
+	}
+	return r.Start(ctx, manager.ChannelID(), false)
+}
+
+// RoomID returns the effective Bilibili room of a channel (0 means the logged-in account's own room).
+func (r *Registry) RoomID(ctx context.Context, channelID int64) (int64, error) {
+	setting, err := r.store.GetPushSettingByID(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
+	live, err := r.store.GetLiveSetting(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return ApplyChannelRoom(live, setting).RoomID, nil
+}
+
+// ChannelForRoom returns the channel bound to roomID, falling back to the default channel.
+func (r *Registry) ChannelForRoom(ctx context.Context, roomID int64) (int64, error) {
+	if setting, err := r.store.FindPushSettingByRoomID(ctx, roomID); err == nil {
+		return setting.ID, nil
+	}
+	setting, err := r.store.GetPushSetting(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return setting.ID, nil
+}
+
+// AccountContext binds ctx to the Bilibili account of a channel, so room calls made for the channel use
+// its credentials. An unknown channel keeps the main account.
+func (r *Registry) AccountContext(ctx context.Context, channelID int64) context.Context {
+	setting, err := r.store.GetPushSettingByID(ctx, channelID)
+	if err != nil {
+		return ctx
+	}
+	return bilibili.WithAccount(ctx, setting.AccountID)
+}
+
+// RoomAccountContext binds ctx to the account of the channel bound to roomID, or the main account.
+func (r *Registry) RoomAccountContext(ctx context.Context, roomID int64) context.Context {
+	setting, err := r.store.FindPushSettingByRoomID(ctx, roomID)
+	if err != nil {
+		return bilibili.WithAccount(ctx, 0)
+	}
+	return bilibili.WithAccount(ctx, setting.AccountID)
+}
+
+// RoomPushing reports whether any channel targets roomID and whether one of them is pushing, that is
+// starting, running or waiting to retry. Short and long ids of the same room match.
+func (r *Registry) RoomPushing(ctx context.Context, roomID int64) (bool, bool) {
+	settings, err := r.store.ListPushSettings(ctx)
+	if err != nil {
+		return false, false
+	}
+	live, err := r.store.GetLiveSetting(ctx)
+	if err != nil {
+		return false, false
+	}
+	r.mu.Lock()
+	managers := make(map[int64]*Manager, len(r.managers))
+	for id, manager := range r.managers {
+		managers[id] = manager
+	}
+	r.mu.Unlock()
+	roomID = r.longRoomID(ctx, roomID)
+	bound := false
+	for idx := range settings {
+		if r.longRoomID(ctx, ApplyChannelRoom(live, &settings[idx]).RoomID) != roomID {
+			continue
+		}
+		bound = true
+		if manager, ok := managers[settings[idx].ID]; ok && manager.Status() != store.PushStatusStopped {
+			return true, true
+		}
+	}
+	return bound, false
+}
+
+// longRoomID resolves a short room id to the long id Bilibili reports for the room. A room's ids never
+// change, so answers are cached; an id that can not be resolved now is returned as is.
+func (r *Registry) longRoomID(ctx context.Context, roomID int64) int64 {
+	if roomID <= 0 || r.bilibili == nil {
+		return roomID
+	}
+	r.mu.Lock()
+	long, ok := r.longRoomIDs[roomID]
+	r.mu.Unlock()
+	if ok {
+		return long
+	}
+	info, err := r.bilibili.GetRoomInfo(ctx, roomID)
+	if err != nil || info.RoomID <= 0 {
+		return roomID
+	}
+	r.mu.Lock()
+	r.longRoomIDs[roomID] = info.RoomID
+	r.longRoomIDs[info.RoomID] = info.RoomID
+	r.mu.Unlock()
+	return info.RoomID
+}
+
+func (r *Registry) checkRoomConflict(ctx context.Context, channelID int64) error {
+	roomID, err := r.RoomID(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	for _, manager := range r.snapshot() {
+		if manager.ChannelID() == channelID || manager.Status() == store.PushStatusStopped {
+			continue
+		}
+		otherRoomID, err := r.RoomID(ctx, manager.ChannelID())
+		if err != nil {
+			continue
+		}
+		if otherRoomID == roomID {
+			return fmt.Errorf("push channel %d is already streaming to room %d", manager.ChannelID(), roomID)
+		}
+	}
+	return nil
+}
+
+// StartAll starts every channel at application startup; channels with auto retry disabled are skipped.
+func (r *Registry) StartAll(ctx context.Context) {
+	settings, err := r.store.ListPushSettings(ctx)
+	if err != nil {
+		log.Printf("startup stream skipped: %v", err)
+		return
+	}
+	for _, setting := range settings {
+		if err := r.Start(ctx, setting.ID, true); err != nil {
+			log.Printf("startup stream skipped (channel %d %s): %v", setting.ID, setting.Name, err)
+		}
+	}
+}
+
+func (r *Registry) StopAll(ctx context.Context) {
+	for _, manager := range r.snapshot() {
+		_ = manager.Stop(ctx)
+	}
+}
+
+// Forget stops and drops the managers of deleted channels.
+func (r *Registry) Forget(ctx context.Context, channelIDs []int64) {
+	for _, id := range channelIDs {
+		r.mu.Lock()
+		manager, ok := r.managers[id]
+		delete(r.managers, id)
+		r.mu.Unlock()
+		if ok {
+			_ = manager.Stop(ctx)
+		}
+	}
+}
+
+// Status reports running when any channel is live, for services that only care about "is something streaming".
+func (r *Registry) Status() store.PushStatus {
+	result := store.PushStatusStopped
+	for _, manager := range r.snapshot() {
+		status := manager.Status()
+		if status == store.PushStatusRunning {
+			return status
+		}
+		if status != store.PushStatusStopped {
+			result = status
+		}
+	}
+	return result
+}
+
+func (r *Registry) List(ctx context.Context) ([]store.PushChannelStatus, error) {
+	settings, err := r.store.ListPushSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	managers := make(map[int64]*Manager, len(r.managers))
+	for id, manager := range r.managers {
+		managers[id] = manager
+	}
+	r.mu.Unlock()
+	items := make([]store.PushChannelStatus, 0, len(settings))
+	for idx := range settings {
+		item := store.PushChannelStatus{Setting: &settings[idx], Status: store.PushStatusStopped}
+		if manager, ok := managers[settings[idx].ID]; ok {
+			item.Status = manager.Status()
+			item.Outputs = manager.OutputStatuses()
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// OnAlert registers the handler invoked when a channel gives up retrying.
+func (r *Registry) OnAlert(fn func(store.PushAlert)) {
+	r.mu.Lock()
+	r.alertFn = fn
+	r.mu.Unlock()
+	for _, manager := range r.snapshot() {
+		manager.mu.Lock()
+		manager.alertFn = fn
+		manager.mu.Unlock()
+	}
+}
+
+// SetEvents makes every channel publish its log lines and status transitions to hub.
+func (r *Registry) SetEvents(hub *events.Hub) {
+	r.mu.Lock()
+	r.events = hub
+	r.mu.Unlock()
+	for _, manager := range r.snapshot() {
+		manager.mu.Lock()
+		manager.events = hub
+		manager.mu.Unlock()
+	}
+}
+
+func (r *Registry) UpdateDebug(enabled bool) {
+	r.mu.Lock()
+	r.debugLogs = enabled
+	r.mu.Unlock()
+	for _, manager := range r.snapshot() {
+		manager.UpdateDebug(enabled)
+	}
+}
+
+func (r *Registry) snapshot() []*Manager {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := make([]*Manager, 0, len(r.managers))
+	for _, manager := range r.managers {
+		items = append(items, manager)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ChannelID() < items[j].ChannelID() })
+	return items
+}
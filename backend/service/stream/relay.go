@@ -0,0 +1,275 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+const (
+	tsPacketSize = 188
+	// relayGapTimeout is how long the input stage may stay silent before the slate fills in.
+	relayGapTimeout = 1500 * time.Millisecond
+)
+
+// localRelay accepts the MPEG-TS of the input stage on a loopback port and forwards it to the stdin of the
+// long-lived output stage. While the input is silent the slate stream is forwarded instead, so the
+// output stage (and its RTMP connection to Bilibili) never sees the input restart.
+type localRelay struct {
+	listener net.Listener
+	onSlate  func(active bool)
+
+	mu        sync.Mutex
+	sink      io.Writer
+	input     net.Conn
+	lastInput time.Time
+	slate     bool
+}
+
+func newLocalRelay(onSlate func(active bool)) (*localRelay, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen local relay: %w", err)
+	}
+	return &localRelay{listener: listener, onSlate: onSlate}, nil
+}
+
+// URL is the ffmpeg output address of the input stage.
+func (r *localRelay) URL() string {
+	return "tcp://" + r.listener.Addr().String()
+}
+
+// Serve accepts input stage connections until ctx ends; a new connection replaces the previous one.
+func (r *localRelay) Serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		_ = r.listener.Close()
+	}()
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		r.mu.Lock()
+		previous := r.input
+		r.input = conn
+		r.mu.Unlock()
+		if previous != nil {
+			_ = previous.Close()
+		}
+		go r.pump(conn, true)
+	}
+}
+
+// FeedSlate forwards slate packets whenever the input stage has been silent for relayGapTimeout.
+func (r *localRelay) FeedSlate(reader io.Reader) {
+	r.pump(reader, false)
+}
+
+func (r *localRelay) pump(reader io.Reader, fromInput bool) {
+	buf := make([]byte, 64*1024)
+	var carry []byte
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			var packets []byte
+			packets, carry = splitTSPackets(carry, buf[:n])
+			if len(packets) > 0 {
+				r.write(packets, fromInput)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// splitTSPackets returns the whole 188-byte packets of carry+chunk, so a source switch never cuts a packet.
+func splitTSPackets(carry []byte, chunk []byte) ([]byte, []byte) {
+	data := append(carry, chunk...)
+	n := len(data) - len(data)%tsPacketSize
+	rest := append([]byte(nil), data[n:]...)
+	return data[:n], rest
+}
+
+func (r *localRelay) write(packets []byte, fromInput bool) {
+	r.mu.Lock()
+	changed := false
+	if fromInput {
+		r.lastInput = time.Now()
+		if r.slate {
+			r.slate = false
+			changed = true
+		}
+	} else {
+		if time.Since(r.lastInput) < relayGapTimeout {
+			r.mu.Unlock()
+			return
+		}
+		if !r.slate {
+			r.slate = true
+			changed = true
+		}
+	}
+	if r.sink != nil {
+		if _, err := r.sink.Write(packets); err != nil {
+			r.sink = nil
+		}
+	}
+	slate := r.slate
+	r.mu.Unlock()
+	if changed && r.onSlate != nil {
+		r.onSlate(slate)
+	}
+}
+
+// SetSink points the relay at the stdin of a (re)started output stage; nil drops packets.
+func (r *localRelay) SetSink(sink io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sink = sink
+}
+
+func (r *localRelay) Close() {
+	_ = r.listener.Close()
+	r.mu.Lock()
+	input := r.input
+	r.input = nil
+	r.sink = nil
+	r.mu.Unlock()
+	if input != nil {
+		_ = input.Close()
+	}
+}
+
+// appendRelayInputStageOutput encodes fast and generously for the loopback hop; the output stage does the
+// real encode. Audio is always present so input and slate segments carry the same streams: a source
+// whose audio is only mapped if it exists gets silence as a second track, so the first audio track of
+// the relay is the source's when it has one and silence otherwise.
+func appendRelayInputStageOutput(args []string, ctx BuildContext, hasAudio bool) []string {
+	mapAt := indexOfArg(args, "-map")
+	if !hasAudio || mapAt < 0 || hasOptionalAudioMap(args) {
+		inputIndex := countArg(args, "-i")
+		silence := []string{"-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=44100"}
+		if mapAt < 0 {
+			args = append(args, silence...)
+			args = append(args, "-map", "0:v:0")
+			if hasAudio {
+				args = append(args, "-map", "0:a:0?")
+			}
+		} else {
+			// Input options must come before the first output option.
+			args = append(args[:mapAt], append(silence, args[mapAt:]...)...)
+			if !hasAudio {
+				args = removeAudioMaps(args)
+			}
+		}
+		args = append(args, "-map", fmt.Sprintf("%d:a:0", inputIndex))
+	}
+	args = append(args, relayEncodeArgs(ctx.Setting.OutputResolution)...)
+	return append(args, ctx.RelayURL)
+}
+
+func relayEncodeArgs(outputResolution string) []string {
+	width, height := parseOutputResolution(outputResolution)
+	return []string{
+		"-c:v", "libx264", "-preset", "ultrafast", "-tune", "zerolatency", "-crf", "18",
+		"-pix_fmt", "yuv420p", "-r", "30", "-g", "30", "-s", fmt.Sprintf("%dx%d", width, height),
+		"-c:a", "aac", "-ac", "2", "-ar", "44100", "-b:a", "192k",
+		"-f", "mpegts", "-mpegts_flags", "+resend_headers",
+	}
+}
+
+// BuildRelaySlateCommand generates the gap filler: the slate material when one is given, else the test card.
+func BuildRelaySlateCommand(ctx BuildContext) (string, []string) {
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	if ctx.VideoMaterial != nil {
+		videoPath := filepath.Join(ctx.MediaDir, filepath.FromSlash(ctx.VideoMaterial.Path))
+		args = append(args, "-re", "-stream_loop", "-1", "-i", videoPath,
+			"-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=44100")
+	} else {
+		args = append(args, testCardInputArgs(ctx.Setting.OutputResolution)...)
+	}
+	width, height := parseOutputResolution(ctx.Setting.OutputResolution)
+	args = append(args, "-map", "0:v:0", "-map", "1:a:0",
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2", width, height, width, height))
+	args = append(args, relayEncodeArgs(ctx.Setting.OutputResolution)...)
+	return ctx.FFmpegPath, append(args, "pipe:1")
+}
+
+// BuildRelayOutputCommand reads the relay from stdin and pushes to every output target. Timestamps are
+// regenerated from frame/sample counts because each input restart or slate segment starts its own clock.
+func BuildRelayOutputCommand(ctx BuildContext) (string, []string, error) {
+	if ctx.Setting == nil {
+		return "", nil, errors.New("missing push setting")
+	}
+	if strings.TrimSpace(ctx.StreamURL) == "" {
+		return "", nil, errors.New("missing stream url")
+	}
+	args := []string{
+		"-hide_banner", "-fflags", "+genpts+discardcorrupt", "-f", "mpegts", "-i", "pipe:0",
+		"-map", "0:v:0", "-map", "0:a:0",
+		"-vf", fmt.Sprintf("setpts=N/%d/TB", outputFrameRate(ctx.EncoderProfile)), "-af", "asetpts=N/SR/TB",
+	}
+	args = appendVideoEncodeArgs(args, ctx.Setting, ctx.EncoderProfile, true)
+	args = appendAudioEncodeArgs(args, ctx.EncoderProfile)
+	args = appendOutputTargets(args, ResolveOutputTargets(ctx), true)
+	return ctx.FFmpegPath, args, nil
+}
+
+func countArg(args []string, name string) int {
+	count := 0
+	for _, arg := range args {
+		if arg == name {
+			count++
+		}
+	}
+	return count
+}
+
+func indexOfArg(args []string, name string) int {
+	for idx, arg := range args {
+		if arg == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func hasOptionalAudioMap(args []string) bool {
+	for idx := 0; idx+1 < len(args); idx++ {
+		if args[idx] == "-map" && strings.Contains(args[idx+1], ":a") && strings.HasSuffix(args[idx+1], "?") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeAudioMaps drops optional source audio maps such as "0:a:0?" once silence replaces them.
+func removeAudioMaps(args []string) []string {
+	result := make([]string, 0, len(args))
+	for idx := 0; idx < len(args); idx++ {
+		if args[idx] == "-map" && idx+1 < len(args) && strings.Contains(args[idx+1], ":a") {
+			idx++
+			continue
+		}
+		result = append(result, args[idx])
+	}
+	return result
+}
+
+// relayEnabled reports whether a channel pushes through the local relay; advanced mode owns its output.
+func relayEnabled(setting *store.PushSetting) bool {
+	return setting != nil && setting.RelayEnabled && setting.Model != store.ConfigModelAdvance
+}
@@ -0,0 +1,240 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"bilibililivetools/gover/backend/service/bilibili"
+	"bilibililivetools/gover/backend/store"
+)
+
+// startRelay brings up the loopback relay, the slate generator and the output stage of a channel with
+// relay enabled. The returned stop function waits for all of them; nil means the channel pushes directly.
+func (m *Manager) startRelay(ctx context.Context) func() {
+	setting, err := m.loadSetting(ctx)
+	if err != nil || !setting.RelayEnabled {
+		return nil
+	}
+	if !relayEnabled(setting) {
+		m.addLog("Warn", "local relay is only available in normal mode, pushing directly")
+		return nil
+	}
+	relay, err := newLocalRelay(func(active bool) {
+		if active {
+			m.addLog("Warn", "relay: input stage silent, sending slate")
+		} else {
+			m.addLog("Info", "relay: input stage resumed")
+		}
+	})
+	if err != nil {
+		m.addLog("Warn", err.Error()+", pushing directly")
+		return nil
+	}
+	m.mu.Lock()
+	m.relay = relay
+	m.mu.Unlock()
+	m.addLog("Info", "relay listening on "+relay.URL())
+
+	relayCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		relay.Serve(relayCtx)
+	}()
+	go func() {
+		defer wg.Done()
+		m.runRelaySlate(relayCtx, relay)
+	}()
+	go func() {
+		defer wg.Done()
+		m.runRelayOutput(relayCtx, relay)
+	}()
+	return func() {
+		cancel()
+		relay.Close()
+		wg.Wait()
+		m.mu.Lock()
+		m.relay = nil
+		m.mu.Unlock()
+	}
+}
+
+// relayURL is the output address of the input stage, or "" when the channel pushes directly.
+func (m *Manager) relayURL() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.relay == nil {
+		return ""
+	}
+	return m.relay.URL()
+}
+
+// runRelaySlate keeps the slate generator running so a gap can be filled without spawning ffmpeg first.
+func (m *Manager) runRelaySlate(ctx context.Context, relay *localRelay) {
+	for {
+		if err := m.runRelaySlateOnce(ctx, relay); err != nil && ctx.Err() == nil {
+			m.addLog("Warn", "relay slate stopped: "+err.Error())
+		}
+		if !sleepContext(ctx, stallRestartDelay) {
+			return
+		}
+	}
+}
+
+func (m *Manager) runRelaySlateOnce(ctx context.Context, relay *localRelay) error {
+	setting, err := m.loadSetting(ctx)
+	if err != nil {
+		return err
+	}
+	buildCtx := BuildContext{Setting: setting, MediaDir: m.mediaDir, FFmpegPath: m.ffmpeg.BinaryPath()}
+	if setting.Failover.SlateMaterialID > 0 {
+		if material, getErr := m.store.GetMaterialByID(ctx, setting.Failover.SlateMaterialID); getErr == nil {
+			buildCtx.VideoMaterial = material
+		}
+	}
+	cmdPath, args := BuildRelaySlateCommand(buildCtx)
+	cmd := exec.CommandContext(ctx, cmdPath, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	relay.FeedSlate(stdout)
+	return cmd.Wait()
+}
+
+// runRelayOutput keeps the Bilibili connection up independently of the input stage and restarts the
+// output ffmpeg with the channel retry policy. Failures that a retry cannot fix stop the whole channel.
+func (m *Manager) runRelayOutput(ctx context.Context, relay *localRelay) {
+	attempt := 0
+	for {
+		startedAt := time.Now()
+		err := m.runRelayOutputOnce(ctx, relay)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			m.addLog("Error", "relay output stage: "+err.Error())
+		}
+		setting, settingErr := m.loadSetting(ctx)
+		if settingErr != nil {
+			m.addLog("Error", "load push setting failed: "+settingErr.Error())
+			return
+		}
+		policy := ResolveRetryPolicy(setting)
+		if time.Since(startedAt) >= policy.ResetWindow {
+			attempt = 0
+		}
+		class := ClassifyFailure(err)
+		if !class.Retryable() {
+			m.abortRetry(class, err.Error(), attempt+1, policy.MaxAttempts)
+			m.mu.RLock()
+			cancel := m.cancel
+			m.mu.RUnlock()
+			if cancel != nil {
+				cancel()
+			}
+			return
+		}
+		attempt++
+		wait := policy.Delay(attempt)
+		if attempt == 1 {
+			// The first reconnect is immediate; the input keeps feeding the relay meanwhile.
+			wait = stallRestartDelay
+		}
+		m.addLog("Info", fmt.Sprintf("relay output stage restart #%d in %s", attempt, wait))
+		if !sleepContext(ctx, wait) {
+			return
+		}
+	}
+}
+
+func (m *Manager) runRelayOutputOnce(ctx context.Context, relay *localRelay) error {
+	setting, err := m.loadSetting(ctx)
+	if err != nil {
+		return err
+	}
+	live, err := m.loadLiveSetting(ctx, setting)
+	if err != nil {
+		return err
+	}
+	encoderProfile, err := m.loadEncoderProfile(ctx, setting)
+	if err != nil {
+		return err
+	}
+	streamURL, err := m.bilibili.GetStreamURL(bilibili.WithAccount(ctx, setting.AccountID), live)
+	if err != nil {
+		return err
+	}
+	buildCtx := BuildContext{
+		Setting:        setting,
+		Live:           live,
+		StreamURL:      streamURL,
+		MediaDir:       m.mediaDir,
+		FFmpegPath:     m.ffmpeg.BinaryPath(),
+		RecordingDir:   m.prepareRecordingDir(setting),
+		EncoderProfile: encoderProfile,
+	}
+	cmdPath, args, err := BuildRelayOutputCommand(buildCtx)
+	if err != nil {
+		return err
+	}
+	m.resetOutputs(setting, ResolveOutputTargets(buildCtx))
+
+	cmd := exec.CommandContext(ctx, cmdPath, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	m.addLog("Info", "================ start relay output stage ================")
+	m.addLog("Info", cmdPath+" "+joinArgs(args))
+	if err := cmd.Start(); err != nil {
+		m.transitionOutputs(store.PushOutputStatePending, store.PushOutputStateFailed, err.Error())
+		return err
+	}
+	m.transitionOutputs(store.PushOutputStatePending, store.PushOutputStateRunning, "")
+	relay.SetSink(stdin)
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go m.watchRecordings(watchCtx, buildCtx.RecordingDir)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.collectPipe("Error", stderr)
+	}()
+	err = cmd.Wait()
+	<-done
+	stopWatch()
+	relay.SetSink(nil)
+	_ = stdin.Close()
+	m.indexRecordings(buildCtx.RecordingDir, true)
+	m.transitionOutputs(store.PushOutputStateRunning, store.PushOutputStateStopped, "")
+	if err != nil {
+		if summary := m.recentFailureSummary(); summary != "" {
+			return fmt.Errorf("%w: %s", err, summary)
+		}
+	}
+	return err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
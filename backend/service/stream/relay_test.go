@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+func TestBuildCommandRelayInputStageEndsWithRelayURL(t *testing.T) {
+	const relayURL = "tcp://127.0.0.1:40123"
+	cases := []struct {
+		name    string
+		setting store.PushSetting
+	}{
+		{name: "test card", setting: store.PushSetting{InputType: store.InputTypeTestCard}},
+		{name: "video file", setting: store.PushSetting{InputType: store.InputTypeVideo}},
+		{name: "rtsp with audio", setting: store.PushSetting{InputType: store.InputTypeRTSP, RTSPURL: "rtsp://camera/stream"}},
+		{name: "muted rtsp", setting: store.PushSetting{InputType: store.InputTypeRTSP, RTSPURL: "rtsp://camera/stream", IsMute: true}},
+		{name: "muted rtmp", setting: store.PushSetting{InputType: store.InputTypeRTMP, RTMPURL: "rtmp://origin/live", IsMute: true}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setting := tc.setting
+			setting.OutputResolution = "1280x720"
+			_, args, err := BuildCommand(BuildContext{
+				Setting:       &setting,
+				FFmpegPath:    "ffmpeg",
+				RelayURL:      relayURL,
+				VideoMaterial: &store.Material{Path: "loop.mp4"},
+			})
+			if err != nil {
+				t.Fatalf("BuildCommand: %v", err)
+			}
+			if len(args) == 0 || args[len(args)-1] != relayURL {
+				t.Fatalf("last argument is not the relay url: %q", args)
+			}
+			if countArg(args, "-i") == 0 {
+				t.Fatalf("input stage has no input: %q", args)
+			}
+			if args[len(args)-2] != "+resend_headers" {
+				t.Fatalf("relay url does not follow the mpegts output options: %q", args)
+			}
+			// The output stage maps 0:a:0 without "?", so the first audio track must always exist.
+			for idx, arg := range args {
+				if arg != "-map" || !strings.Contains(args[idx+1], ":a") {
+					continue
+				}
+				if strings.HasSuffix(args[idx+1], "?") && !containsArg(args[idx+2:], "-map") {
+					t.Fatalf("relay audio is optional: %q", args)
+				}
+			}
+			if !containsArg(args, "anullsrc=channel_layout=stereo:sample_rate=44100") {
+				t.Fatalf("relay input stage has no silence track: %q", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+const defaultRetryResetWindow = 5 * time.Minute
+
+// RetryPolicy is the effective backoff of a push channel.
+type RetryPolicy struct {
+	InitialDelay time.Duration
+	Multiplier   float64
+	MaxDelay     time.Duration
+	MaxAttempts  int
+	ResetWindow  time.Duration
+}
+
+// ResolveRetryPolicy falls back to the legacy flat RetryInterval (at least 30s) when no policy is configured.
+func ResolveRetryPolicy(setting *store.PushSetting) RetryPolicy {
+	legacy := 30
+	if setting != nil && setting.RetryInterval > legacy {
+		legacy = setting.RetryInterval
+	}
+	if setting == nil || setting.RetryPolicy.InitialDelaySec <= 0 {
+		return RetryPolicy{
+			InitialDelay: time.Duration(legacy) * time.Second,
+			Multiplier:   1,
+			MaxDelay:     time.Duration(legacy) * time.Second,
+			ResetWindow:  defaultRetryResetWindow,
+		}
+	}
+	policy := setting.RetryPolicy
+	result := RetryPolicy{
+		InitialDelay: time.Duration(policy.InitialDelaySec) * time.Second,
+		Multiplier:   math.Max(policy.Multiplier, 1),
+		MaxDelay:     time.Duration(policy.MaxDelaySec) * time.Second,
+		MaxAttempts:  policy.MaxAttempts,
+		ResetWindow:  time.Duration(policy.ResetWindowSec) * time.Second,
+	}
+	if result.MaxDelay < result.InitialDelay {
+		result.MaxDelay = result.InitialDelay
+	}
+	if result.ResetWindow <= 0 {
+		result.ResetWindow = defaultRetryResetWindow
+	}
+	return result
+}
+
+// Delay returns the wait before the given retry attempt (1-based).
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+var (
+	// publishRejectedKeywords are RTMP statuses only a server we publish to sends.
+	publishRejectedKeywords = []string{
+		"netconnection.connect.rejected", "netstream.publish.badname", "netstream.publish.denied",
+		"publish denied", "invalid stream key",
+	}
+	// authRejectedKeywords also come from cameras and HTTP inputs, so they only count on lines of the
+	// publish stage; an input answering 403 is an unreachable input.
+	authRejectedKeywords = []string{
+		"authentication failed", "auth failed", "unauthorized", "server returned 401", "server returned 403",
+	}
+	publishStageMarkers = []string{"rtmp://", "rtmps://", "[rtmp @", "error opening output"}
+	badArgumentKeywords = []string{
+		"unrecognized option", "option not found", "error splitting the argument list",
+		"error parsing options", "unknown encoder", "unknown decoder", "encoder not found",
+		"no such filter", "error initializing filter", "error reinitializing filters",
+		"unable to find a suitable output format", "at least one output file must be specified",
+		"ffmpeg command is empty", "invalid ffmpeg command", "executable file not found",
+	}
+	inputUnreachableKeywords = []string{
+		"connection refused", "connection timed out", "no route to host", "network is unreachable",
+		"name or service not known", "temporary failure in name resolution", "server returned 401",
+		"server returned 403", "server returned 404", "http error 401", "http error 403",
+		"method describe failed", "could not open", "no such file or directory", "i/o error",
+		"material not found",
+	}
+)
+
+// ClassifyFailure maps the error of one run (which already carries recentFailureSummary) to a failure class.
+func ClassifyFailure(err error) store.PushFailureClass {
+	if err == nil {
+		return store.PushFailureNone
+	}
+	if errors.Is(err, errStreamStalled) {
+		return store.PushFailureStalled
+	}
+	lower := strings.ToLower(err.Error())
+	if containsAnyKeyword(lower, publishRejectedKeywords) || publishStageRejected(lower) {
+		return store.PushFailureAuthRejected
+	}
+	if containsAnyKeyword(lower, badArgumentKeywords) {
+		return store.PushFailureBadArguments
+	}
+	if containsAnyKeyword(lower, inputUnreachableKeywords) {
+		return store.PushFailureInputUnreachable
+	}
+	return store.PushFailureUnknown
+}
+
+// publishStageRejected reports whether one of the log lines of the summary is an output URL or the RTMP
+// client turning down our credentials.
+func publishStageRejected(summary string) bool {
+	for _, line := range strings.Split(summary, " | ") {
+		if containsAnyKeyword(line, publishStageMarkers) && containsAnyKeyword(line, authRejectedKeywords) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyKeyword(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) setRetryState(state *store.PushRetryState) {
+	if state != nil {
+		state.UpdatedAt = time.Now()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retry = state
+}
+
+// RetryState returns the retry bookkeeping of the current (or last) push loop.
+func (m *Manager) RetryState() *store.PushRetryState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.retry == nil {
+		return nil
+	}
+	copied := *m.retry
+	return &copied
+}
+
+// abortRetry ends the push loop without another attempt and raises an alert.
+func (m *Manager) abortRetry(class store.PushFailureClass, reason string, attempts int, maxAttempts int) {
+	m.addLog("Error", "push loop aborted ("+string(class)+"): "+reason)
+	m.setRetryState(&store.PushRetryState{
+		Attempt:      attempts,
+		MaxAttempts:  maxAttempts,
+		FailureClass: class,
+		LastError:    reason,
+		Aborted:      true,
+	})
+	alert := store.PushAlert{
+		ChannelID:    m.channelID,
+		FailureClass: class,
+		Reason:       reason,
+		Attempts:     attempts,
+		Time:         time.Now(),
+	}
+	if body, err := json.Marshal(alert); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = m.store.CreateLiveEvent(ctx, "push.retry_aborted", string(body))
+		cancel()
+	}
+	m.mu.RLock()
+	alertFn := m.alertFn
+	m.mu.RUnlock()
+	if alertFn != nil {
+		alertFn(alert)
+	}
+}
@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want store.PushFailureClass
+	}{
+		{name: "no error", err: nil, want: store.PushFailureNone},
+		{name: "stalled", err: fmt.Errorf("%w: no progress for 30s", errStreamStalled), want: store.PushFailureStalled},
+		{name: "rtmp connect rejected", err: errors.New("exit status 1: [rtmp @ 0x55d1] Server error: NetConnection.Connect.Rejected"), want: store.PushFailureAuthRejected},
+		{name: "push url 403", err: errors.New("exit status 1: Error opening output rtmp://live-push.bilivideo.com/live-bvc/?streamname=live_1: Server returned 403 Forbidden (access denied)"), want: store.PushFailureAuthRejected},
+		{name: "rtsp camera 403", err: errors.New("exit status 1: [rtsp @ 0x55d1] method DESCRIBE failed: 403 Forbidden | rtsp://10.0.0.2/stream: Server returned 403 Forbidden (access denied)"), want: store.PushFailureInputUnreachable},
+		{name: "rtsp camera 401", err: errors.New("exit status 1: rtsp://10.0.0.2/stream: Server returned 401 Unauthorized (authorization failed)"), want: store.PushFailureInputUnreachable},
+		{name: "http input 403", err: errors.New("exit status 1: [http @ 0x55d1] HTTP error 403 Forbidden | http://10.0.0.2/video.mjpg: Server returned 403 Forbidden (access denied)"), want: store.PushFailureInputUnreachable},
+		{name: "camera refused", err: errors.New("exit status 1: rtsp://10.0.0.2/stream: Connection refused"), want: store.PushFailureInputUnreachable},
+		{name: "output end of file", err: errors.New("exit status 1: av_interleaved_write_frame(): End of file | Error writing trailer of rtmp://live-push.bilivideo.com/live-bvc/: End of file"), want: store.PushFailureUnknown},
+		{name: "bare timeout", err: errors.New("exit status 1: rtmp://live-push.bilivideo.com/live-bvc/: timed out"), want: store.PushFailureUnknown},
+		{name: "bad option", err: errors.New("exit status 1: Unrecognized option 'foo'."), want: store.PushFailureBadArguments},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClassifyFailure(tc.err); got != tc.want {
+				t.Fatalf("ClassifyFailure(%v) = %s, want %s", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bilibililivetools/gover/backend/service/events"
+	"bilibililivetools/gover/backend/store"
+)
+
+// ErrSceneDwell is returned when a switch comes while the current scene is still within its dwell time.
+var ErrSceneDwell = errors.New("scene is within its dwell time")
+
+// ActivateScene switches the channel a scene belongs to over to it. source tells who asked (api,
+// danmaku, bot:<provider>); force skips the dwell time of the current scene.
+func (r *Registry) ActivateScene(ctx context.Context, sceneID int64, source string, force bool) (*store.ActiveScene, error) {
+	scene, err := r.store.GetSceneByID(ctx, sceneID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", store.ErrSceneNotFound, sceneID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	manager, err := r.Channel(ctx, scene.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActivateScene(ctx, scene, source, force)
+}
+
+// ActiveScene returns the scene a channel switched to last, nil when none was activated since startup.
+func (r *Registry) ActiveScene(ctx context.Context, channelID int64) (*store.ActiveScene, error) {
+	manager, err := r.Channel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ActiveScene(), nil
+}
+
+// ActivateScene saves the scene's input into the push setting and, while pushing, restarts only the
+// input: with the local relay the slate covers the switch (held for the scene's SlateSec) and the
+// Bilibili connection stays up; without it the switch is a quick reconnect.
+func (m *Manager) ActivateScene(ctx context.Context, scene *store.Scene, source string, force bool) (*store.ActiveScene, error) {
+	m.sceneMu.Lock()
+	defer m.sceneMu.Unlock()
+
+	now := time.Now()
+	if current := m.ActiveScene(); current != nil {
+		if current.SceneID == scene.ID && !force {
+			return current, nil
+		}
+		if !force && now.Before(current.DwellUntil) {
+			return nil, fmt.Errorf("%w: %q stays on air for another %s", ErrSceneDwell, current.Name, current.DwellUntil.Sub(now).Round(time.Second))
+		}
+	}
+
+	var camera *store.CameraSource
+	if scene.Layout == store.SceneLayoutSingle && scene.CameraID > 0 {
+		var err error
+		if camera, err = m.store.GetCameraSourceByID(ctx, scene.CameraID); err != nil {
+			return nil, fmt.Errorf("camera %d not found: %w", scene.CameraID, err)
+		}
+	}
+	setting, err := m.loadSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := store.NewPushSettingUpdateRequest(setting)
+	if err := store.ApplySceneToUpdateRequest(&req, scene, camera); err != nil {
+		return nil, err
+	}
+	if _, err := m.store.UpdatePushSettingByID(ctx, setting.ID, req); err != nil {
+		return nil, err
+	}
+
+	source = strings.TrimSpace(source)
+	if source == "" {
+		source = "api"
+	}
+	active := &store.ActiveScene{
+		ChannelID:   m.channelID,
+		SceneID:     scene.ID,
+		Name:        scene.Name,
+		Source:      source,
+		ActivatedAt: now,
+		DwellUntil:  now.Add(time.Duration(scene.MinDwellSec) * time.Second),
+	}
+	delay := time.Duration(scene.SlateSec) * time.Second
+	m.mu.Lock()
+	m.scene = active
+	cmd := m.cmd
+	withRelay := m.relay != nil
+	if cmd != nil {
+		m.sourceSwitched = true
+		m.switchDelay = &delay
+	}
+	m.mu.Unlock()
+
+	m.addLog("Info", fmt.Sprintf("scene: switching to %q (requested by %s)", scene.Name, source))
+	if cmd != nil && scene.SlateSec > 0 && !withRelay {
+		m.addLog("Warn", "scene: the transition slate needs the local relay, switching directly")
+	}
+	m.saveSceneEvent(active)
+	m.events.Publish(events.TopicSceneActive, m.channelID, active)
+	if cmd != nil && cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+	result := *active
+	return &result, nil
+}
+
+func (m *Manager) ActiveScene() *store.ActiveScene {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.scene == nil {
+		return nil
+	}
+	active := *m.scene
+	return &active
+}
+
+// takeSwitchDelay returns (once) how long to wait before the input restarts after a deliberate switch.
+func (m *Manager) takeSwitchDelay() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.switchDelay == nil {
+		return stallRestartDelay
+	}
+	delay := *m.switchDelay
+	m.switchDelay = nil
+	return delay
+}
+
+func (m *Manager) saveSceneEvent(active *store.ActiveScene) {
+	body, err := json.Marshal(active)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.store.CreateLiveEvent(ctx, "scene.activate", string(body)); err != nil {
+		m.addLog("Warn", "save scene event failed: "+err.Error())
+	}
+}
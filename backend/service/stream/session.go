@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// openSession records the start of an ffmpeg run; 0 is returned when the row could not be written.
+// The room is stored by its long id, the one live messages carry, so revenue can be matched to sessions.
+func (m *Manager) openSession(setting *store.PushSetting, live *store.LiveSetting, startedAt time.Time) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	item := store.StreamSession{
+		ChannelID:         m.channelID,
+		StartedAt:         startedAt,
+		InputType:         setting.InputType,
+		OutputResolution:  setting.OutputResolution,
+		OutputBitrateKbps: setting.OutputBitrateKbps,
+	}
+	if live != nil {
+		item.RoomID = live.RoomID
+		if m.longRoomID != nil {
+			item.RoomID = m.longRoomID(ctx, live.RoomID)
+		}
+	}
+	id, err := m.store.CreateStreamSession(ctx, item)
+	if err != nil {
+		m.addLog("Warn", "save stream session failed: "+err.Error())
+		return 0
+	}
+	m.mu.Lock()
+	m.sessionID = id
+	m.mu.Unlock()
+	return id
+}
+
+// closeSession stores how the run ended. runErr is the error runOnce is about to return.
+func (m *Manager) closeSession(sessionID int64, cmd *exec.Cmd, runErr error, stopped bool) {
+	if sessionID <= 0 {
+		return
+	}
+	var exitCode *int
+	if cmd.ProcessState != nil {
+		code := cmd.ProcessState.ExitCode()
+		exitCode = &code
+	}
+	status := store.StreamSessionFinished
+	class := store.PushFailureNone
+	note := ""
+	switch {
+	case stopped:
+		status = store.StreamSessionStopped
+	case errors.Is(runErr, errSourceSwitched), errors.Is(runErr, errPlaylistFinished):
+		note = runErr.Error()
+	case errors.Is(runErr, errStreamStalled):
+		status = store.StreamSessionStalled
+		class = store.PushFailureStalled
+		note = runErr.Error()
+	case runErr != nil:
+		status = store.StreamSessionFailed
+		class = ClassifyFailure(runErr)
+		note = runErr.Error()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.store.FinishStreamSession(ctx, sessionID, status, exitCode, class, note); err != nil {
+		m.addLog("Warn", "close stream session failed: "+err.Error())
+	}
+}
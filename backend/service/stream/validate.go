@@ -0,0 +1,242 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bilibililivetools/gover/backend/store"
+)
+
+// DefaultValidateProbeTimeout bounds each ffprobe run of a dry run.
+const DefaultValidateProbeTimeout = 10 * time.Second
+
+// unprobedInputFormats are capture devices and generated sources; probing a device can take it away
+// from a running push, and generated sources always work.
+var unprobedInputFormats = map[string]bool{
+	"lavfi": true, "dshow": true, "v4l2": true, "gdigrab": true, "x11grab": true,
+	"alsa": true, "pulse": true, "avfoundation": true,
+}
+
+type commandInput struct {
+	Source  string
+	Format  string
+	Options []string
+	// Error is set when the input could not even be resolved, e.g. a deleted playlist material.
+	Error string
+}
+
+// Validate dry-runs a push setting: it builds the command, probes each input with ffprobe and flags
+// combinations that would fail or surprise. Nothing is started and Bilibili is never asked for a stream
+// key. A nil setting validates the stored one.
+func (m *Manager) Validate(ctx context.Context, setting *store.PushSetting, timeout time.Duration) (*store.PushValidationReport, error) {
+	if setting == nil {
+		var err error
+		if setting, err = m.loadSetting(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if timeout <= 0 {
+		timeout = DefaultValidateProbeTimeout
+	}
+	report := &store.PushValidationReport{
+		ChannelID: setting.ID,
+		Inputs:    []store.PushInputProbe{},
+		Issues:    []store.PushValidationIssue{},
+		CheckedAt: time.Now(),
+	}
+	addIssue := func(level string, code string, message string) {
+		report.Issues = append(report.Issues, store.PushValidationIssue{Level: level, Code: code, Message: message})
+	}
+
+	buildCtx, preview, err := m.buildPreview(ctx, setting)
+	if err != nil {
+		addIssue("error", "build_failed", err.Error())
+		return report, nil
+	}
+	report.Command = preview.Command
+	report.Args = preview.Args
+	report.CommandLine = preview.CommandLine
+
+	inputs := commandInputs(preview.Args)
+	if setting.Model != store.ConfigModelAdvance && setting.InputType == store.InputTypePlaylist {
+		inputs = append(inputs, m.playlistInputs(ctx, setting)...)
+	}
+	report.Inputs = m.probeInputs(ctx, inputs, timeout)
+
+	audioPath := ""
+	if buildCtx.AudioMaterial != nil {
+		audioPath = filepath.Join(m.mediaDir, filepath.FromSlash(buildCtx.AudioMaterial.Path))
+	}
+	var primary *store.PushInputProbe
+	for idx := range report.Inputs {
+		probe := &report.Inputs[idx]
+		if probe.Skipped != "" {
+			continue
+		}
+		if !probe.Reachable {
+			addIssue("error", "input_unreachable", fmt.Sprintf("input %s: %s", probe.Source, probe.Error))
+			continue
+		}
+		if probe.Source == audioPath {
+			if !probe.HasAudio {
+				addIssue("error", "audio_material_no_audio", fmt.Sprintf("audio material %s has no audio stream", probe.Source))
+			}
+			continue
+		}
+		if probe.Source == buildCtx.MusicList {
+			if !probe.HasAudio {
+				addIssue("error", "music_no_audio", "the first track of the audio mix music has no audio stream")
+			}
+			continue
+		}
+		if primary == nil {
+			primary = probe
+		}
+		if probe.VideoCodec == "" {
+			addIssue("error", "no_video", fmt.Sprintf("input %s has no video stream", probe.Source))
+		}
+	}
+
+	if primary != nil && setting.Model != store.ConfigModelAdvance {
+		copyMode := false
+		for idx := 0; idx+1 < len(preview.Args); idx++ {
+			if (preview.Args[idx] == "-c:v" || preview.Args[idx] == "-vcodec") && preview.Args[idx+1] == "copy" {
+				copyMode = true
+			}
+		}
+		codec := strings.ToLower(primary.VideoCodec)
+		if copyMode && (codec == "hevc" || codec == "h265") {
+			addIssue("error", "hevc_copy", "HEVC input can not be stream-copied into Bilibili's FLV ingest; pick a quality other than original or an encoder profile")
+		} else if copyMode && codec != "" && codec != "h264" {
+			addIssue("warning", "copy_codec", fmt.Sprintf("%s input is stream-copied; Bilibili expects H.264", primary.VideoCodec))
+		}
+		mix := setting.AudioMix
+		if mix.Enabled && !mix.Input.Muted && !setting.IsMute && setting.InputAudioSource != store.InputAudioSourceDevice && !primary.HasAudio {
+			addIssue("warning", "audio_mix_input_no_audio", fmt.Sprintf("input %s has no audio stream; the audio mix takes silence in its place", primary.Source))
+		}
+		audioFromElsewhere := audioPath != "" || setting.InputAudioSource == store.InputAudioSourceDevice || mix.Enabled
+		if !setting.IsMute && !audioFromElsewhere && !primary.HasAudio {
+			addIssue("warning", "missing_audio", fmt.Sprintf("input %s has no audio stream although the channel is not muted; the push will be silent", primary.Source))
+		}
+		if width, _ := parseOutputResolution(setting.OutputResolution); !copyMode && primary.Width > 0 && primary.Width < width {
+			addIssue("warning", "upscale", fmt.Sprintf("input is %dx%d and is scaled up to %s", primary.Width, primary.Height, setting.OutputResolution))
+		}
+	}
+
+	report.Valid = true
+	for _, issue := range report.Issues {
+		if issue.Level == "error" {
+			report.Valid = false
+			break
+		}
+	}
+	return report, nil
+}
+
+// commandInputs lists the -i sources of argv with the demuxer options that apply to each.
+func commandInputs(args []string) []commandInput {
+	inputs := make([]commandInput, 0, 2)
+	pending := commandInput{}
+	for idx := 0; idx+1 < len(args); idx++ {
+		switch args[idx] {
+		case "-f":
+			pending.Format = args[idx+1]
+			idx++
+		case "-rtsp_transport", "-protocol_whitelist", "-safe":
+			pending.Options = append(pending.Options, args[idx], args[idx+1])
+			idx++
+		case "-i":
+			pending.Source = args[idx+1]
+			inputs = append(inputs, pending)
+			pending = commandInput{}
+			idx++
+		}
+	}
+	return inputs
+}
+
+// playlistInputs are the materials the playlist feeder would play, since the command itself only reads
+// its stdin.
+func (m *Manager) playlistInputs(ctx context.Context, setting *store.PushSetting) []commandInput {
+	playlist, err := m.store.GetPlaylistByID(ctx, setting.PlaylistID)
+	if err != nil {
+		return nil
+	}
+	inputs := make([]commandInput, 0, len(playlist.Items))
+	for _, item := range playlist.Items {
+		material, getErr := m.store.GetMaterialByID(ctx, item.MaterialID)
+		if getErr != nil {
+			inputs = append(inputs, commandInput{Source: fmt.Sprintf("material #%d", item.MaterialID), Error: "material not found"})
+			continue
+		}
+		inputs = append(inputs, commandInput{Source: filepath.Join(m.mediaDir, filepath.FromSlash(material.Path))})
+	}
+	return inputs
+}
+
+// probeInputs runs ffprobe on all inputs at once, each bounded by timeout.
+func (m *Manager) probeInputs(ctx context.Context, inputs []commandInput, timeout time.Duration) []store.PushInputProbe {
+	results := make([]store.PushInputProbe, len(inputs))
+	var wg sync.WaitGroup
+	for idx, input := range inputs {
+		results[idx] = store.PushInputProbe{Source: input.Source, Format: input.Format, Error: input.Error}
+		if input.Error != "" {
+			continue
+		}
+		if unprobedInputFormats[input.Format] {
+			results[idx].Skipped = "capture device or generated source"
+			continue
+		}
+		if strings.HasPrefix(input.Source, "pipe:") {
+			results[idx].Skipped = "fed by the playlist"
+			continue
+		}
+		wg.Add(1)
+		go func(probe *store.PushInputProbe, input commandInput) {
+			defer wg.Done()
+			options := append([]string(nil), input.Options...)
+			if input.Format != "" {
+				options = append(options, "-f", input.Format)
+			}
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			startedAt := time.Now()
+			result, err := m.ffmpeg.ProbeSource(probeCtx, input.Source, options...)
+			probe.ProbeMs = time.Since(startedAt).Milliseconds()
+			if err != nil {
+				if probeCtx.Err() == context.DeadlineExceeded {
+					probe.Error = fmt.Sprintf("no answer within %s", timeout)
+				} else {
+					probe.Error = err.Error()
+				}
+				return
+			}
+			probe.Reachable = true
+			probe.DurationSec = result.DurationSec
+			for _, stream := range result.Streams {
+				switch stream.CodecType {
+				case "video":
+					if probe.VideoCodec == "" {
+						probe.VideoCodec = stream.CodecName
+						probe.Width = stream.Width
+						probe.Height = stream.Height
+						probe.FPS = stream.FPS
+					}
+				case "audio":
+					if !probe.HasAudio {
+						probe.HasAudio = true
+						probe.AudioCodec = stream.CodecName
+						probe.AudioSampleRate = stream.SampleRate
+						probe.AudioChannels = stream.Channels
+					}
+				}
+			}
+		}(&results[idx], input)
+	}
+	wg.Wait()
+	return results
+}
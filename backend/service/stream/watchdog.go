@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// errStreamStalled marks an ffmpeg run that was killed by the stall watchdog.
+var errStreamStalled = errors.New("stream stalled")
+
+// stallRestartDelay is the pause before a stalled run is restarted; it does not depend on auto retry.
+const stallRestartDelay = 3 * time.Second
+
+// markProgress records forward progress; only a growing frame count or output time counts.
+func (m *Manager) markProgress(frame int64, outTimeMS int64) {
+	if frame <= m.lastFrame && outTimeMS <= m.lastOutTimeMS {
+		return
+	}
+	m.lastFrame = frame
+	m.lastOutTimeMS = outTimeMS
+	m.lastProgressAt = time.Now()
+}
+
+// watchStall kills ffmpeg when no progress report moved forward within timeout.
+// ffmpeg can keep the RTMP connection open after an RTSP source freezes, so cmd.Wait would never return.
+func (m *Manager) watchStall(ctx context.Context, cmd *exec.Cmd, timeout time.Duration, stalled chan<- string) {
+	interval := timeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m.mu.RLock()
+		idle := time.Since(m.lastProgressAt)
+		frame := m.lastFrame
+		outTimeMS := m.lastOutTimeMS
+		m.mu.RUnlock()
+		if idle < timeout {
+			continue
+		}
+		reason := fmt.Sprintf("no ffmpeg progress for %s (frame=%d, out_time=%dms)", idle.Round(time.Second), frame, outTimeMS)
+		m.addLog("Warn", "stall watchdog: "+reason+", restarting ffmpeg")
+		m.saveStallEvent(reason, timeout, frame, outTimeMS)
+		stalled <- reason
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+		return
+	}
+}
+
+func (m *Manager) saveStallEvent(reason string, timeout time.Duration, frame int64, outTimeMS int64) {
+	body, err := json.Marshal(map[string]any{
+		"channelId":  m.channelID,
+		"reason":     reason,
+		"timeoutSec": int(timeout / time.Second),
+		"frame":      frame,
+		"outTimeMs":  outTimeMS,
+	})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.store.CreateLiveEvent(ctx, "push.stall_restart", string(body)); err != nil {
+		m.addLog("Warn", "save stall event failed: "+err.Error())
+	}
+}
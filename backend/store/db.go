@@ -130,6 +130,39 @@ func (s *Store) ensureSchemaUpgrades(ctx context.Context) error {
 	if err := s.ensureColumn(ctx, "push_settings", "multi_input_meta", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
 		return err
 	}
+	if err := s.ensureColumn(ctx, "push_settings", "name", "TEXT NOT NULL DEFAULT 'default'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "room_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "area_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "room_title", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "account_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "stall_timeout_sec", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "retry_policy", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "failover", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "relay_enabled", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "playlist_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "extra_outputs", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
+		return err
+	}
 	if err := s.ensureColumn(ctx, "push_settings", "output_bitrate_kbps", "INTEGER NOT NULL DEFAULT 0"); err != nil {
 		return err
 	}
@@ -160,13 +193,115 @@ func (s *Store) ensureSchemaUpgrades(ctx context.Context) error {
 	if err := s.ensureColumn(ctx, "danmaku_consumer_settings", "config_json", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
 		return err
 	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "channel_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "room_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "exit_code", "INTEGER NULL"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "failure_class", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "input_type", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "output_resolution", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "stream_sessions", "output_bitrate_kbps", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "overlays", "danmaku", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "recording", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "audio_mix", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "push_settings", "encoder_profile_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "danmaku_ptz_rules", "scene_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.upgradeDanmakuRuleEventTypes(ctx); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "webhook_settings", "events", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "maintenance_settings", "recording_retention_days", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "maintenance_settings", "recording_max_size_mb", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "monitor_settings", "poll_interval_sec", "INTEGER NOT NULL DEFAULT 60"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "monitor_settings", "debounce_count", "INTEGER NOT NULL DEFAULT 2"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn(ctx, "monitor_settings", "cooldown_sec", "INTEGER NOT NULL DEFAULT 600"); err != nil {
+		return err
+	}
 	return nil
 }
 
+// upgradeDanmakuRuleEventTypes rebuilds the rule table of older databases, whose keyword was unique on
+// its own, so that each event type has its own keywords.
+func (s *Store) upgradeDanmakuRuleEventTypes(ctx context.Context) error {
+	exists, err := s.hasColumn(ctx, "danmaku_ptz_rules", "event_type")
+	if err != nil || exists {
+		return err
+	}
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		statements := []string{
+			`CREATE TABLE danmaku_ptz_rules_upgrade (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				event_type TEXT NOT NULL DEFAULT 'danmaku',
+				keyword TEXT NOT NULL,
+				min_gold INTEGER NOT NULL DEFAULT 0,
+				action TEXT NOT NULL DEFAULT 'ptz',
+				ptz_direction TEXT NOT NULL DEFAULT 'center',
+				ptz_speed INTEGER NOT NULL DEFAULT 1,
+				scene_id INTEGER NOT NULL DEFAULT 0,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE(event_type, keyword)
+			);`,
+			`INSERT INTO danmaku_ptz_rules_upgrade (id, keyword, action, ptz_direction, ptz_speed, scene_id, enabled, updated_at)
+			SELECT id, keyword, action, ptz_direction, ptz_speed, scene_id, enabled, updated_at FROM danmaku_ptz_rules;`,
+			`DROP TABLE danmaku_ptz_rules;`,
+			`ALTER TABLE danmaku_ptz_rules_upgrade RENAME TO danmaku_ptz_rules;`,
+		}
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *Store) ensureColumn(ctx context.Context, table string, column string, definition string) error {
+	exists, err := s.hasColumn(ctx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column+" "+definition)
+	return err
+}
+
+func (s *Store) hasColumn(ctx context.Context, table string, column string) (bool, error) {
 	rows, err := s.db.QueryContext(ctx, "PRAGMA table_info("+table+")")
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer rows.Close()
 
@@ -181,19 +316,12 @@ func (s *Store) ensureColumn(ctx context.Context, table string, column string, d
 			pk        int
 		)
 		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
-			return err
+			return false, err
 		}
 		if name == column {
 			exists = true
 			break
 		}
 	}
-	if err := rows.Err(); err != nil {
-		return err
-	}
-	if exists {
-		return nil
-	}
-	_, err = s.db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column+" "+definition)
-	return err
+	return exists, rows.Err()
 }
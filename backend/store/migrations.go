@@ -28,10 +28,21 @@ var schemaStatements = []string{
 	);`,
 	`CREATE TABLE IF NOT EXISTS push_settings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT 'default',
+		room_id INTEGER NOT NULL DEFAULT 0,
+		area_id INTEGER NOT NULL DEFAULT 0,
+		room_title TEXT NOT NULL DEFAULT '',
+		account_id INTEGER NOT NULL DEFAULT 0,
 		model INTEGER NOT NULL DEFAULT 1,
 		ffmpeg_command TEXT NOT NULL DEFAULT '',
 		is_auto_retry INTEGER NOT NULL DEFAULT 1,
 		retry_interval INTEGER NOT NULL DEFAULT 30,
+		stall_timeout_sec INTEGER NOT NULL DEFAULT 0,
+		retry_policy TEXT NOT NULL DEFAULT '{}',
+		failover TEXT NOT NULL DEFAULT '{}',
+		relay_enabled INTEGER NOT NULL DEFAULT 0,
+		recording TEXT NOT NULL DEFAULT '{}',
+		audio_mix TEXT NOT NULL DEFAULT '{}',
 		is_update INTEGER NOT NULL DEFAULT 0,
 		input_type TEXT NOT NULL DEFAULT 'video',
 		output_resolution TEXT NOT NULL DEFAULT '1280x720',
@@ -41,6 +52,8 @@ var schemaStatements = []string{
 		custom_video_codec TEXT NOT NULL DEFAULT '',
 		video_material_id INTEGER NULL,
 		audio_material_id INTEGER NULL,
+		playlist_id INTEGER NOT NULL DEFAULT 0,
+		encoder_profile_id INTEGER NOT NULL DEFAULT 0,
 		is_mute INTEGER NOT NULL DEFAULT 0,
 		input_screen TEXT NOT NULL DEFAULT '',
 		input_audio_source TEXT NOT NULL DEFAULT 'file',
@@ -61,6 +74,7 @@ var schemaStatements = []string{
 		multi_input_layout TEXT NOT NULL DEFAULT '2x2',
 		multi_input_urls TEXT NOT NULL DEFAULT '[]',
 		multi_input_meta TEXT NOT NULL DEFAULT '[]',
+		extra_outputs TEXT NOT NULL DEFAULT '[]',
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY(video_material_id) REFERENCES materials(id) ON DELETE SET NULL,
@@ -79,9 +93,25 @@ var schemaStatements = []string{
 		mail_name TEXT NOT NULL DEFAULT '',
 		password TEXT NOT NULL DEFAULT '',
 		receivers TEXT NOT NULL DEFAULT '',
+		poll_interval_sec INTEGER NOT NULL DEFAULT 60,
+		debounce_count INTEGER NOT NULL DEFAULT 2,
+		cooldown_sec INTEGER NOT NULL DEFAULT 600,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
+	`CREATE TABLE IF NOT EXISTS monitor_state_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL DEFAULT 0,
+		kind TEXT NOT NULL DEFAULT '',
+		from_status INTEGER NOT NULL DEFAULT 0,
+		to_status INTEGER NOT NULL DEFAULT 0,
+		previous_title TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL DEFAULT '',
+		unexpected INTEGER NOT NULL DEFAULT 0,
+		notified INTEGER NOT NULL DEFAULT 0,
+		notify_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
 	`CREATE TABLE IF NOT EXISTS cookie_settings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		content TEXT NOT NULL DEFAULT '',
@@ -89,14 +119,31 @@ var schemaStatements = []string{
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
+	`CREATE TABLE IF NOT EXISTS bilibili_accounts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		uid INTEGER NOT NULL DEFAULT 0,
+		uname TEXT NOT NULL DEFAULT '',
+		face TEXT NOT NULL DEFAULT '',
+		cookie TEXT NOT NULL DEFAULT '',
+		refresh_token TEXT NOT NULL DEFAULT '',
+		is_bot INTEGER NOT NULL DEFAULT 0,
+		checked_at DATETIME NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
 	`CREATE TABLE IF NOT EXISTS danmaku_ptz_rules (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		keyword TEXT NOT NULL UNIQUE,
+		event_type TEXT NOT NULL DEFAULT 'danmaku',
+		keyword TEXT NOT NULL,
+		min_gold INTEGER NOT NULL DEFAULT 0,
 		action TEXT NOT NULL DEFAULT 'ptz',
 		ptz_direction TEXT NOT NULL DEFAULT 'center',
 		ptz_speed INTEGER NOT NULL DEFAULT 1,
+		scene_id INTEGER NOT NULL DEFAULT 0,
 		enabled INTEGER NOT NULL DEFAULT 1,
-		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(event_type, keyword)
 	);`,
 	`CREATE TABLE IF NOT EXISTS webhook_settings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -104,6 +151,7 @@ var schemaStatements = []string{
 		url TEXT NOT NULL,
 		secret TEXT NOT NULL DEFAULT '',
 		enabled INTEGER NOT NULL DEFAULT 1,
+		events TEXT NOT NULL DEFAULT '[]',
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
 	`CREATE TABLE IF NOT EXISTS api_key_settings (
@@ -206,16 +254,146 @@ var schemaStatements = []string{
 		enabled INTEGER NOT NULL DEFAULT 1,
 		retention_days INTEGER NOT NULL DEFAULT 7,
 		auto_vacuum INTEGER NOT NULL DEFAULT 1,
+		recording_retention_days INTEGER NOT NULL DEFAULT 0,
+		recording_max_size_mb INTEGER NOT NULL DEFAULT 0,
 		last_cleanup_at DATETIME NULL,
 		last_vacuum_at DATETIME NULL,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
+	`CREATE TABLE IF NOT EXISTS cookie_health_settings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		interval_minutes INTEGER NOT NULL DEFAULT 60,
+		lead_minutes INTEGER NOT NULL DEFAULT 120,
+		email_alert INTEGER NOT NULL DEFAULT 1,
+		webhook_alert INTEGER NOT NULL DEFAULT 1,
+		provider TEXT NOT NULL DEFAULT '',
+		provider_params TEXT NOT NULL DEFAULT '{}',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
 	`CREATE TABLE IF NOT EXISTS stream_sessions (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL DEFAULT 0,
+		room_id INTEGER NOT NULL DEFAULT 0,
 		started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		ended_at DATETIME NULL,
 		status TEXT NOT NULL DEFAULT 'unknown',
-		note TEXT NOT NULL DEFAULT ''
+		exit_code INTEGER NULL,
+		failure_class TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		input_type TEXT NOT NULL DEFAULT '',
+		output_resolution TEXT NOT NULL DEFAULT '',
+		output_bitrate_kbps INTEGER NOT NULL DEFAULT 0
+	);`,
+	`CREATE TABLE IF NOT EXISTS push_metric_summaries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL DEFAULT 0,
+		session_id INTEGER NULL,
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME NOT NULL,
+		samples INTEGER NOT NULL DEFAULT 0,
+		avg_fps REAL NOT NULL DEFAULT 0,
+		min_fps REAL NOT NULL DEFAULT 0,
+		max_fps REAL NOT NULL DEFAULT 0,
+		avg_bitrate_kbps REAL NOT NULL DEFAULT 0,
+		min_bitrate_kbps REAL NOT NULL DEFAULT 0,
+		avg_speed REAL NOT NULL DEFAULT 0,
+		min_speed REAL NOT NULL DEFAULT 0,
+		frames INTEGER NOT NULL DEFAULT 0,
+		dup_frames INTEGER NOT NULL DEFAULT 0,
+		drop_frames INTEGER NOT NULL DEFAULT 0,
+		total_size INTEGER NOT NULL DEFAULT 0,
+		out_time_ms INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(session_id) REFERENCES stream_sessions(id) ON DELETE SET NULL
+	);`,
+	`CREATE TABLE IF NOT EXISTS push_schedules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		channel_id INTEGER NOT NULL DEFAULT 0,
+		action TEXT NOT NULL DEFAULT 'start',
+		cron_expr TEXT NOT NULL DEFAULT '',
+		windows TEXT NOT NULL DEFAULT '[]',
+		timezone TEXT NOT NULL DEFAULT '',
+		params TEXT NOT NULL DEFAULT '{}',
+		skip_dates TEXT NOT NULL DEFAULT '[]',
+		skip_count INTEGER NOT NULL DEFAULT 0,
+		last_run_at DATETIME NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS overlays (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL DEFAULT 0,
+		name TEXT NOT NULL DEFAULT '',
+		overlay_type TEXT NOT NULL DEFAULT 'text',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		z_index INTEGER NOT NULL DEFAULT 0,
+		content TEXT NOT NULL DEFAULT '',
+		live_key TEXT NOT NULL DEFAULT '',
+		target_at DATETIME NULL,
+		material_id INTEGER NOT NULL DEFAULT 0,
+		style TEXT NOT NULL DEFAULT '{}',
+		danmaku TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS encoder_profiles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		video_codec TEXT NOT NULL DEFAULT 'libx264',
+		frame_rate INTEGER NOT NULL DEFAULT 30,
+		gop_sec REAL NOT NULL DEFAULT 1,
+		preset TEXT NOT NULL DEFAULT '',
+		tune TEXT NOT NULL DEFAULT '',
+		profile TEXT NOT NULL DEFAULT '',
+		level TEXT NOT NULL DEFAULT '',
+		rate_control TEXT NOT NULL DEFAULT 'cbr',
+		bitrate_kbps INTEGER NOT NULL DEFAULT 0,
+		crf INTEGER NOT NULL DEFAULT 23,
+		b_frames INTEGER NOT NULL DEFAULT 0,
+		pixel_format TEXT NOT NULL DEFAULT 'yuv420p',
+		audio_codec TEXT NOT NULL DEFAULT 'aac',
+		audio_bitrate_kbps INTEGER NOT NULL DEFAULT 128,
+		audio_sample_rate INTEGER NOT NULL DEFAULT 44100,
+		extra_args TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS scenes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL DEFAULT 0,
+		name TEXT NOT NULL DEFAULT '',
+		layout TEXT NOT NULL DEFAULT 'single',
+		camera_id INTEGER NOT NULL DEFAULT 0,
+		video_material_id INTEGER NOT NULL DEFAULT 0,
+		sources TEXT NOT NULL DEFAULT '[]',
+		min_dwell_sec INTEGER NOT NULL DEFAULT 0,
+		slate_sec INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(channel_id, name COLLATE NOCASE)
+	);`,
+	`CREATE TABLE IF NOT EXISTS recordings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL DEFAULT 0,
+		session_id INTEGER NOT NULL DEFAULT 0,
+		file_name TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL UNIQUE,
+		format TEXT NOT NULL DEFAULT 'mp4',
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		ended_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS playlists (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		mode TEXT NOT NULL DEFAULT 'loop',
+		items TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
 	`CREATE TABLE IF NOT EXISTS live_events (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -234,6 +412,49 @@ var schemaStatements = []string{
 		raw_payload TEXT NOT NULL DEFAULT '',
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
+	`CREATE TABLE IF NOT EXISTS live_gifts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL DEFAULT 0,
+		uid INTEGER NOT NULL DEFAULT 0,
+		uname TEXT NOT NULL DEFAULT '',
+		gift_id INTEGER NOT NULL DEFAULT 0,
+		gift_name TEXT NOT NULL DEFAULT '',
+		num INTEGER NOT NULL DEFAULT 1,
+		coin_type TEXT NOT NULL DEFAULT 'gold',
+		price INTEGER NOT NULL DEFAULT 0,
+		total_coin INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS live_super_chats (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL DEFAULT 0,
+		super_chat_id INTEGER NOT NULL DEFAULT 0,
+		uid INTEGER NOT NULL DEFAULT 0,
+		uname TEXT NOT NULL DEFAULT '',
+		message TEXT NOT NULL DEFAULT '',
+		price INTEGER NOT NULL DEFAULT 0,
+		duration_sec INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS live_guard_buys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL DEFAULT 0,
+		uid INTEGER NOT NULL DEFAULT 0,
+		uname TEXT NOT NULL DEFAULT '',
+		guard_level INTEGER NOT NULL DEFAULT 3,
+		gift_name TEXT NOT NULL DEFAULT '',
+		num INTEGER NOT NULL DEFAULT 1,
+		price INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS live_interactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL DEFAULT 0,
+		uid INTEGER NOT NULL DEFAULT 0,
+		uname TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL DEFAULT 'enter',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
 	`CREATE TABLE IF NOT EXISTS camera_sources (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT NOT NULL,
@@ -315,8 +536,16 @@ var schemaStatements = []string{
 		ended_at DATETIME NULL
 	);`,
 	`CREATE INDEX IF NOT EXISTS idx_live_events_created_at ON live_events(created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_stream_sessions_started_at ON stream_sessions(started_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_push_metric_summaries_channel ON push_metric_summaries(channel_id, ended_at);`,
 	`CREATE INDEX IF NOT EXISTS idx_danmaku_records_created_at ON danmaku_records(created_at);`,
 	`CREATE INDEX IF NOT EXISTS idx_danmaku_records_room_id ON danmaku_records(room_id);`,
+	`CREATE INDEX IF NOT EXISTS idx_live_gifts_room_created ON live_gifts(room_id, created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_live_super_chats_room_created ON live_super_chats(room_id, created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_live_guard_buys_room_created ON live_guard_buys(room_id, created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_live_interactions_room_kind ON live_interactions(room_id, kind, created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_live_interactions_created_at ON live_interactions(created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_monitor_state_changes_room_created ON monitor_state_changes(room_id, created_at);`,
 	`CREATE INDEX IF NOT EXISTS idx_camera_sources_type_enabled ON camera_sources(source_type, enabled);`,
 	`CREATE INDEX IF NOT EXISTS idx_camera_sources_updated_at ON camera_sources(updated_at);`,
 	`CREATE INDEX IF NOT EXISTS idx_gb28181_devices_status ON gb28181_devices(status, updated_at);`,
@@ -336,6 +565,9 @@ var schemaStatements = []string{
 	`CREATE INDEX IF NOT EXISTS idx_bilibili_api_error_logs_created_at ON bilibili_api_error_logs(created_at);`,
 	`CREATE INDEX IF NOT EXISTS idx_bilibili_api_error_logs_endpoint ON bilibili_api_error_logs(endpoint);`,
 	`CREATE INDEX IF NOT EXISTS idx_maintenance_settings_updated_at ON maintenance_settings(updated_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_overlays_channel ON overlays(channel_id, z_index);`,
+	`CREATE INDEX IF NOT EXISTS idx_recordings_channel_started ON recordings(channel_id, started_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_recordings_session ON recordings(session_id);`,
 	`CREATE TABLE IF NOT EXISTS admin_users (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		username TEXT NOT NULL UNIQUE,
@@ -464,6 +696,9 @@ var seedStatements = []string{
 	`INSERT INTO maintenance_settings (enabled, retention_days, auto_vacuum)
 	SELECT 1, 7, 1
 	WHERE NOT EXISTS (SELECT 1 FROM maintenance_settings LIMIT 1);`,
+	`INSERT INTO cookie_health_settings (enabled, interval_minutes, lead_minutes)
+	SELECT 1, 60, 120
+	WHERE NOT EXISTS (SELECT 1 FROM cookie_health_settings LIMIT 1);`,
 	`INSERT INTO admin_users (username, password_hash)
 	SELECT 'admin', '$2a$10$/f/lGuzKkDis1gDBarabAuxCL.atTUPawMSx3cpcE5X2xKUhIX4Di'
 	WHERE NOT EXISTS (SELECT 1 FROM admin_users LIMIT 1);`,
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"strconv"
 	"strings"
 	"time"
 )
@@ -37,6 +38,7 @@ const (
 	FileTypeUnknown FileType = 0
 	FileTypeVideo   FileType = 1
 	FileTypeMusic   FileType = 2
+	FileTypeImage   FileType = 3
 )
 
 type InputType string
@@ -51,6 +53,8 @@ const (
 	InputTypeONVIF      InputType = "onvif"
 	InputTypeRTMP       InputType = "rtmp"
 	InputTypeGB28181    InputType = "gb28181"
+	InputTypeTestCard   InputType = "test_card"
+	InputTypePlaylist   InputType = "playlist"
 )
 
 type InputAudioSource string
@@ -63,7 +67,7 @@ const (
 func NormalizeInputType(newType string, legacyType int) InputType {
 	if strings.TrimSpace(newType) != "" {
 		switch InputType(strings.ToLower(strings.TrimSpace(newType))) {
-		case InputTypeVideo, InputTypeDesktop, InputTypeUSBCamera, InputTypeCameraPlus, InputTypeRTSP, InputTypeMJPEG, InputTypeONVIF, InputTypeRTMP, InputTypeGB28181:
+		case InputTypeVideo, InputTypeDesktop, InputTypeUSBCamera, InputTypeCameraPlus, InputTypeRTSP, InputTypeMJPEG, InputTypeONVIF, InputTypeRTMP, InputTypeGB28181, InputTypeTestCard, InputTypePlaylist:
 			return InputType(strings.ToLower(strings.TrimSpace(newType)))
 		}
 	}
@@ -81,12 +85,16 @@ func NormalizeInputType(newType string, legacyType int) InputType {
 	}
 }
 
+// MultiInputSource is one input of a mosaic. A source referencing a camera source (CameraID) or a
+// material (MaterialID) has its URL resolved from that record when the command is built; URL then only
+// keeps the last resolved value for display.
 type MultiInputSource struct {
 	URL         string  `json:"url"`
 	Title       string  `json:"title"`
 	Primary     bool    `json:"primary"`
 	EnableAudio bool    `json:"enableAudio"`
 	SourceType  string  `json:"sourceType"`
+	CameraID    int64   `json:"cameraId"`
 	MaterialID  int64   `json:"materialId"`
 	X           float64 `json:"x"`
 	Y           float64 `json:"y"`
@@ -95,6 +103,44 @@ type MultiInputSource struct {
 	Z           int     `json:"z"`
 }
 
+// SourceKey identifies a mosaic source when deduplicating: the camera or material it references,
+// otherwise its URL.
+func (s MultiInputSource) SourceKey() string {
+	switch {
+	case s.CameraID > 0:
+		return "camera:" + strconv.FormatInt(s.CameraID, 10)
+	case s.MaterialID > 0:
+		return "material:" + strconv.FormatInt(s.MaterialID, 10)
+	default:
+		return strings.TrimSpace(s.URL)
+	}
+}
+
+type PushOutputFailurePolicy string
+
+const (
+	PushOutputFailureIgnore PushOutputFailurePolicy = "ignore"
+	PushOutputFailureAbort  PushOutputFailurePolicy = "abort"
+)
+
+// PushOutput is an extra destination that receives the same encode as the Bilibili ingest.
+type PushOutput struct {
+	Name          string                  `json:"name"`
+	URL           string                  `json:"url"`
+	Format        string                  `json:"format"`
+	Enabled       bool                    `json:"enabled"`
+	FailurePolicy PushOutputFailurePolicy `json:"failurePolicy"`
+}
+
+func NormalizePushOutputFailurePolicy(raw string) PushOutputFailurePolicy {
+	switch PushOutputFailurePolicy(strings.ToLower(strings.TrimSpace(raw))) {
+	case PushOutputFailureAbort:
+		return PushOutputFailureAbort
+	default:
+		return PushOutputFailureIgnore
+	}
+}
+
 // Result-compatible page payload.
 type QueryPageModel[T any] struct {
 	Page      int   `json:"page"`
@@ -106,10 +152,22 @@ type QueryPageModel[T any] struct {
 
 type PushSetting struct {
 	ID                    int64              `json:"id"`
+	Name                  string             `json:"name"`
+	IsDefault             bool               `json:"isDefault"`
+	RoomID                int64              `json:"roomId"`
+	AreaID                int                `json:"areaId"`
+	RoomTitle             string             `json:"roomTitle"`
+	AccountID             int64              `json:"accountId"`
 	Model                 ConfigModel        `json:"model"`
 	FFmpegCommand         string             `json:"ffmpegCommand"`
 	IsAutoRetry           bool               `json:"isAutoRetry"`
 	RetryInterval         int                `json:"retryInterval"`
+	StallTimeoutSec       int                `json:"stallTimeoutSec"`
+	RetryPolicy           PushRetryPolicy    `json:"retryPolicy"`
+	Failover              PushFailover       `json:"failover"`
+	RelayEnabled          bool               `json:"relayEnabled"`
+	Recording             PushRecording      `json:"recording"`
+	AudioMix              PushAudioMix       `json:"audioMix"`
 	IsUpdate              bool               `json:"isUpdate"`
 	InputType             InputType          `json:"inputType"`
 	OutputResolution      string             `json:"outputResolution"`
@@ -119,6 +177,8 @@ type PushSetting struct {
 	CustomVideoCodec      string             `json:"custumVideoCodec"`
 	VideoMaterialID       *int64             `json:"videoId"`
 	AudioMaterialID       *int64             `json:"audioId"`
+	PlaylistID            int64              `json:"playlistId"`
+	EncoderProfileID      int64              `json:"encoderProfileId"`
 	IsMute                bool               `json:"isMute"`
 	InputScreen           string             `json:"inputScreen"`
 	InputAudioSource      InputAudioSource   `json:"inputAudioSource"`
@@ -139,6 +199,7 @@ type PushSetting struct {
 	MultiInputLayout      string             `json:"multiInputLayout"`
 	MultiInputURLs        []string           `json:"multiInputUrls"`
 	MultiInputMeta        []MultiInputSource `json:"multiInputMeta"`
+	ExtraOutputs          []PushOutput       `json:"extraOutputs"`
 	CreatedAt             time.Time          `json:"createdAt"`
 	UpdatedAt             time.Time          `json:"updatedAt"`
 }
@@ -148,6 +209,12 @@ type PushSettingUpdateRequest struct {
 	FFmpegCommand          string             `json:"ffmpegCommand"`
 	IsAutoRetry            bool               `json:"isAutoRetry"`
 	RetryInterval          int                `json:"retryInterval"`
+	StallTimeoutSec        *int               `json:"stallTimeoutSec"`
+	RetryPolicy            *PushRetryPolicy   `json:"retryPolicy"`
+	Failover               *PushFailover      `json:"failover"`
+	RelayEnabled           *bool              `json:"relayEnabled"`
+	Recording              *PushRecording     `json:"recording"`
+	AudioMix               *PushAudioMix      `json:"audioMix"`
 	InputType              string             `json:"inputType"`
 	LegacyInputType        int                `json:"legacyInputType"`
 	OutputResolution       string             `json:"outputResolution"`
@@ -157,6 +224,8 @@ type PushSettingUpdateRequest struct {
 	CustomVideoCodec       string             `json:"custumVideoCodec"`
 	VideoID                int64              `json:"videoId"`
 	AudioID                int64              `json:"audioId"`
+	PlaylistID             *int64             `json:"playlistId"`
+	EncoderProfileID       *int64             `json:"encoderProfileId"`
 	IsMute                 bool               `json:"isMute"`
 	InputScreen            string             `json:"inputScreen"`
 	InputDeviceName        string             `json:"inputDeviceName"`
@@ -181,10 +250,229 @@ type PushSettingUpdateRequest struct {
 	MultiInputLayout       string             `json:"multiInputLayout"`
 	MultiInputURLs         []string           `json:"multiInputUrls"`
 	MultiInputMeta         []MultiInputSource `json:"multiInputMeta"`
+	ExtraOutputs           []PushOutput       `json:"extraOutputs"`
+}
+
+// PushRetryPolicy controls the wait between push attempts. A zero InitialDelaySec keeps the
+// legacy flat RetryInterval behaviour.
+type PushRetryPolicy struct {
+	InitialDelaySec int     `json:"initialDelaySec"`
+	Multiplier      float64 `json:"multiplier"`
+	MaxDelaySec     int     `json:"maxDelaySec"`
+	MaxAttempts     int     `json:"maxAttempts"`
+	ResetWindowSec  int     `json:"resetWindowSec"`
+}
+
+// PushFailover is the fallback chain used when the primary input keeps failing:
+// backup camera, then slate video material, then a generated test card. Zero IDs skip a step.
+type PushFailover struct {
+	Enabled          bool  `json:"enabled"`
+	FailureThreshold int   `json:"failureThreshold"`
+	BackupCameraID   int64 `json:"backupCameraId"`
+	SlateMaterialID  int64 `json:"slateMaterialId"`
+	TestCard         bool  `json:"testCard"`
+	ProbeIntervalSec int   `json:"probeIntervalSec"`
+}
+
+const (
+	RecordingFormatMP4 = "mp4"
+	RecordingFormatFLV = "flv"
+)
+
+// PushRecording makes the channel also write what it pushes into segment files of SegmentSec seconds
+// under the recording directory. mp4 segments are fragmented so a crash only loses the last fragment.
+type PushRecording struct {
+	Enabled    bool   `json:"enabled"`
+	Format     string `json:"format"`
+	SegmentSec int    `json:"segmentSec"`
+}
+
+// Sources of PushAudioMix, as named by the API and bot commands.
+const (
+	AudioMixSourceInput  = "input"
+	AudioMixSourceMusic  = "music"
+	AudioMixSourceDevice = "device"
+)
+
+// PushAudioMix replaces the single audio source of a normal-mode channel with a mix of the input's own
+// audio, a looping background music playlist of audio materials (the channel's audio material when
+// MusicMaterialIDs is empty) and a capture device such as a microphone. Muted sources are left out of
+// the mix, so an input without an audio track has to be muted. Ducking lowers the music by DuckRatio
+// whenever the input or device is louder than DuckThresholdDB; a non-zero TargetLUFS normalizes the
+// loudness of the mix.
+type PushAudioMix struct {
+	Enabled          bool               `json:"enabled"`
+	Input            PushAudioMixSource `json:"input"`
+	Music            PushAudioMixSource `json:"music"`
+	Device           PushAudioMixSource `json:"device"`
+	MusicMaterialIDs []int64            `json:"musicMaterialIds"`
+	DeviceName       string             `json:"deviceName"`
+	Ducking          bool               `json:"ducking"`
+	DuckThresholdDB  float64            `json:"duckThresholdDb"`
+	DuckRatio        float64            `json:"duckRatio"`
+	TargetLUFS       float64            `json:"targetLufs"`
+}
+
+// PushAudioMixSource is the gain in dB and the mute switch of one source of the audio mix.
+type PushAudioMixSource struct {
+	GainDB float64 `json:"gainDb"`
+	Muted  bool    `json:"muted"`
+}
+
+// Source returns the settings of a named mix source, nil for an unknown name.
+func (m *PushAudioMix) Source(name string) *PushAudioMixSource {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case AudioMixSourceInput:
+		return &m.Input
+	case AudioMixSourceMusic:
+		return &m.Music
+	case AudioMixSourceDevice:
+		return &m.Device
+	default:
+		return nil
+	}
+}
+
+// PushInputProbe is what ffprobe found in one input of a push command.
+type PushInputProbe struct {
+	Source          string  `json:"source"`
+	Format          string  `json:"format,omitempty"`
+	Reachable       bool    `json:"reachable"`
+	Error           string  `json:"error,omitempty"`
+	Skipped         string  `json:"skipped,omitempty"`
+	ProbeMs         int64   `json:"probeMs"`
+	DurationSec     float64 `json:"durationSec,omitempty"`
+	VideoCodec      string  `json:"videoCodec,omitempty"`
+	Width           int     `json:"width,omitempty"`
+	Height          int     `json:"height,omitempty"`
+	FPS             float64 `json:"fps,omitempty"`
+	HasAudio        bool    `json:"hasAudio"`
+	AudioCodec      string  `json:"audioCodec,omitempty"`
+	AudioSampleRate int     `json:"audioSampleRate,omitempty"`
+	AudioChannels   int     `json:"audioChannels,omitempty"`
+}
+
+// PushValidationIssue is one finding of a dry run; errors would stop the push, warnings may not.
+type PushValidationIssue struct {
+	Level   string `json:"level"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PushValidationReport is the result of a dry run of a push setting: the command it would run, what
+// its inputs contain and what is likely to go wrong. Valid means no error-level issue was found.
+type PushValidationReport struct {
+	ChannelID   int64                 `json:"channelId"`
+	Valid       bool                  `json:"valid"`
+	Command     string                `json:"command,omitempty"`
+	Args        []string              `json:"args,omitempty"`
+	CommandLine string                `json:"commandLine,omitempty"`
+	Inputs      []PushInputProbe      `json:"inputs"`
+	Issues      []PushValidationIssue `json:"issues"`
+	CheckedAt   time.Time             `json:"checkedAt"`
+}
+
+type PushFailoverSource string
+
+const (
+	PushFailoverPrimary      PushFailoverSource = "primary"
+	PushFailoverBackupCamera PushFailoverSource = "backup_camera"
+	PushFailoverSlate        PushFailoverSource = "slate"
+	PushFailoverTestCard     PushFailoverSource = "test_card"
+)
+
+// PushFailoverState reports which source of the fallback chain the channel is pushing.
+type PushFailoverState struct {
+	Source         PushFailoverSource `json:"source"`
+	Failures       int                `json:"failures"`
+	Since          *time.Time         `json:"since,omitempty"`
+	LastProbeAt    *time.Time         `json:"lastProbeAt,omitempty"`
+	LastProbeError string             `json:"lastProbeError,omitempty"`
+}
+
+type PushFailureClass string
+
+const (
+	PushFailureNone             PushFailureClass = ""
+	PushFailureAuthRejected     PushFailureClass = "auth_rejected"
+	PushFailureBadArguments     PushFailureClass = "bad_arguments"
+	PushFailureInputUnreachable PushFailureClass = "input_unreachable"
+	PushFailureStalled          PushFailureClass = "stalled"
+	PushFailureUnknown          PushFailureClass = "unknown"
+)
+
+// Retryable reports whether another attempt can succeed without the user changing anything.
+func (c PushFailureClass) Retryable() bool {
+	return c != PushFailureAuthRejected && c != PushFailureBadArguments
+}
+
+// PushRetryState is the retry bookkeeping of the running push loop.
+type PushRetryState struct {
+	Attempt      int              `json:"attempt"`
+	MaxAttempts  int              `json:"maxAttempts"`
+	NextRetryAt  *time.Time       `json:"nextRetryAt,omitempty"`
+	FailureClass PushFailureClass `json:"failureClass"`
+	LastError    string           `json:"lastError"`
+	Aborted      bool             `json:"aborted"`
+	UpdatedAt    time.Time        `json:"updatedAt"`
+}
+
+// PushAlert is raised when the push loop gives up instead of retrying.
+type PushAlert struct {
+	ChannelID    int64            `json:"channelId"`
+	FailureClass PushFailureClass `json:"failureClass"`
+	Reason       string           `json:"reason"`
+	Attempts     int              `json:"attempts"`
+	Time         time.Time        `json:"time"`
+}
+
+// PushChannelSaveRequest creates or renames a push channel and binds it to a Bilibili room.
+// A zero RoomID keeps the channel on the global live room setting.
+type PushChannelSaveRequest struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	RoomID    int64  `json:"roomId"`
+	AreaID    int    `json:"areaId"`
+	RoomTitle string `json:"roomTitle"`
+	// AccountID binds the channel to a Bilibili account, 0 for the main one; nil keeps the stored binding.
+	AccountID *int64 `json:"accountId"`
+}
+
+type PushChannelStatus struct {
+	Setting *PushSetting       `json:"setting"`
+	Status  PushStatus         `json:"status"`
+	Outputs []PushOutputStatus `json:"outputs,omitempty"`
+}
+
+type PushOutputState string
+
+const (
+	PushOutputStatePending  PushOutputState = "pending"
+	PushOutputStateRunning  PushOutputState = "running"
+	PushOutputStateFailed   PushOutputState = "failed"
+	PushOutputStateStopped  PushOutputState = "stopped"
+	PushOutputStateDisabled PushOutputState = "disabled"
+)
+
+type PushOutputStatus struct {
+	Index         int                     `json:"index"`
+	Name          string                  `json:"name"`
+	URL           string                  `json:"url"`
+	Primary       bool                    `json:"primary"`
+	Enabled       bool                    `json:"enabled"`
+	FailurePolicy PushOutputFailurePolicy `json:"failurePolicy"`
+	State         PushOutputState         `json:"state"`
+	LastError     string                  `json:"lastError"`
+	UpdatedAt     time.Time               `json:"updatedAt"`
 }
 
 type PushStatusResponse struct {
-	Status PushStatus `json:"status"`
+	ChannelID int64               `json:"channelId"`
+	Status    PushStatus          `json:"status"`
+	Outputs   []PushOutputStatus  `json:"outputs,omitempty"`
+	Retry     *PushRetryState     `json:"retry,omitempty"`
+	Failover  *PushFailoverState  `json:"failover,omitempty"`
+	Playlist  *PlaylistNowPlaying `json:"playlist,omitempty"`
 }
 
 type LiveSetting struct {
@@ -221,14 +509,46 @@ type MonitorSetting struct {
 	MailName            string    `json:"mailName"`
 	Password            string    `json:"password"`
 	Receivers           string    `json:"receivers"`
+	PollIntervalSec     int       `json:"pollIntervalSec"`
+	DebounceCount       int       `json:"debounceCount"`
+	CooldownSec         int       `json:"cooldownSec"`
 	CreatedAt           time.Time `json:"createdAt"`
 	UpdatedAt           time.Time `json:"updatedAt"`
 }
 
+// MonitorRoomInfoUpdateRequest changes the watched room. Zero PollIntervalSec and DebounceCount fall back
+// to 60s and 2 polls; a missing CooldownSec falls back to 600s, while 0 turns the cooldown off.
 type MonitorRoomInfoUpdateRequest struct {
-	IsEnabled bool   `json:"isEnabled"`
-	RoomID    int64  `json:"roomId"`
-	RoomURL   string `json:"roomUrl"`
+	IsEnabled       bool   `json:"isEnabled"`
+	RoomID          int64  `json:"roomId"`
+	RoomURL         string `json:"roomUrl"`
+	PollIntervalSec int    `json:"pollIntervalSec"`
+	DebounceCount   int    `json:"debounceCount"`
+	CooldownSec     *int   `json:"cooldownSec"`
+}
+
+// Kinds of MonitorStateChange.
+const (
+	MonitorChangeStarted = "started"
+	MonitorChangeOffline = "offline"
+	MonitorChangeTitle   = "title"
+)
+
+// MonitorStateChange is one transition the room monitor confirmed. Status is the live_status of the
+// room info API (0 offline, 1 live, 2 rotating replays). Unexpected marks an offline while a channel of
+// this app was still pushing to the room, or one the app could not account for.
+type MonitorStateChange struct {
+	ID            int64     `json:"id"`
+	RoomID        int64     `json:"roomId"`
+	Kind          string    `json:"kind"`
+	FromStatus    int       `json:"fromStatus"`
+	ToStatus      int       `json:"toStatus"`
+	PreviousTitle string    `json:"previousTitle"`
+	Title         string    `json:"title"`
+	Unexpected    bool      `json:"unexpected"`
+	Notified      bool      `json:"notified"`
+	NotifyError   string    `json:"notifyError"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
 
 type MonitorEmailUpdateRequest struct {
@@ -250,6 +570,29 @@ type CookieSetting struct {
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+// BilibiliAccount is an extra logged-in Bilibili account. Account 0 is the main account kept in
+// cookie_settings; the bot account is the one automated replies send danmaku as.
+type BilibiliAccount struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	UID          int64      `json:"uid"`
+	Uname        string     `json:"uname"`
+	Face         string     `json:"face"`
+	Cookie       string     `json:"-"`
+	RefreshToken string     `json:"-"`
+	HasCookie    bool       `json:"hasCookie"`
+	IsBot        bool       `json:"isBot"`
+	CheckedAt    *time.Time `json:"checkedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+type BilibiliAccountSaveRequest struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	IsBot bool   `json:"isBot"`
+}
+
 type Material struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
@@ -290,26 +633,128 @@ type FFmpegLogItem struct {
 	Message string    `json:"message"`
 }
 
+// PushMetrics is one ffmpeg -progress report.
+type PushMetrics struct {
+	Frame       int64     `json:"frame"`
+	FPS         float64   `json:"fps"`
+	BitrateKbps float64   `json:"bitrateKbps"`
+	TotalSize   int64     `json:"totalSize"`
+	OutTimeMS   int64     `json:"outTimeMs"`
+	Speed       float64   `json:"speed"`
+	DupFrames   int64     `json:"dupFrames"`
+	DropFrames  int64     `json:"dropFrames"`
+	Progress    string    `json:"progress"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type PushMetricsResponse struct {
+	ChannelID int64         `json:"channelId"`
+	Status    PushStatus    `json:"status"`
+	Current   *PushMetrics  `json:"current,omitempty"`
+	Series    []PushMetrics `json:"series"`
+}
+
+type StreamSessionStatus string
+
+const (
+	StreamSessionRunning     StreamSessionStatus = "running"
+	StreamSessionFinished    StreamSessionStatus = "finished"
+	StreamSessionStopped     StreamSessionStatus = "stopped"
+	StreamSessionFailed      StreamSessionStatus = "failed"
+	StreamSessionStalled     StreamSessionStatus = "stalled"
+	StreamSessionInterrupted StreamSessionStatus = "interrupted"
+)
+
+// StreamSession is one ffmpeg run of a push channel.
+type StreamSession struct {
+	ID                int64               `json:"id"`
+	ChannelID         int64               `json:"channelId"`
+	RoomID            int64               `json:"roomId"`
+	StartedAt         time.Time           `json:"startedAt"`
+	EndedAt           *time.Time          `json:"endedAt,omitempty"`
+	DurationSec       int64               `json:"durationSec"`
+	Status            StreamSessionStatus `json:"status"`
+	ExitCode          *int                `json:"exitCode,omitempty"`
+	FailureClass      PushFailureClass    `json:"failureClass"`
+	Note              string              `json:"note"`
+	InputType         InputType           `json:"inputType"`
+	OutputResolution  string              `json:"outputResolution"`
+	OutputBitrateKbps int                 `json:"outputBitrateKbps"`
+	Metrics           *PushMetricSummary  `json:"metrics,omitempty"`
+	Revenue           *LiveRevenueSummary `json:"revenue,omitempty"`
+}
+
+type StreamSessionListRequest struct {
+	ChannelID int64  `json:"channelId"`
+	Status    string `json:"status"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
+
+// PushMetricSummary aggregates the progress samples of one ffmpeg run.
+type PushMetricSummary struct {
+	ID             int64     `json:"id"`
+	ChannelID      int64     `json:"channelId"`
+	SessionID      *int64    `json:"sessionId,omitempty"`
+	StartedAt      time.Time `json:"startedAt"`
+	EndedAt        time.Time `json:"endedAt"`
+	Samples        int       `json:"samples"`
+	AvgFPS         float64   `json:"avgFps"`
+	MinFPS         float64   `json:"minFps"`
+	MaxFPS         float64   `json:"maxFps"`
+	AvgBitrateKbps float64   `json:"avgBitrateKbps"`
+	MinBitrateKbps float64   `json:"minBitrateKbps"`
+	AvgSpeed       float64   `json:"avgSpeed"`
+	MinSpeed       float64   `json:"minSpeed"`
+	Frames         int64     `json:"frames"`
+	DupFrames      int64     `json:"dupFrames"`
+	DropFrames     int64     `json:"dropFrames"`
+	TotalSize      int64     `json:"totalSize"`
+	OutTimeMS      int64     `json:"outTimeMs"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
 // Placeholder entities for future integrations.
+// DanmakuPTZRule runs an action when a message-stream event of EventType (danmaku when empty) matches:
+// its text contains Keyword ("*" matches any) and, for paid events, its value reaches MinGold.
 type DanmakuPTZRule struct {
 	ID           int64     `json:"id"`
+	EventType    string    `json:"eventType"`
 	Keyword      string    `json:"keyword"`
+	MinGold      int64     `json:"minGold"`
 	Action       string    `json:"action"`
 	PTZDirection string    `json:"ptzDirection"`
 	PTZSpeed     int       `json:"ptzSpeed"`
+	SceneID      int64     `json:"sceneId"`
 	Enabled      bool      `json:"enabled"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+// WebhookSetting is a webhook target. Events lists the event types it receives, where an entry such as
+// "live" also covers "live.gift"; an empty list receives every event but the message-stream ones.
 type WebhookSetting struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
 	URL       string    `json:"url"`
 	Secret    string    `json:"secret"`
 	Enabled   bool      `json:"enabled"`
+	Events    []string  `json:"events"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// Accepts reports whether the webhook subscribes to an event type.
+func (w WebhookSetting) Accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return !IsLiveMessageEvent(eventType)
+	}
+	for _, item := range w.Events {
+		if item == "*" || item == eventType || strings.HasPrefix(eventType, item+".") {
+			return true
+		}
+	}
+	return false
+}
+
 type APIKeySetting struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
@@ -430,14 +875,61 @@ type BilibiliAPIErrorLog struct {
 	CreatedAt       time.Time `json:"createdAt"`
 }
 
+// MaintenanceSetting drives the periodic cleanup. Recordings have their own limits: segments older than
+// RecordingRetentionDays go first, then the oldest ones until the total is under RecordingMaxSizeMB.
+// Zero disables a limit.
 type MaintenanceSetting struct {
-	ID            int64      `json:"id"`
-	Enabled       bool       `json:"enabled"`
-	RetentionDays int        `json:"retentionDays"`
-	AutoVacuum    bool       `json:"autoVacuum"`
-	LastCleanupAt *time.Time `json:"lastCleanupAt,omitempty"`
-	LastVacuumAt  *time.Time `json:"lastVacuumAt,omitempty"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
+	ID                     int64      `json:"id"`
+	Enabled                bool       `json:"enabled"`
+	RetentionDays          int        `json:"retentionDays"`
+	AutoVacuum             bool       `json:"autoVacuum"`
+	RecordingRetentionDays int        `json:"recordingRetentionDays"`
+	RecordingMaxSizeMB     int64      `json:"recordingMaxSizeMb"`
+	LastCleanupAt          *time.Time `json:"lastCleanupAt,omitempty"`
+	LastVacuumAt           *time.Time `json:"lastVacuumAt,omitempty"`
+	UpdatedAt              time.Time  `json:"updatedAt"`
+}
+
+// CookieHealthSetting drives the background login check of the Bilibili accounts. Besides every
+// IntervalMinutes, the accounts are checked LeadMinutes before the next scheduled start, so a lost login
+// is reported while there is still time to log in again; a zero LeadMinutes skips that. Provider and
+// ProviderParams name the bot provider alerts go to and the params it needs (e.g. chatId).
+type CookieHealthSetting struct {
+	ID              int64          `json:"id"`
+	Enabled         bool           `json:"enabled"`
+	IntervalMinutes int            `json:"intervalMinutes"`
+	LeadMinutes     int            `json:"leadMinutes"`
+	EmailAlert      bool           `json:"emailAlert"`
+	WebhookAlert    bool           `json:"webhookAlert"`
+	Provider        string         `json:"provider"`
+	ProviderParams  map[string]any `json:"providerParams"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+}
+
+// Outcomes of a cookie health check.
+const (
+	CookieHealthOK            = "ok"
+	CookieHealthRefreshed     = "refreshed"
+	CookieHealthRefreshFailed = "refresh_failed"
+	CookieHealthLoggedOut     = "logged_out"
+	CookieHealthCheckFailed   = "check_failed"
+)
+
+// CookieHealthCheck is the outcome of checking one account, kept as a bilibili.cookie.check live event.
+// Reason is scheduled, pre_stream or manual.
+type CookieHealthCheck struct {
+	AccountID int64     `json:"accountId"`
+	Account   string    `json:"account"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason"`
+	Error     string    `json:"error,omitempty"`
+	Alerted   bool      `json:"alerted"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Failed reports whether the outcome needs someone to log in again.
+func (c CookieHealthCheck) Failed() bool {
+	return c.Outcome == CookieHealthRefreshFailed || c.Outcome == CookieHealthLoggedOut
 }
 
 type CleanupStats struct {
@@ -447,7 +939,11 @@ type CleanupStats struct {
 	BilibiliErrorLogs   int64 `json:"bilibiliErrorLogs"`
 	IntegrationTasks    int64 `json:"integrationTasks"`
 	StreamSessions      int64 `json:"streamSessions"`
+	PushMetricSummaries int64 `json:"pushMetricSummaries"`
+	LiveInteractions    int64 `json:"liveInteractions"`
 	Total               int64 `json:"total"`
+	Recordings          int64 `json:"recordings"`
+	RecordingBytes      int64 `json:"recordingBytes"`
 }
 
 type DBStats struct {
@@ -526,6 +1022,24 @@ func (c CameraSource) StreamURL() string {
 	}
 }
 
+// Kinds of CameraSourceReference.
+const (
+	CameraReferenceMosaic   = "mosaic"
+	CameraReferenceFailover = "failover"
+	CameraReferenceScene    = "scene"
+	CameraReferenceSchedule = "schedule"
+	CameraReferenceCommand  = "command"
+)
+
+// CameraSourceReference is a record that uses a camera source by id: the mosaic, failover chain or
+// advanced-mode command of a push channel, a scene or a switch_camera schedule.
+type CameraSourceReference struct {
+	Kind      string `json:"kind"`
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	ChannelID int64  `json:"channelId"`
+}
+
 type CameraSourceListRequest struct {
 	Keyword    string
 	SourceType string
@@ -712,6 +1226,134 @@ type DanmakuRecord struct {
 	CreatedAt  time.Time `json:"createdAt"`
 }
 
+// Event types of the Bilibili message stream. Rules match them by EventType; webhooks and the event
+// hub get them as "live.<type>".
+const (
+	LiveMessageDanmaku    = "danmaku"
+	LiveMessageGift       = "gift"
+	LiveMessageSuperChat  = "super_chat"
+	LiveMessageGuard      = "guard"
+	LiveMessageEnter      = "enter"
+	LiveMessageFollow     = "follow"
+	LiveMessageShare      = "share"
+	LiveMessageLike       = "like"
+	LiveMessageRoomChange = "room_change"
+	LiveMessageStats      = "stats"
+)
+
+// LiveMessageEventPrefix prefixes the webhook and event hub types of message-stream events.
+const LiveMessageEventPrefix = "live."
+
+// IsLiveMessageEvent reports whether an event type is one of the message-stream events, like "live.gift".
+func IsLiveMessageEvent(eventType string) bool {
+	name, ok := strings.CutPrefix(eventType, LiveMessageEventPrefix)
+	return ok && name != LiveMessageDanmaku && ruleEventTypes[name]
+}
+
+// LiveGift is one SEND_GIFT message. Prices are in gold seeds (1000 = 1 CNY = 10 batteries) for gold
+// gifts and in silver seeds for free ones.
+type LiveGift struct {
+	ID        int64     `json:"id"`
+	RoomID    int64     `json:"roomId"`
+	UID       int64     `json:"uid"`
+	Uname     string    `json:"uname"`
+	GiftID    int64     `json:"giftId"`
+	GiftName  string    `json:"giftName"`
+	Num       int64     `json:"num"`
+	CoinType  string    `json:"coinType"`
+	Price     int64     `json:"price"`
+	TotalCoin int64     `json:"totalCoin"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LiveSuperChat is one SUPER_CHAT_MESSAGE. Price is in CNY.
+type LiveSuperChat struct {
+	ID          int64     `json:"id"`
+	RoomID      int64     `json:"roomId"`
+	SuperChatID int64     `json:"superChatId"`
+	UID         int64     `json:"uid"`
+	Uname       string    `json:"uname"`
+	Message     string    `json:"message"`
+	Price       int64     `json:"price"`
+	DurationSec int64     `json:"durationSec"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// LiveGuardBuy is one GUARD_BUY. GuardLevel is 1 (总督), 2 (提督) or 3 (舰长); Price is in gold seeds
+// per month and Num the months bought.
+type LiveGuardBuy struct {
+	ID         int64     `json:"id"`
+	RoomID     int64     `json:"roomId"`
+	UID        int64     `json:"uid"`
+	Uname      string    `json:"uname"`
+	GuardLevel int       `json:"guardLevel"`
+	GiftName   string    `json:"giftName"`
+	Num        int64     `json:"num"`
+	Price      int64     `json:"price"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// LiveInteraction is an enter, follow or share (INTERACT_WORD) or a like click (LIKE_INFO_V3_CLICK).
+type LiveInteraction struct {
+	ID        int64     `json:"id"`
+	RoomID    int64     `json:"roomId"`
+	UID       int64     `json:"uid"`
+	Uname     string    `json:"uname"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LiveRevenueSummary totals the paid messages of a room. Gold values are gold seeds (1000 = 1 CNY =
+// 10 batteries); silver gifts are free and only counted in SilverCoin.
+type LiveRevenueSummary struct {
+	GiftGold       int64 `json:"giftGold"`
+	SuperChatGold  int64 `json:"superChatGold"`
+	GuardGold      int64 `json:"guardGold"`
+	TotalGold      int64 `json:"totalGold"`
+	Battery        int64 `json:"battery"`
+	SilverCoin     int64 `json:"silverCoin"`
+	GiftCount      int64 `json:"giftCount"`
+	SuperChatCount int64 `json:"superChatCount"`
+	GuardCount     int64 `json:"guardCount"`
+}
+
+// LiveRevenueDay is the revenue of one UTC day.
+type LiveRevenueDay struct {
+	Day string `json:"day"`
+	LiveRevenueSummary
+}
+
+// LiveSessionRevenue is the revenue of the session's room while the session ran.
+type LiveSessionRevenue struct {
+	SessionID int64      `json:"sessionId"`
+	ChannelID int64      `json:"channelId"`
+	RoomID    int64      `json:"roomId"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	LiveRevenueSummary
+}
+
+// LiveSupporter is a viewer ranked by the gold they spent.
+type LiveSupporter struct {
+	UID           int64  `json:"uid"`
+	Uname         string `json:"uname"`
+	GiftGold      int64  `json:"giftGold"`
+	SuperChatGold int64  `json:"superChatGold"`
+	GuardGold     int64  `json:"guardGold"`
+	TotalGold     int64  `json:"totalGold"`
+	Battery       int64  `json:"battery"`
+}
+
+// LiveGiftStat is the count and value of one gift type.
+type LiveGiftStat struct {
+	GiftID    int64  `json:"giftId"`
+	GiftName  string `json:"giftName"`
+	CoinType  string `json:"coinType"`
+	Times     int64  `json:"times"`
+	Num       int64  `json:"num"`
+	TotalCoin int64  `json:"totalCoin"`
+}
+
 type LoginStatus struct {
 	Status       int           `json:"status"`
 	RedirectURL  string        `json:"redirectUrl,omitempty"`
@@ -763,6 +1405,357 @@ type MyLiveRoomInfo struct {
 	} `json:"audit_info"`
 }
 
+// ---------- Recordings ----------
+
+// Recording is one finished DVR segment. Path is relative to the recording directory; SessionID is
+// the stream session that was running when the segment started.
+type Recording struct {
+	ID        int64     `json:"id"`
+	ChannelID int64     `json:"channelId"`
+	SessionID int64     `json:"sessionId"`
+	FileName  string    `json:"fileName"`
+	Path      string    `json:"path"`
+	Format    string    `json:"format"`
+	SizeBytes int64     `json:"sizeBytes"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RecordingListRequest struct {
+	ChannelID int64 `json:"channelId"`
+	SessionID int64 `json:"sessionId"`
+	Page      int   `json:"page"`
+	Limit     int   `json:"limit"`
+}
+
+// ---------- Overlays ----------
+
+type OverlayType string
+
+const (
+	OverlayTypeText      OverlayType = "text"
+	OverlayTypeClock     OverlayType = "clock"
+	OverlayTypeCountdown OverlayType = "countdown"
+	OverlayTypeImage     OverlayType = "image"
+	OverlayTypeLiveValue OverlayType = "live_value"
+	OverlayTypeDanmaku   OverlayType = "danmaku"
+)
+
+func NormalizeOverlayType(raw string) OverlayType {
+	switch OverlayType(strings.ToLower(strings.TrimSpace(raw))) {
+	case OverlayTypeText, OverlayTypeClock, OverlayTypeCountdown, OverlayTypeImage, OverlayTypeLiveValue, OverlayTypeDanmaku:
+		return OverlayType(strings.ToLower(strings.TrimSpace(raw)))
+	default:
+		return ""
+	}
+}
+
+// OverlayStyle positions a layer. X/Y are ffmpeg expressions (e.g. "w-tw-20"); Width scales images
+// (0 keeps the original size) and Opacity fades them.
+type OverlayStyle struct {
+	X         string  `json:"x"`
+	Y         string  `json:"y"`
+	FontSize  int     `json:"fontSize"`
+	FontColor string  `json:"fontColor"`
+	FontFile  string  `json:"fontFile"`
+	Box       bool    `json:"box"`
+	BoxColor  string  `json:"boxColor"`
+	Width     int     `json:"width"`
+	Opacity   float64 `json:"opacity"`
+}
+
+const (
+	OverlayDanmakuModeStack  = "stack"
+	OverlayDanmakuModeScroll = "scroll"
+)
+
+// OverlayDanmakuConfig shapes a danmaku chat box. Stack mode draws the last MaxLines messages as lines
+// below Style.Y, newest at the bottom; scroll mode runs them through one ticker line at ScrollSpeed
+// px/s. Names are colored per user in stack mode only: UserColors (uid -> color) first, otherwise a
+// color picked from NameColors by uid. Every extra color costs one drawtext per line, so at most
+// eight distinct colors are allowed. Messages containing BlockedWords are masked, or dropped with
+// DropBlocked.
+type OverlayDanmakuConfig struct {
+	Mode         string            `json:"mode"`
+	MaxLines     int               `json:"maxLines"`
+	MaxChars     int               `json:"maxChars"`
+	HideNames    bool              `json:"hideNames"`
+	ScrollSpeed  int               `json:"scrollSpeed"`
+	NameColors   []string          `json:"nameColors"`
+	UserColors   map[string]string `json:"userColors"`
+	BlockedWords []string          `json:"blockedWords"`
+	DropBlocked  bool              `json:"dropBlocked"`
+}
+
+// Overlay is a named layer drawn over the video of a push channel in normal mode. Content depends on
+// Type: the text itself, a strftime format (clock), a template with {remaining} (countdown) or the
+// current value of LiveKey (live_value); danmaku layers ignore it and show incoming danmaku shaped by
+// Danmaku. Text content is rendered from a reloaded text file, so it can change while streaming;
+// adding, removing or moving layers takes effect on the next ffmpeg start.
+type Overlay struct {
+	ID         int64                `json:"id"`
+	ChannelID  int64                `json:"channelId"`
+	Name       string               `json:"name"`
+	Type       OverlayType          `json:"type"`
+	Enabled    bool                 `json:"enabled"`
+	ZIndex     int                  `json:"zIndex"`
+	Content    string               `json:"content"`
+	LiveKey    string               `json:"liveKey"`
+	TargetAt   *time.Time           `json:"targetAt,omitempty"`
+	MaterialID int64                `json:"materialId"`
+	Style      OverlayStyle         `json:"style"`
+	Danmaku    OverlayDanmakuConfig `json:"danmaku"`
+	CreatedAt  time.Time            `json:"createdAt"`
+	UpdatedAt  time.Time            `json:"updatedAt"`
+}
+
+type OverlaySaveRequest struct {
+	ID         int64                `json:"id"`
+	ChannelID  int64                `json:"channelId"`
+	Name       string               `json:"name"`
+	Type       string               `json:"type"`
+	Enabled    bool                 `json:"enabled"`
+	ZIndex     int                  `json:"zIndex"`
+	Content    string               `json:"content"`
+	LiveKey    string               `json:"liveKey"`
+	TargetAt   *time.Time           `json:"targetAt"`
+	MaterialID int64                `json:"materialId"`
+	Style      OverlayStyle         `json:"style"`
+	Danmaku    OverlayDanmakuConfig `json:"danmaku"`
+}
+
+// ---------- Schedules ----------
+
+type PushScheduleAction string
+
+const (
+	PushScheduleActionStart        PushScheduleAction = "start"
+	PushScheduleActionStop         PushScheduleAction = "stop"
+	PushScheduleActionSwitchCamera PushScheduleAction = "switch_camera"
+	PushScheduleActionSwitchInput  PushScheduleAction = "switch_input"
+	PushScheduleActionUpdateRoom   PushScheduleAction = "update_room"
+)
+
+func NormalizePushScheduleAction(raw string) PushScheduleAction {
+	switch PushScheduleAction(strings.ToLower(strings.TrimSpace(raw))) {
+	case PushScheduleActionStart, PushScheduleActionStop, PushScheduleActionSwitchCamera, PushScheduleActionSwitchInput, PushScheduleActionUpdateRoom:
+		return PushScheduleAction(strings.ToLower(strings.TrimSpace(raw)))
+	default:
+		return ""
+	}
+}
+
+// PushScheduleWindow is a weekly window. Days are 0 (Sunday) to 6, Start/End are "HH:MM" in the
+// schedule timezone; an End before Start runs past midnight.
+type PushScheduleWindow struct {
+	Days  []int  `json:"days"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// PushScheduleParams carries the arguments of an action; fields an action does not use are ignored.
+type PushScheduleParams struct {
+	CameraID        int64  `json:"cameraId"`
+	InputType       string `json:"inputType"`
+	VideoMaterialID int64  `json:"videoId"`
+	RTSPURL         string `json:"rtspUrl"`
+	MJPEGURL        string `json:"mjpegUrl"`
+	RTMPURL         string `json:"rtmpUrl"`
+	Title           string `json:"title"`
+	AreaID          int    `json:"areaId"`
+}
+
+// PushSchedule fires Action on a channel either by CronExpr or, when no expression is set, at the
+// start of every window. A start action inside windows also stops the channel at the window end.
+type PushSchedule struct {
+	ID        int64                `json:"id"`
+	Name      string               `json:"name"`
+	Enabled   bool                 `json:"enabled"`
+	ChannelID int64                `json:"channelId"`
+	Action    PushScheduleAction   `json:"action"`
+	CronExpr  string               `json:"cronExpr"`
+	Windows   []PushScheduleWindow `json:"windows"`
+	Timezone  string               `json:"timezone"`
+	Params    PushScheduleParams   `json:"params"`
+	SkipDates []string             `json:"skipDates"`
+	SkipCount int                  `json:"skipCount"`
+	LastRunAt *time.Time           `json:"lastRunAt,omitempty"`
+	NextRunAt *time.Time           `json:"nextRunAt,omitempty"`
+	CreatedAt time.Time            `json:"createdAt"`
+	UpdatedAt time.Time            `json:"updatedAt"`
+}
+
+type PushScheduleSaveRequest struct {
+	ID        int64                `json:"id"`
+	Name      string               `json:"name"`
+	Enabled   bool                 `json:"enabled"`
+	ChannelID int64                `json:"channelId"`
+	Action    string               `json:"action"`
+	CronExpr  string               `json:"cronExpr"`
+	Windows   []PushScheduleWindow `json:"windows"`
+	Timezone  string               `json:"timezone"`
+	Params    PushScheduleParams   `json:"params"`
+	SkipDates []string             `json:"skipDates"`
+	SkipCount int                  `json:"skipCount"`
+}
+
+// PushScheduleRun is one firing of a schedule, stored as a "schedule.run" live event.
+type PushScheduleRun struct {
+	ScheduleID  int64              `json:"scheduleId"`
+	Name        string             `json:"name"`
+	ChannelID   int64              `json:"channelId"`
+	Action      PushScheduleAction `json:"action"`
+	Trigger     string             `json:"trigger"`
+	Status      string             `json:"status"`
+	Message     string             `json:"message"`
+	ScheduledAt time.Time          `json:"scheduledAt"`
+	FinishedAt  time.Time          `json:"finishedAt"`
+}
+
+// ---------- Playlists ----------
+
+type PlaylistMode string
+
+const (
+	// PlaylistModeSequential plays the items once in order and then ends the push.
+	PlaylistModeSequential PlaylistMode = "sequential"
+	PlaylistModeLoop       PlaylistMode = "loop"
+	// PlaylistModeShuffle plays the items in a new random order on every pass.
+	PlaylistModeShuffle PlaylistMode = "shuffle"
+)
+
+func NormalizePlaylistMode(raw string) PlaylistMode {
+	switch PlaylistMode(strings.ToLower(strings.TrimSpace(raw))) {
+	case PlaylistModeSequential:
+		return PlaylistModeSequential
+	case PlaylistModeShuffle:
+		return PlaylistModeShuffle
+	default:
+		return PlaylistModeLoop
+	}
+}
+
+// PlaylistItem is one material of a playlist. InPoint/OutPoint are seconds into the file; a zero
+// OutPoint plays to the end.
+type PlaylistItem struct {
+	MaterialID int64   `json:"materialId"`
+	InPoint    float64 `json:"inPoint"`
+	OutPoint   float64 `json:"outPoint"`
+}
+
+type Playlist struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Mode      PlaylistMode   `json:"mode"`
+	Items     []PlaylistItem `json:"items"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+type PlaylistSaveRequest struct {
+	ID    int64          `json:"id"`
+	Name  string         `json:"name"`
+	Mode  string         `json:"mode"`
+	Items []PlaylistItem `json:"items"`
+}
+
+// PlaylistNowPlaying reports the item a playlist channel is currently sending.
+type PlaylistNowPlaying struct {
+	PlaylistID   int64        `json:"playlistId"`
+	Mode         PlaylistMode `json:"mode"`
+	Index        int          `json:"index"`
+	Count        int          `json:"count"`
+	MaterialID   int64        `json:"materialId"`
+	MaterialName string       `json:"materialName"`
+	InPoint      float64      `json:"inPoint"`
+	OutPoint     float64      `json:"outPoint"`
+	StartedAt    time.Time    `json:"startedAt"`
+}
+
+// ---------- Encoder profiles ----------
+
+type EncoderRateControl string
+
+const (
+	EncoderRateControlCBR EncoderRateControl = "cbr"
+	EncoderRateControlVBR EncoderRateControl = "vbr"
+	EncoderRateControlCRF EncoderRateControl = "crf"
+)
+
+func NormalizeEncoderRateControl(raw string) EncoderRateControl {
+	switch EncoderRateControl(strings.ToLower(strings.TrimSpace(raw))) {
+	case EncoderRateControlVBR:
+		return EncoderRateControlVBR
+	case EncoderRateControlCRF:
+		return EncoderRateControlCRF
+	default:
+		return EncoderRateControlCBR
+	}
+}
+
+// EncoderProfile is a named set of encoder parameters a push channel can use instead of the built-in
+// quality levels. BitrateKbps is the target (CBR/VBR) or cap (CRF) and yields to the channel's own
+// outputBitrateKbps; 0 falls back to the quality level. Empty Preset/Tune/Profile/Level leave the
+// encoder default, and ExtraArgs are appended after the generated video arguments.
+type EncoderProfile struct {
+	ID               int64              `json:"id"`
+	Name             string             `json:"name"`
+	VideoCodec       string             `json:"videoCodec"`
+	FrameRate        int                `json:"frameRate"`
+	GOPSec           float64            `json:"gopSec"`
+	Preset           string             `json:"preset"`
+	Tune             string             `json:"tune"`
+	Profile          string             `json:"profile"`
+	Level            string             `json:"level"`
+	RateControl      EncoderRateControl `json:"rateControl"`
+	BitrateKbps      int                `json:"bitrateKbps"`
+	CRF              int                `json:"crf"`
+	BFrames          int                `json:"bFrames"`
+	PixelFormat      string             `json:"pixelFormat"`
+	AudioCodec       string             `json:"audioCodec"`
+	AudioBitrateKbps int                `json:"audioBitrateKbps"`
+	AudioSampleRate  int                `json:"audioSampleRate"`
+	ExtraArgs        string             `json:"extraArgs"`
+	CreatedAt        time.Time          `json:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt"`
+}
+
+// ---------- Scenes ----------
+
+// SceneLayoutSingle shows one camera or video material full screen; any other layout is a mosaic
+// layout (2x2, 3x3, focus, canvas, ...) over Sources.
+const SceneLayoutSingle = "single"
+
+// Scene is a named layout and source set of a push channel. Activating it rewrites the channel's input
+// (and mosaic) settings and restarts only the input. MinDwellSec keeps the scene on air for at least
+// that long before another scene may replace it; SlateSec holds the relay slate between the old and the
+// new input, which needs the channel's local relay.
+type Scene struct {
+	ID              int64              `json:"id"`
+	ChannelID       int64              `json:"channelId"`
+	Name            string             `json:"name"`
+	Layout          string             `json:"layout"`
+	CameraID        int64              `json:"cameraId"`
+	VideoMaterialID int64              `json:"videoMaterialId"`
+	Sources         []MultiInputSource `json:"sources"`
+	MinDwellSec     int                `json:"minDwellSec"`
+	SlateSec        int                `json:"slateSec"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
+}
+
+// ActiveScene is the scene a channel switched to last, with who asked for it.
+type ActiveScene struct {
+	ChannelID   int64     `json:"channelId"`
+	SceneID     int64     `json:"sceneId"`
+	Name        string    `json:"name"`
+	Source      string    `json:"source"`
+	ActivatedAt time.Time `json:"activatedAt"`
+	// DwellUntil is when another scene may take over without force.
+	DwellUntil time.Time `json:"dwellUntil"`
+}
+
 // ---------- Admin ----------
 
 type AdminUser struct {
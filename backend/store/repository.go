@@ -7,25 +7,322 @@ import (
 	"errors"
 	"fmt"
 	"html"
+	"math"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func (s *Store) GetPushSetting(ctx context.Context) (*PushSetting, error) {
-	const q = `SELECT
-		id, model, ffmpeg_command, is_auto_retry, retry_interval, is_update,
+const pushSettingColumns = `id, name, room_id, area_id, room_title, account_id, model, ffmpeg_command, is_auto_retry, retry_interval, stall_timeout_sec, retry_policy, failover, relay_enabled, recording, audio_mix, is_update,
 		input_type, output_resolution, output_quality, output_bitrate_kbps, custom_output_params, custom_video_codec,
-		video_material_id, audio_material_id, is_mute, input_screen, input_audio_source,
+		video_material_id, audio_material_id, playlist_id, encoder_profile_id, is_mute, input_screen, input_audio_source,
 		input_audio_device_name, input_device_name, input_device_resolution, input_device_framerate,
 		input_device_plugins, rtsp_url, mjpeg_url, rtmp_url, gb_pull_url, onvif_endpoint, onvif_username, onvif_password,
-		onvif_profile_token, multi_input_enabled, multi_input_layout, multi_input_urls, multi_input_meta, created_at, updated_at
-	FROM push_settings ORDER BY id DESC LIMIT 1`
-	row := s.db.QueryRowContext(ctx, q)
+		onvif_profile_token, multi_input_enabled, multi_input_layout, multi_input_urls, multi_input_meta, extra_outputs,
+		(id = (SELECT MIN(id) FROM push_settings)) AS is_default, created_at, updated_at`
+
+// GetPushSetting returns the default push channel (the oldest push_settings row).
+func (s *Store) GetPushSetting(ctx context.Context) (*PushSetting, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+pushSettingColumns+` FROM push_settings ORDER BY id ASC LIMIT 1`)
+	return scanPushSetting(row)
+}
+
+// GetPushSettingByID returns one push channel; id <= 0 resolves to the default channel.
+func (s *Store) GetPushSettingByID(ctx context.Context, id int64) (*PushSetting, error) {
+	if id <= 0 {
+		return s.GetPushSetting(ctx)
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+pushSettingColumns+` FROM push_settings WHERE id = ?`, id)
+	return scanPushSetting(row)
+}
+
+func (s *Store) ListPushSettings(ctx context.Context) ([]PushSetting, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+pushSettingColumns+` FROM push_settings ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]PushSetting, 0, 4)
+	for rows.Next() {
+		item, scanErr := scanPushSetting(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// FindPushSettingByRoomID returns the channel explicitly bound to a Bilibili room.
+func (s *Store) FindPushSettingByRoomID(ctx context.Context, roomID int64) (*PushSetting, error) {
+	if roomID <= 0 {
+		return nil, sql.ErrNoRows
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+pushSettingColumns+` FROM push_settings WHERE room_id = ? ORDER BY id ASC LIMIT 1`, roomID)
+	return scanPushSetting(row)
+}
+
+// SavePushChannel creates a new push channel (id == 0) or updates the name, room and account binding of an existing one.
+func (s *Store) SavePushChannel(ctx context.Context, req PushChannelSaveRequest) (*PushSetting, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.RoomTitle = strings.TrimSpace(req.RoomTitle)
+	if req.Name == "" {
+		return nil, errors.New("channel name is required")
+	}
+	if req.RoomID < 0 {
+		req.RoomID = 0
+	}
+	if req.AreaID < 0 {
+		req.AreaID = 0
+	}
+	accountID := int64(0)
+	if req.ID > 0 {
+		current, err := s.GetPushSettingByID(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		accountID = current.AccountID
+	}
+	if req.AccountID != nil {
+		accountID = *req.AccountID
+	}
+	if accountID < 0 {
+		accountID = 0
+	}
+	if accountID > 0 {
+		if _, err := s.GetBilibiliAccountByID(ctx, accountID); err != nil {
+			return nil, fmt.Errorf("bilibili account %d not found", accountID)
+		}
+	}
+	if req.RoomID > 0 {
+		var otherID int64
+		err := s.db.QueryRowContext(ctx, `SELECT id FROM push_settings WHERE room_id = ? AND id <> ? LIMIT 1`, req.RoomID, req.ID).Scan(&otherID)
+		if err == nil {
+			return nil, fmt.Errorf("room %d is already bound to push channel %d", req.RoomID, otherID)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if req.ID <= 0 {
+		result, err := s.db.ExecContext(ctx, `INSERT INTO push_settings (name, room_id, area_id, room_title, account_id, is_update, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?)`, req.Name, req.RoomID, req.AreaID, req.RoomTitle, accountID, now, now)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		return s.GetPushSettingByID(ctx, id)
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE push_settings SET name=?, room_id=?, area_id=?, room_title=?, account_id=?, updated_at=? WHERE id=?`,
+		req.Name, req.RoomID, req.AreaID, req.RoomTitle, accountID, now, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return s.GetPushSettingByID(ctx, req.ID)
+}
+
+// NewPushSettingUpdateRequest turns a stored push setting back into an update request, so callers can
+// change a few fields and save it without losing the rest.
+func NewPushSettingUpdateRequest(item *PushSetting) PushSettingUpdateRequest {
+	req := PushSettingUpdateRequest{
+		Model:                 item.Model,
+		FFmpegCommand:         item.FFmpegCommand,
+		IsAutoRetry:           item.IsAutoRetry,
+		RetryInterval:         item.RetryInterval,
+		StallTimeoutSec:       &item.StallTimeoutSec,
+		RetryPolicy:           &item.RetryPolicy,
+		Failover:              &item.Failover,
+		RelayEnabled:          &item.RelayEnabled,
+		Recording:             &item.Recording,
+		AudioMix:              &item.AudioMix,
+		PlaylistID:            &item.PlaylistID,
+		EncoderProfileID:      &item.EncoderProfileID,
+		InputType:             string(item.InputType),
+		OutputResolution:      item.OutputResolution,
+		OutputQuality:         item.OutputQuality,
+		OutputBitrateKbps:     item.OutputBitrateKbps,
+		CustomOutputParams:    item.CustomOutputParams,
+		CustomVideoCodec:      item.CustomVideoCodec,
+		IsMute:                item.IsMute,
+		InputScreen:           item.InputScreen,
+		InputDeviceName:       item.InputDeviceName,
+		InputDeviceResolution: item.InputDeviceResolution,
+		InputDeviceFramerate:  item.InputDeviceFramerate,
+		InputDevicePlugins:    item.InputDevicePlugins,
+		RTSPURL:               item.RTSPURL,
+		MJPEGURL:              item.MJPEGURL,
+		RTMPURL:               item.RTMPURL,
+		GBPullURL:             item.GBPullURL,
+		ONVIFEndpoint:         item.ONVIFEndpoint,
+		ONVIFUsername:         item.ONVIFUsername,
+		ONVIFPassword:         item.ONVIFPassword,
+		ONVIFProfileToken:     item.ONVIFProfileToken,
+		MultiInputEnabled:     item.MultiInputEnabled,
+		MultiInputLayout:      item.MultiInputLayout,
+		MultiInputURLs:        item.MultiInputURLs,
+		MultiInputMeta:        item.MultiInputMeta,
+		ExtraOutputs:          item.ExtraOutputs,
+	}
+	if item.VideoMaterialID != nil {
+		req.VideoID = *item.VideoMaterialID
+	}
+	if item.AudioMaterialID != nil {
+		audioID := *item.AudioMaterialID
+		switch item.InputType {
+		case InputTypeDesktop:
+			if item.InputAudioSource == InputAudioSourceDevice {
+				req.DesktopAudioFrom = true
+				req.DesktopAudioDevice = item.InputAudioDeviceName
+			} else {
+				req.DesktopAudioID = audioID
+			}
+		case InputTypeUSBCamera, InputTypeCameraPlus:
+			if item.InputAudioSource == InputAudioSourceDevice {
+				req.InputDeviceAudioFrom = true
+				req.InputDeviceAudioDevice = item.InputAudioDeviceName
+			} else {
+				req.InputDeviceAudioID = audioID
+			}
+		default:
+			req.AudioID = audioID
+		}
+	} else {
+		switch item.InputType {
+		case InputTypeDesktop:
+			if item.InputAudioSource == InputAudioSourceDevice {
+				req.DesktopAudioFrom = true
+				req.DesktopAudioDevice = item.InputAudioDeviceName
+			}
+		case InputTypeUSBCamera, InputTypeCameraPlus:
+			if item.InputAudioSource == InputAudioSourceDevice {
+				req.InputDeviceAudioFrom = true
+				req.InputDeviceAudioDevice = item.InputAudioDeviceName
+			}
+		}
+	}
+	return req
+}
+
+// ApplyCameraSourceToUpdateRequest points the input of req at a saved camera source.
+func ApplyCameraSourceToUpdateRequest(req *PushSettingUpdateRequest, camera *CameraSource) error {
+	switch camera.SourceType {
+	case CameraSourceTypeRTSP:
+		req.InputType = string(InputTypeRTSP)
+		req.RTSPURL = camera.RTSPURL
+		req.MJPEGURL = ""
+		req.RTMPURL = ""
+		req.GBPullURL = ""
+	case CameraSourceTypeMJPEG:
+		req.InputType = string(InputTypeMJPEG)
+		req.MJPEGURL = camera.MJPEGURL
+		req.RTSPURL = ""
+		req.RTMPURL = ""
+		req.GBPullURL = ""
+	case CameraSourceTypeONVIF:
+		req.InputType = string(InputTypeONVIF)
+		req.RTSPURL = camera.RTSPURL
+		req.MJPEGURL = ""
+		req.RTMPURL = ""
+		req.GBPullURL = ""
+		req.ONVIFEndpoint = camera.ONVIFEndpoint
+		req.ONVIFUsername = camera.ONVIFUsername
+		req.ONVIFPassword = camera.ONVIFPassword
+		req.ONVIFProfileToken = camera.ONVIFProfileToken
+	case CameraSourceTypeUSB:
+		req.InputType = string(InputTypeUSBCamera)
+		req.RTSPURL = ""
+		req.MJPEGURL = ""
+		req.RTMPURL = ""
+		req.GBPullURL = ""
+		req.InputDeviceName = camera.USBDeviceName
+		req.InputDeviceResolution = camera.USBDeviceResolution
+		req.InputDeviceFramerate = camera.USBDeviceFramerate
+	case CameraSourceTypeRTMP:
+		req.InputType = string(InputTypeRTMP)
+		req.RTSPURL = ""
+		req.MJPEGURL = ""
+		req.RTMPURL = camera.RTMPURL
+		req.GBPullURL = ""
+	case CameraSourceTypeGB28181:
+		req.InputType = string(InputTypeGB28181)
+		req.RTSPURL = ""
+		req.MJPEGURL = ""
+		req.RTMPURL = ""
+		req.GBPullURL = camera.GBPullURL
+	default:
+		return errors.New("unsupported camera source type")
+	}
+	return nil
+}
+
+// DeletePushSettings removes push channels with their overlays and scenes and returns the ids that
+// existed; the default channel can not be deleted.
+func (s *Store) DeletePushSettings(ctx context.Context, ids []int64) ([]int64, error) {
+	ids = dedupPositiveIDs(ids)
+	if len(ids) == 0 {
+		return []int64{}, nil
+	}
+	defaultSetting, err := s.GetPushSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placeholders := make([]string, 0, len(ids))
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		if id == defaultSetting.ID {
+			return nil, errors.New("default push channel can not be deleted")
+		}
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	in := "(" + strings.Join(placeholders, ",") + ")"
+	deleted := make([]int64, 0, len(ids))
+	err = s.WithTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM push_settings WHERE id IN "+in, args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			deleted = append(deleted, id)
+		}
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		_ = rows.Close()
+		if _, err := tx.ExecContext(ctx, "DELETE FROM push_settings WHERE id IN "+in, args...); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM overlays WHERE channel_id IN "+in, args...); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM scenes WHERE channel_id IN "+in, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func scanPushSetting(scanner interface{ Scan(dest ...any) error }) (*PushSetting, error) {
 	item := PushSetting{}
 	var videoID sql.NullInt64
 	var audioID sql.NullInt64
@@ -37,12 +334,30 @@ func (s *Store) GetPushSetting(ctx context.Context) (*PushSetting, error) {
 	var multiEnabled int
 	var multiURLsRaw string
 	var multiMetaRaw string
-	if err := row.Scan(
+	var extraOutputsRaw string
+	var retryPolicyRaw string
+	var failoverRaw string
+	var relayEnabled int
+	var recordingRaw string
+	var audioMixRaw string
+	var isDefault int
+	if err := scanner.Scan(
 		&item.ID,
+		&item.Name,
+		&item.RoomID,
+		&item.AreaID,
+		&item.RoomTitle,
+		&item.AccountID,
 		&item.Model,
 		&item.FFmpegCommand,
 		&autoRetry,
 		&item.RetryInterval,
+		&item.StallTimeoutSec,
+		&retryPolicyRaw,
+		&failoverRaw,
+		&relayEnabled,
+		&recordingRaw,
+		&audioMixRaw,
 		&isUpdate,
 		&item.InputType,
 		&item.OutputResolution,
@@ -52,6 +367,8 @@ func (s *Store) GetPushSetting(ctx context.Context) (*PushSetting, error) {
 		&item.CustomVideoCodec,
 		&videoID,
 		&audioID,
+		&item.PlaylistID,
+		&item.EncoderProfileID,
 		&isMute,
 		&item.InputScreen,
 		&item.InputAudioSource,
@@ -72,6 +389,8 @@ func (s *Store) GetPushSetting(ctx context.Context) (*PushSetting, error) {
 		&item.MultiInputLayout,
 		&multiURLsRaw,
 		&multiMetaRaw,
+		&extraOutputsRaw,
+		&isDefault,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -86,29 +405,98 @@ func (s *Store) GetPushSetting(ctx context.Context) (*PushSetting, error) {
 	item.IsAutoRetry = autoRetry == 1
 	item.IsUpdate = isUpdate == 1
 	item.IsMute = isMute == 1
+	item.IsDefault = isDefault == 1
+	item.RelayEnabled = relayEnabled == 1
 	item.MultiInputEnabled = multiEnabled == 1
 	item.MultiInputURLs = parseJSONStringArray(multiURLsRaw)
 	item.MultiInputMeta = parseMultiInputMeta(multiMetaRaw, item.MultiInputURLs)
+	item.ExtraOutputs = parsePushOutputs(extraOutputsRaw)
+	item.RetryPolicy = parsePushRetryPolicy(retryPolicyRaw)
+	item.Failover = parsePushFailover(failoverRaw)
+	item.Recording = parsePushRecording(recordingRaw)
+	item.AudioMix = parsePushAudioMix(audioMixRaw)
 	item.CreatedAt = parseSQLiteTime(createdAt)
 	item.UpdatedAt = parseSQLiteTime(updatedAt)
 	return &item, nil
 }
 
 func (s *Store) UpdatePushSetting(ctx context.Context, req PushSettingUpdateRequest) (*PushSetting, error) {
-	current, err := s.GetPushSetting(ctx)
+	return s.UpdatePushSettingByID(ctx, 0, req)
+}
+
+// ResolvePushSettingUpdate merges req into the stored setting of channel id (id <= 0 is the default
+// channel): nil fields keep their stored value and the rest is normalized and validated, including the
+// music materials, encoder profile, advanced command and mosaic sources it references. It returns the
+// setting an update would save without saving it, so a dry run sees what UpdatePushSettingByID stores.
+func (s *Store) ResolvePushSettingUpdate(ctx context.Context, id int64, req PushSettingUpdateRequest) (*PushSetting, error) {
+	current, err := s.GetPushSettingByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if req.RetryInterval < 30 {
 		req.RetryInterval = 30
 	}
+	stallTimeoutSec := current.StallTimeoutSec
+	if req.StallTimeoutSec != nil {
+		stallTimeoutSec = normalizeStallTimeoutSec(*req.StallTimeoutSec)
+	}
+	retryPolicy := current.RetryPolicy
+	if req.RetryPolicy != nil {
+		retryPolicy = normalizePushRetryPolicy(*req.RetryPolicy)
+	}
+	failover := current.Failover
+	if req.Failover != nil {
+		failover = normalizePushFailover(*req.Failover)
+	}
+	relayEnabled := current.RelayEnabled
+	if req.RelayEnabled != nil {
+		relayEnabled = *req.RelayEnabled
+	}
+	recording := current.Recording
+	if req.Recording != nil {
+		recording = normalizePushRecording(*req.Recording)
+	}
+	audioMix := current.AudioMix
+	if req.AudioMix != nil {
+		audioMix = normalizePushAudioMix(*req.AudioMix)
+	}
+	if audioMix.Enabled {
+		for _, id := range audioMix.MusicMaterialIDs {
+			material, getErr := s.GetMaterialByID(ctx, id)
+			if getErr != nil {
+				return nil, fmt.Errorf("music material %d not found", id)
+			}
+			if material.FileType != FileTypeMusic {
+				return nil, fmt.Errorf("material %q is not an audio file", material.Name)
+			}
+		}
+	}
+	playlistID := current.PlaylistID
+	if req.PlaylistID != nil {
+		playlistID = *req.PlaylistID
+	}
 	inputType := NormalizeInputType(req.InputType, req.LegacyInputType)
+	if inputType == InputTypePlaylist && playlistID <= 0 {
+		return nil, errors.New("playlist input requires a playlist")
+	}
+	encoderProfileID := current.EncoderProfileID
+	if req.EncoderProfileID != nil {
+		encoderProfileID = *req.EncoderProfileID
+	}
+	if encoderProfileID < 0 {
+		encoderProfileID = 0
+	}
+	if encoderProfileID > 0 {
+		if _, err := s.GetEncoderProfileByID(ctx, encoderProfileID); err != nil {
+			return nil, fmt.Errorf("encoder profile %d not found", encoderProfileID)
+		}
+	}
 	if req.Model == ConfigModelAdvance {
 		if strings.TrimSpace(req.FFmpegCommand) == "" {
 			return nil, errors.New("ffmpeg command can not be empty")
 		}
-		if !strings.Contains(req.FFmpegCommand, "{URL}") {
-			return nil, errors.New("ffmpeg command must include {URL}")
+		if !strings.Contains(req.FFmpegCommand, "{URL}") && !advanceURLTemplatePattern.MatchString(req.FFmpegCommand) {
+			return nil, errors.New("ffmpeg command must include {URL} or {{.URL}}")
 		}
 		if isLikelyUSBTemplateCommand(req.FFmpegCommand) && (inputType == InputTypeRTSP || inputType == InputTypeMJPEG || inputType == InputTypeONVIF || inputType == InputTypeRTMP || inputType == InputTypeGB28181) {
 			return nil, errors.New("advanced ffmpeg command looks like USB camera template; switch to normal mode or replace command for current input type")
@@ -123,19 +511,22 @@ func (s *Store) UpdatePushSetting(ctx context.Context, req PushSettingUpdateRequ
 	}
 	multiURLs := normalizeURLList(req.MultiInputURLs, 9)
 	multiMeta := normalizeMultiInputMeta(req.MultiInputMeta, multiURLs, 9)
-	if len(multiURLs) == 0 && len(multiMeta) > 0 {
-		multiURLs = make([]string, 0, len(multiMeta))
-		for _, item := range multiMeta {
-			multiURLs = append(multiURLs, item.URL)
+	if req.MultiInputEnabled {
+		if multiMeta, err = s.ResolveMultiInputSources(ctx, multiMeta); err != nil {
+			return nil, err
 		}
 	}
-	multiURLsJSON := "[]"
-	if body, marshalErr := json.Marshal(multiURLs); marshalErr == nil {
-		multiURLsJSON = string(body)
+	// The legacy URL list follows the sources, so a stale camera URL can not come back as an extra source.
+	multiURLs = make([]string, 0, len(multiMeta))
+	for _, item := range multiMeta {
+		if item.URL != "" {
+			multiURLs = append(multiURLs, item.URL)
+		}
 	}
-	multiMetaJSON := "[]"
-	if body, marshalErr := json.Marshal(multiMeta); marshalErr == nil {
-		multiMetaJSON = string(body)
+	// Older clients do not send extraOutputs at all; keep the stored list instead of wiping it.
+	extraOutputs := current.ExtraOutputs
+	if req.ExtraOutputs != nil {
+		extraOutputs = normalizePushOutputs(req.ExtraOutputs, 8)
 	}
 
 	var videoID sql.NullInt64
@@ -164,12 +555,113 @@ func (s *Store) UpdatePushSetting(ctx context.Context, req PushSettingUpdateRequ
 		audioID = sql.NullInt64{Int64: req.AudioID, Valid: true}
 	}
 
+	next := *current
+	next.Model = req.Model
+	next.FFmpegCommand = req.FFmpegCommand
+	next.IsAutoRetry = req.IsAutoRetry
+	next.RetryInterval = req.RetryInterval
+	next.StallTimeoutSec = stallTimeoutSec
+	next.RetryPolicy = retryPolicy
+	next.Failover = failover
+	next.RelayEnabled = relayEnabled
+	next.Recording = recording
+	next.AudioMix = audioMix
+	next.IsUpdate = true
+	next.InputType = inputType
+	next.OutputResolution = req.OutputResolution
+	next.OutputQuality = req.OutputQuality
+	next.OutputBitrateKbps = req.OutputBitrateKbps
+	next.CustomOutputParams = req.CustomOutputParams
+	next.CustomVideoCodec = req.CustomVideoCodec
+	next.VideoMaterialID = nil
+	if videoID.Valid {
+		next.VideoMaterialID = &videoID.Int64
+	}
+	next.AudioMaterialID = nil
+	if audioID.Valid {
+		next.AudioMaterialID = &audioID.Int64
+	}
+	next.PlaylistID = playlistID
+	next.EncoderProfileID = encoderProfileID
+	next.IsMute = req.IsMute
+	next.InputScreen = req.InputScreen
+	next.InputAudioSource = inputAudioSource
+	next.InputAudioDeviceName = inputAudioDeviceName
+	next.InputDeviceName = req.InputDeviceName
+	next.InputDeviceResolution = req.InputDeviceResolution
+	next.InputDeviceFramerate = req.InputDeviceFramerate
+	next.InputDevicePlugins = req.InputDevicePlugins
+	next.RTSPURL = normalizeInputURL(req.RTSPURL)
+	next.MJPEGURL = normalizeInputURL(req.MJPEGURL)
+	next.RTMPURL = normalizeInputURL(req.RTMPURL)
+	next.GBPullURL = normalizeInputURL(req.GBPullURL)
+	next.ONVIFEndpoint = normalizeInputURL(req.ONVIFEndpoint)
+	next.ONVIFUsername = strings.TrimSpace(req.ONVIFUsername)
+	next.ONVIFPassword = strings.TrimSpace(req.ONVIFPassword)
+	next.ONVIFProfileToken = strings.TrimSpace(req.ONVIFProfileToken)
+	next.MultiInputEnabled = req.MultiInputEnabled
+	next.MultiInputLayout = strings.TrimSpace(req.MultiInputLayout)
+	next.MultiInputURLs = multiURLs
+	next.MultiInputMeta = multiMeta
+	next.ExtraOutputs = extraOutputs
+	return &next, nil
+}
+
+// UpdatePushSettingByID updates the stream configuration of one push channel; id <= 0 targets the default channel.
+func (s *Store) UpdatePushSettingByID(ctx context.Context, id int64, req PushSettingUpdateRequest) (*PushSetting, error) {
+	next, err := s.ResolvePushSettingUpdate(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	retryPolicyJSON := "{}"
+	if body, marshalErr := json.Marshal(next.RetryPolicy); marshalErr == nil {
+		retryPolicyJSON = string(body)
+	}
+	failoverJSON := "{}"
+	if body, marshalErr := json.Marshal(next.Failover); marshalErr == nil {
+		failoverJSON = string(body)
+	}
+	recordingJSON := "{}"
+	if body, marshalErr := json.Marshal(next.Recording); marshalErr == nil {
+		recordingJSON = string(body)
+	}
+	audioMixJSON := "{}"
+	if body, marshalErr := json.Marshal(next.AudioMix); marshalErr == nil {
+		audioMixJSON = string(body)
+	}
+	multiURLsJSON := "[]"
+	if body, marshalErr := json.Marshal(next.MultiInputURLs); marshalErr == nil {
+		multiURLsJSON = string(body)
+	}
+	multiMetaJSON := "[]"
+	if body, marshalErr := json.Marshal(next.MultiInputMeta); marshalErr == nil {
+		multiMetaJSON = string(body)
+	}
+	extraOutputsJSON := "[]"
+	if body, marshalErr := json.Marshal(next.ExtraOutputs); marshalErr == nil {
+		extraOutputsJSON = string(body)
+	}
+	var videoID sql.NullInt64
+	if next.VideoMaterialID != nil {
+		videoID = sql.NullInt64{Int64: *next.VideoMaterialID, Valid: true}
+	}
+	var audioID sql.NullInt64
+	if next.AudioMaterialID != nil {
+		audioID = sql.NullInt64{Int64: *next.AudioMaterialID, Valid: true}
+	}
+
 	now := time.Now().UTC()
 	_, err = s.db.ExecContext(ctx, `UPDATE push_settings SET
 		model = ?,
 		ffmpeg_command = ?,
 		is_auto_retry = ?,
 		retry_interval = ?,
+		stall_timeout_sec = ?,
+		retry_policy = ?,
+		failover = ?,
+		relay_enabled = ?,
+		recording = ?,
+		audio_mix = ?,
 		is_update = 1,
 		input_type = ?,
 		output_resolution = ?,
@@ -179,6 +671,8 @@ func (s *Store) UpdatePushSetting(ctx context.Context, req PushSettingUpdateRequ
 		custom_video_codec = ?,
 		video_material_id = ?,
 		audio_material_id = ?,
+		playlist_id = ?,
+		encoder_profile_id = ?,
 		is_mute = ?,
 		input_screen = ?,
 		input_audio_source = ?,
@@ -199,47 +693,57 @@ func (s *Store) UpdatePushSetting(ctx context.Context, req PushSettingUpdateRequ
 		multi_input_layout = ?,
 		multi_input_urls = ?,
 		multi_input_meta = ?,
+		extra_outputs = ?,
 		updated_at = ?
 	WHERE id = ?`,
-		req.Model,
-		req.FFmpegCommand,
-		boolToInt(req.IsAutoRetry),
-		req.RetryInterval,
-		inputType,
-		req.OutputResolution,
-		req.OutputQuality,
-		req.OutputBitrateKbps,
-		req.CustomOutputParams,
-		req.CustomVideoCodec,
+		next.Model,
+		next.FFmpegCommand,
+		boolToInt(next.IsAutoRetry),
+		next.RetryInterval,
+		next.StallTimeoutSec,
+		retryPolicyJSON,
+		failoverJSON,
+		boolToInt(next.RelayEnabled),
+		recordingJSON,
+		audioMixJSON,
+		next.InputType,
+		next.OutputResolution,
+		next.OutputQuality,
+		next.OutputBitrateKbps,
+		next.CustomOutputParams,
+		next.CustomVideoCodec,
 		videoID,
 		audioID,
-		boolToInt(req.IsMute),
-		req.InputScreen,
-		inputAudioSource,
-		inputAudioDeviceName,
-		req.InputDeviceName,
-		req.InputDeviceResolution,
-		req.InputDeviceFramerate,
-		req.InputDevicePlugins,
-		normalizeInputURL(req.RTSPURL),
-		normalizeInputURL(req.MJPEGURL),
-		normalizeInputURL(req.RTMPURL),
-		normalizeInputURL(req.GBPullURL),
-		normalizeInputURL(req.ONVIFEndpoint),
-		strings.TrimSpace(req.ONVIFUsername),
-		strings.TrimSpace(req.ONVIFPassword),
-		strings.TrimSpace(req.ONVIFProfileToken),
-		boolToInt(req.MultiInputEnabled),
-		strings.TrimSpace(req.MultiInputLayout),
+		next.PlaylistID,
+		next.EncoderProfileID,
+		boolToInt(next.IsMute),
+		next.InputScreen,
+		next.InputAudioSource,
+		next.InputAudioDeviceName,
+		next.InputDeviceName,
+		next.InputDeviceResolution,
+		next.InputDeviceFramerate,
+		next.InputDevicePlugins,
+		next.RTSPURL,
+		next.MJPEGURL,
+		next.RTMPURL,
+		next.GBPullURL,
+		next.ONVIFEndpoint,
+		next.ONVIFUsername,
+		next.ONVIFPassword,
+		next.ONVIFProfileToken,
+		boolToInt(next.MultiInputEnabled),
+		next.MultiInputLayout,
 		multiURLsJSON,
 		multiMetaJSON,
+		extraOutputsJSON,
 		now.Format(time.RFC3339Nano),
-		current.ID,
+		next.ID,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return s.GetPushSetting(ctx)
+	return s.GetPushSettingByID(ctx, next.ID)
 }
 
 func (s *Store) GetLiveSetting(ctx context.Context) (*LiveSetting, error) {
@@ -283,7 +787,7 @@ func (s *Store) UpdateLiveAnnouncement(ctx context.Context, req RoomNewUpdateReq
 }
 
 func (s *Store) GetMonitorSetting(ctx context.Context) (*MonitorSetting, error) {
-	const q = `SELECT id, is_enabled, room_id, room_url, is_enable_email_notice, smtp_server, smtp_ssl, smtp_port, mail_address, mail_name, password, receivers, created_at, updated_at
+	const q = `SELECT id, is_enabled, room_id, room_url, is_enable_email_notice, smtp_server, smtp_ssl, smtp_port, mail_address, mail_name, password, receivers, poll_interval_sec, debounce_count, cooldown_sec, created_at, updated_at
 	FROM monitor_settings ORDER BY id DESC LIMIT 1`
 	row := s.db.QueryRowContext(ctx, q)
 	item := MonitorSetting{}
@@ -305,6 +809,9 @@ func (s *Store) GetMonitorSetting(ctx context.Context) (*MonitorSetting, error)
 		&item.MailName,
 		&item.Password,
 		&item.Receivers,
+		&item.PollIntervalSec,
+		&item.DebounceCount,
+		&item.CooldownSec,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -323,8 +830,27 @@ func (s *Store) UpdateMonitorRoomInfo(ctx context.Context, req MonitorRoomInfoUp
 	if err != nil {
 		return nil, err
 	}
+	cooldownSec := 600
+	if req.CooldownSec != nil {
+		cooldownSec = *req.CooldownSec
+	}
+	if cooldownSec < 0 {
+		cooldownSec = 0
+	}
+	if cooldownSec > 86400 {
+		cooldownSec = 86400
+	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
-	_, err = s.db.ExecContext(ctx, `UPDATE monitor_settings SET is_enabled=?, room_id=?, room_url=?, updated_at=? WHERE id=?`, boolToInt(req.IsEnabled), req.RoomID, req.RoomURL, now, setting.ID)
+	_, err = s.db.ExecContext(ctx, `UPDATE monitor_settings SET is_enabled=?, room_id=?, room_url=?, poll_interval_sec=?, debounce_count=?, cooldown_sec=?, updated_at=? WHERE id=?`,
+		boolToInt(req.IsEnabled),
+		req.RoomID,
+		req.RoomURL,
+		clampInt(req.PollIntervalSec, 10, 3600, 60),
+		clampInt(req.DebounceCount, 1, 20, 2),
+		cooldownSec,
+		now,
+		setting.ID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -560,22 +1086,55 @@ func (s *Store) DeleteMaterials(ctx context.Context, ids []int64) ([]Material, e
 	return items, nil
 }
 
+// ruleEventTypes are the event types rules can match; stats updates are too frequent to run actions on.
+var ruleEventTypes = map[string]bool{
+	LiveMessageDanmaku:    true,
+	LiveMessageGift:       true,
+	LiveMessageSuperChat:  true,
+	LiveMessageGuard:      true,
+	LiveMessageEnter:      true,
+	LiveMessageFollow:     true,
+	LiveMessageShare:      true,
+	LiveMessageLike:       true,
+	LiveMessageRoomChange: true,
+}
+
 func (s *Store) SaveDanmakuRule(ctx context.Context, item DanmakuPTZRule) error {
 	if strings.TrimSpace(item.Keyword) == "" {
 		return errors.New("keyword is required")
 	}
-	_, err := s.db.ExecContext(ctx, `INSERT INTO danmaku_ptz_rules (keyword, action, ptz_direction, ptz_speed, enabled, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?)
-	ON CONFLICT(keyword) DO UPDATE SET
+	item.EventType = strings.ToLower(strings.TrimSpace(item.EventType))
+	if item.EventType == "" {
+		item.EventType = LiveMessageDanmaku
+	}
+	if !ruleEventTypes[item.EventType] {
+		return fmt.Errorf("unsupported rule event type: %s", item.EventType)
+	}
+	if item.MinGold < 0 {
+		item.MinGold = 0
+	}
+	if strings.EqualFold(strings.TrimSpace(item.Action), "scene") {
+		if _, err := s.GetSceneByID(ctx, item.SceneID); err != nil {
+			return errors.New("scene rule needs an existing sceneId")
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO danmaku_ptz_rules (event_type, keyword, min_gold, action, ptz_direction, ptz_speed, scene_id, enabled, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(event_type, keyword) DO UPDATE SET
+		min_gold=excluded.min_gold,
 		action=excluded.action,
 		ptz_direction=excluded.ptz_direction,
 		ptz_speed=excluded.ptz_speed,
+		scene_id=excluded.scene_id,
 		enabled=excluded.enabled,
 		updated_at=excluded.updated_at`,
+		item.EventType,
 		strings.TrimSpace(item.Keyword),
+		item.MinGold,
 		strings.TrimSpace(item.Action),
 		strings.TrimSpace(item.PTZDirection),
 		item.PTZSpeed,
+		item.SceneID,
 		boolToInt(item.Enabled),
 		time.Now().UTC().Format(time.RFC3339Nano),
 	)
@@ -587,7 +1146,7 @@ func (s *Store) ListDanmakuRules(ctx context.Context, limit int, offset int) ([]
 	if offset < 0 {
 		offset = 0
 	}
-	rows, err := s.db.QueryContext(ctx, `SELECT id, keyword, action, ptz_direction, ptz_speed, enabled, updated_at
+	rows, err := s.db.QueryContext(ctx, `SELECT id, event_type, keyword, min_gold, action, ptz_direction, ptz_speed, scene_id, enabled, updated_at
 	FROM danmaku_ptz_rules ORDER BY id DESC LIMIT ? OFFSET ?`, limit, offset)
 	if err != nil {
 		return nil, err
@@ -598,7 +1157,7 @@ func (s *Store) ListDanmakuRules(ctx context.Context, limit int, offset int) ([]
 		var item DanmakuPTZRule
 		var enabled int
 		var updatedAt string
-		if err := rows.Scan(&item.ID, &item.Keyword, &item.Action, &item.PTZDirection, &item.PTZSpeed, &enabled, &updatedAt); err != nil {
+		if err := rows.Scan(&item.ID, &item.EventType, &item.Keyword, &item.MinGold, &item.Action, &item.PTZDirection, &item.PTZSpeed, &item.SceneID, &enabled, &updatedAt); err != nil {
 			return nil, err
 		}
 		item.Enabled = enabled == 1
@@ -615,33 +1174,56 @@ func (s *Store) SaveWebhook(ctx context.Context, item WebhookSetting) error {
 	if _, err := url.ParseRequestURI(item.URL); err != nil {
 		return fmt.Errorf("invalid webhook url: %w", err)
 	}
+	eventsJSON, err := json.Marshal(normalizeWebhookEvents(item.Events))
+	if err != nil {
+		return err
+	}
 	if item.ID > 0 {
-		_, err := s.db.ExecContext(ctx, `UPDATE webhook_settings SET name=?, url=?, secret=?, enabled=?, updated_at=? WHERE id=?`,
+		_, err := s.db.ExecContext(ctx, `UPDATE webhook_settings SET name=?, url=?, secret=?, enabled=?, events=?, updated_at=? WHERE id=?`,
 			item.Name,
 			item.URL,
 			item.Secret,
 			boolToInt(item.Enabled),
+			string(eventsJSON),
 			time.Now().UTC().Format(time.RFC3339Nano),
 			item.ID,
 		)
 		return err
 	}
-	_, err := s.db.ExecContext(ctx, `INSERT INTO webhook_settings (name, url, secret, enabled, updated_at) VALUES (?, ?, ?, ?, ?)`,
+	_, err = s.db.ExecContext(ctx, `INSERT INTO webhook_settings (name, url, secret, enabled, events, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
 		item.Name,
 		item.URL,
 		item.Secret,
 		boolToInt(item.Enabled),
+		string(eventsJSON),
 		time.Now().UTC().Format(time.RFC3339Nano),
 	)
 	return err
 }
 
+func normalizeWebhookEvents(items []string) []string {
+	result := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		result = append(result, item)
+	}
+	return result
+}
+
 func (s *Store) ListWebhooks(ctx context.Context, limit int, offset int) ([]WebhookSetting, error) {
 	limit = clampLimit(limit, 100, 2000)
 	if offset < 0 {
 		offset = 0
 	}
-	rows, err := s.db.QueryContext(ctx, `SELECT id, name, url, secret, enabled, updated_at
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, url, secret, enabled, events, updated_at
 	FROM webhook_settings ORDER BY id DESC LIMIT ? OFFSET ?`, limit, offset)
 	if err != nil {
 		return nil, err
@@ -651,11 +1233,14 @@ func (s *Store) ListWebhooks(ctx context.Context, limit int, offset int) ([]Webh
 	for rows.Next() {
 		var item WebhookSetting
 		var enabled int
+		var eventsJSON string
 		var updatedAt string
-		if err := rows.Scan(&item.ID, &item.Name, &item.URL, &item.Secret, &enabled, &updatedAt); err != nil {
+		if err := rows.Scan(&item.ID, &item.Name, &item.URL, &item.Secret, &enabled, &eventsJSON, &updatedAt); err != nil {
 			return nil, err
 		}
 		item.Enabled = enabled == 1
+		item.Events = []string{}
+		_ = json.Unmarshal([]byte(eventsJSON), &item.Events)
 		item.UpdatedAt = parseSQLiteTime(updatedAt)
 		result = append(result, item)
 	}
@@ -936,7 +1521,8 @@ func (s *Store) GetBilibiliAPIErrorLog(ctx context.Context, id int64) (*Bilibili
 }
 
 func (s *Store) GetMaintenanceSetting(ctx context.Context) (*MaintenanceSetting, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT id, enabled, retention_days, auto_vacuum, last_cleanup_at, last_vacuum_at, updated_at
+	row := s.db.QueryRowContext(ctx, `SELECT id, enabled, retention_days, auto_vacuum, recording_retention_days, recording_max_size_mb,
+		last_cleanup_at, last_vacuum_at, updated_at
 	FROM maintenance_settings ORDER BY id DESC LIMIT 1`)
 	item := MaintenanceSetting{}
 	var enabled int
@@ -949,6 +1535,8 @@ func (s *Store) GetMaintenanceSetting(ctx context.Context) (*MaintenanceSetting,
 		&enabled,
 		&item.RetentionDays,
 		&autoVacuum,
+		&item.RecordingRetentionDays,
+		&item.RecordingMaxSizeMB,
 		&lastCleanupAt,
 		&lastVacuumAt,
 		&updatedAt,
@@ -980,15 +1568,25 @@ func (s *Store) SaveMaintenanceSetting(ctx context.Context, req MaintenanceSetti
 	if req.RetentionDays > 3650 {
 		req.RetentionDays = 3650
 	}
+	if req.RecordingRetentionDays < 0 {
+		req.RecordingRetentionDays = 0
+	}
+	if req.RecordingMaxSizeMB < 0 {
+		req.RecordingMaxSizeMB = 0
+	}
 	_, err = s.db.ExecContext(ctx, `UPDATE maintenance_settings SET
 		enabled=?,
 		retention_days=?,
 		auto_vacuum=?,
+		recording_retention_days=?,
+		recording_max_size_mb=?,
 		updated_at=?
 	WHERE id=?`,
 		boolToInt(req.Enabled),
 		req.RetentionDays,
 		boolToInt(req.AutoVacuum),
+		req.RecordingRetentionDays,
+		req.RecordingMaxSizeMB,
 		time.Now().UTC().Format(time.RFC3339Nano),
 		current.ID,
 	)
@@ -1054,7 +1652,14 @@ func (s *Store) CleanupOldDataBefore(ctx context.Context, cutoff time.Time, batc
 	if stats.StreamSessions, err = s.batchDeleteBefore(ctx, "stream_sessions", "ended_at", cutoff, batchSize); err != nil {
 		return CleanupStats{}, err
 	}
-	stats.Total = stats.LiveEvents + stats.DanmakuRecords + stats.WebhookDeliveryLogs + stats.BilibiliErrorLogs + stats.IntegrationTasks + stats.StreamSessions
+	if stats.PushMetricSummaries, err = s.batchDeleteBefore(ctx, "push_metric_summaries", "ended_at", cutoff, batchSize); err != nil {
+		return CleanupStats{}, err
+	}
+	// Gifts, super chats and guard purchases are revenue history and stay; enters and likes do not.
+	if stats.LiveInteractions, err = s.batchDeleteBefore(ctx, "live_interactions", "created_at", cutoff, batchSize); err != nil {
+		return CleanupStats{}, err
+	}
+	stats.Total = stats.LiveEvents + stats.DanmakuRecords + stats.WebhookDeliveryLogs + stats.BilibiliErrorLogs + stats.IntegrationTasks + stats.StreamSessions + stats.PushMetricSummaries + stats.LiveInteractions
 	return stats, nil
 }
 
@@ -1566,11 +2171,16 @@ func (s *Store) SaveCameraSource(ctx context.Context, req CameraSourceSaveReques
 	return s.GetCameraSourceByID(ctx, id)
 }
 
+// DeleteCameraSources removes camera sources. It refuses while a channel, scene or schedule still uses
+// one of them, see CameraSourceUsage.
 func (s *Store) DeleteCameraSources(ctx context.Context, ids []int64) (int64, error) {
 	keys := dedupPositiveIDs(ids)
 	if len(keys) == 0 {
 		return 0, nil
 	}
+	if err := s.ensureCameraSourcesUnused(ctx, keys); err != nil {
+		return 0, err
+	}
 	placeholders := make([]string, 0, len(keys))
 	args := make([]any, 0, len(keys))
 	for _, id := range keys {
@@ -1741,6 +2351,9 @@ func normalizeInputURL(raw string) string {
 	return strings.TrimSpace(html.UnescapeString(strings.TrimSpace(raw)))
 }
 
+// advanceURLTemplatePattern matches the template form of the push URL placeholder, {{.URL}}.
+var advanceURLTemplatePattern = regexp.MustCompile(`\{\{-?\s*\.URL\s*-?\}\}`)
+
 func isLikelyUSBTemplateCommand(command string) bool {
 	lower := strings.ToLower(strings.TrimSpace(command))
 	if lower == "" {
@@ -1789,6 +2402,48 @@ func parseMultiInputMeta(raw string, fallbackURLs []string) []MultiInputSource {
 	return normalizeMultiInputMeta(items, fallbackURLs, 9)
 }
 
+func parsePushOutputs(raw string) []PushOutput {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return []PushOutput{}
+	}
+	items := make([]PushOutput, 0)
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return []PushOutput{}
+	}
+	return normalizePushOutputs(items, 8)
+}
+
+func normalizePushOutputs(items []PushOutput, max int) []PushOutput {
+	if max <= 0 {
+		max = 8
+	}
+	seen := map[string]struct{}{}
+	result := make([]PushOutput, 0, len(items))
+	for _, item := range items {
+		urlValue := normalizeInputURL(item.URL)
+		if urlValue == "" {
+			continue
+		}
+		if _, ok := seen[urlValue]; ok {
+			continue
+		}
+		seen[urlValue] = struct{}{}
+		item.URL = urlValue
+		item.Name = strings.TrimSpace(item.Name)
+		if item.Name == "" {
+			item.Name = fmt.Sprintf("output-%d", len(result)+1)
+		}
+		item.Format = strings.ToLower(strings.TrimSpace(item.Format))
+		item.FailurePolicy = NormalizePushOutputFailurePolicy(string(item.FailurePolicy))
+		result = append(result, item)
+		if len(result) >= max {
+			break
+		}
+	}
+	return result
+}
+
 func normalizeURLList(items []string, max int) []string {
 	if max <= 0 {
 		max = 9
@@ -1822,19 +2477,30 @@ func normalizeMultiInputMeta(items []MultiInputSource, fallbackURLs []string, ma
 	audioFound := false
 	appendItem := func(item MultiInputSource) {
 		urlValue := strings.TrimSpace(item.URL)
-		if urlValue == "" {
+		if item.CameraID < 0 {
+			item.CameraID = 0
+		}
+		if item.MaterialID < 0 {
+			item.MaterialID = 0
+		}
+		key := item.SourceKey()
+		if key == "" {
 			return
 		}
-		if _, ok := seen[urlValue]; ok {
+		if _, ok := seen[key]; ok {
 			return
 		}
-		seen[urlValue] = struct{}{}
+		// The legacy URL list repeats the last resolved URL of referenced sources.
+		if _, ok := seen[urlValue]; ok && urlValue != "" {
+			return
+		}
+		seen[key] = struct{}{}
+		if urlValue != "" {
+			seen[urlValue] = struct{}{}
+		}
 		item.URL = urlValue
 		item.Title = strings.TrimSpace(item.Title)
 		item.SourceType = strings.TrimSpace(item.SourceType)
-		if item.MaterialID < 0 {
-			item.MaterialID = 0
-		}
 		if item.X < 0 {
 			item.X = 0
 		}
@@ -1896,6 +2562,176 @@ func normalizeMultiInputMeta(items []MultiInputSource, fallbackURLs []string, ma
 	return result
 }
 
+func parsePushRetryPolicy(raw string) PushRetryPolicy {
+	policy := PushRetryPolicy{}
+	if strings.TrimSpace(raw) == "" {
+		return policy
+	}
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return PushRetryPolicy{}
+	}
+	return normalizePushRetryPolicy(policy)
+}
+
+// normalizePushRetryPolicy clamps delays to 5s..1h; InitialDelaySec 0 is kept and means "use RetryInterval".
+func normalizePushRetryPolicy(policy PushRetryPolicy) PushRetryPolicy {
+	if policy.InitialDelaySec < 0 {
+		policy.InitialDelaySec = 0
+	}
+	if policy.InitialDelaySec > 0 && policy.InitialDelaySec < 5 {
+		policy.InitialDelaySec = 5
+	}
+	if policy.InitialDelaySec > 3600 {
+		policy.InitialDelaySec = 3600
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 1
+	}
+	if policy.Multiplier > 10 {
+		policy.Multiplier = 10
+	}
+	if policy.MaxDelaySec > 3600 {
+		policy.MaxDelaySec = 3600
+	}
+	if policy.MaxDelaySec < policy.InitialDelaySec {
+		policy.MaxDelaySec = policy.InitialDelaySec
+	}
+	if policy.MaxAttempts < 0 {
+		policy.MaxAttempts = 0
+	}
+	if policy.ResetWindowSec < 0 {
+		policy.ResetWindowSec = 0
+	}
+	return policy
+}
+
+func parsePushFailover(raw string) PushFailover {
+	failover := PushFailover{}
+	if strings.TrimSpace(raw) == "" {
+		return normalizePushFailover(failover)
+	}
+	if err := json.Unmarshal([]byte(raw), &failover); err != nil {
+		return normalizePushFailover(PushFailover{})
+	}
+	return normalizePushFailover(failover)
+}
+
+// normalizePushFailover defaults to 3 failures before switching and a 30s primary probe (10s..10min).
+func normalizePushFailover(failover PushFailover) PushFailover {
+	if failover.FailureThreshold <= 0 {
+		failover.FailureThreshold = 3
+	}
+	if failover.FailureThreshold > 20 {
+		failover.FailureThreshold = 20
+	}
+	if failover.ProbeIntervalSec <= 0 {
+		failover.ProbeIntervalSec = 30
+	}
+	if failover.ProbeIntervalSec < 10 {
+		failover.ProbeIntervalSec = 10
+	}
+	if failover.ProbeIntervalSec > 600 {
+		failover.ProbeIntervalSec = 600
+	}
+	if failover.BackupCameraID < 0 {
+		failover.BackupCameraID = 0
+	}
+	if failover.SlateMaterialID < 0 {
+		failover.SlateMaterialID = 0
+	}
+	return failover
+}
+
+func parsePushRecording(raw string) PushRecording {
+	recording := PushRecording{}
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &recording)
+	}
+	return normalizePushRecording(recording)
+}
+
+// normalizePushRecording defaults to 10 minute mp4 segments (1min..2h).
+func normalizePushRecording(recording PushRecording) PushRecording {
+	recording.Format = strings.ToLower(strings.TrimSpace(recording.Format))
+	if recording.Format != RecordingFormatFLV {
+		recording.Format = RecordingFormatMP4
+	}
+	if recording.SegmentSec <= 0 {
+		recording.SegmentSec = 600
+	}
+	if recording.SegmentSec < 60 {
+		recording.SegmentSec = 60
+	}
+	if recording.SegmentSec > 7200 {
+		recording.SegmentSec = 7200
+	}
+	return recording
+}
+
+func parsePushAudioMix(raw string) PushAudioMix {
+	mix := PushAudioMix{}
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &mix)
+	}
+	return normalizePushAudioMix(mix)
+}
+
+// normalizePushAudioMix clamps gains to -60..+20 dB and defaults ducking to a -30 dB threshold with an
+// 8:1 ratio. TargetLUFS 0 leaves loudness alone, anything else is kept within -70..-5.
+func normalizePushAudioMix(mix PushAudioMix) PushAudioMix {
+	for _, source := range []*PushAudioMixSource{&mix.Input, &mix.Music, &mix.Device} {
+		source.GainDB = clampFloat(source.GainDB, -60, 20)
+	}
+	// The music list is a playlist: keep its order, drop repeats.
+	musicIDs := make([]int64, 0, len(mix.MusicMaterialIDs))
+	seen := make(map[int64]struct{}, len(mix.MusicMaterialIDs))
+	for _, id := range mix.MusicMaterialIDs {
+		if _, ok := seen[id]; ok || id <= 0 || len(musicIDs) >= 50 {
+			continue
+		}
+		seen[id] = struct{}{}
+		musicIDs = append(musicIDs, id)
+	}
+	mix.MusicMaterialIDs = musicIDs
+	mix.DeviceName = strings.TrimSpace(mix.DeviceName)
+	if mix.DuckThresholdDB == 0 {
+		mix.DuckThresholdDB = -30
+	}
+	mix.DuckThresholdDB = clampFloat(mix.DuckThresholdDB, -60, 0)
+	if mix.DuckRatio == 0 {
+		mix.DuckRatio = 8
+	}
+	mix.DuckRatio = clampFloat(mix.DuckRatio, 1, 20)
+	if mix.TargetLUFS != 0 {
+		mix.TargetLUFS = clampFloat(mix.TargetLUFS, -70, -5)
+	}
+	return mix
+}
+
+func clampFloat(value float64, min float64, max float64) float64 {
+	if math.IsNaN(value) || value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// normalizeStallTimeoutSec keeps 0 (watchdog disabled) and clamps everything else to 5..600 seconds.
+func normalizeStallTimeoutSec(value int) int {
+	if value <= 0 {
+		return 0
+	}
+	if value < 5 {
+		return 5
+	}
+	if value > 600 {
+		return 600
+	}
+	return value
+}
+
 func clampLimit(limit int, fallback int, max int) int {
 	if fallback <= 0 {
 		fallback = 100
@@ -1962,6 +2798,8 @@ func fileTypeName(fileType FileType) string {
 		return "Video"
 	case FileTypeMusic:
 		return "Music"
+	case FileTypeImage:
+		return "Image"
 	default:
 		return "Unknown"
 	}
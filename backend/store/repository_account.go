@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+const bilibiliAccountColumns = `id, name, uid, uname, face, cookie, refresh_token, is_bot, checked_at, created_at, updated_at`
+
+func (s *Store) ListBilibiliAccounts(ctx context.Context) ([]BilibiliAccount, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+bilibiliAccountColumns+` FROM bilibili_accounts ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]BilibiliAccount, 0)
+	for rows.Next() {
+		item, scanErr := scanBilibiliAccount(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetBilibiliAccountByID(ctx context.Context, id int64) (*BilibiliAccount, error) {
+	if id <= 0 {
+		return nil, errors.New("account id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+bilibiliAccountColumns+` FROM bilibili_accounts WHERE id = ?`, id)
+	return scanBilibiliAccount(row)
+}
+
+// GetBotBilibiliAccount returns the account automated danmaku is sent as, sql.ErrNoRows when none is marked.
+func (s *Store) GetBotBilibiliAccount(ctx context.Context) (*BilibiliAccount, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+bilibiliAccountColumns+` FROM bilibili_accounts WHERE is_bot = 1 ORDER BY id ASC LIMIT 1`)
+	return scanBilibiliAccount(row)
+}
+
+// SaveBilibiliAccount creates (ID 0) or renames an account. Marking one as the bot unmarks the others.
+func (s *Store) SaveBilibiliAccount(ctx context.Context, req BilibiliAccountSaveRequest) (*BilibiliAccount, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("account name is required")
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id := req.ID
+	if id > 0 {
+		result, err := tx.ExecContext(ctx, `UPDATE bilibili_accounts SET name = ?, is_bot = ?, updated_at = ? WHERE id = ?`,
+			req.Name, boolToInt(req.IsBot), now, id)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil, errors.New("account not found")
+		}
+	} else {
+		result, err := tx.ExecContext(ctx, `INSERT INTO bilibili_accounts (name, is_bot, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+			req.Name, boolToInt(req.IsBot), now, now)
+		if err != nil {
+			return nil, err
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsBot {
+		if _, err := tx.ExecContext(ctx, `UPDATE bilibili_accounts SET is_bot = 0 WHERE id <> ?`, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetBilibiliAccountByID(ctx, id)
+}
+
+// DeleteBilibiliAccounts removes accounts that no push channel is bound to.
+func (s *Store) DeleteBilibiliAccounts(ctx context.Context, ids []int64) (int64, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	var inUse int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM push_settings WHERE account_id IN (`+strings.Join(placeholders, ",")+`)`, args...).Scan(&inUse); err != nil {
+		return 0, err
+	}
+	if inUse > 0 {
+		return 0, errors.New("account is bound to a push channel")
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM bilibili_accounts WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpdateBilibiliAccountUser records who an account is logged in as, after a login status check.
+func (s *Store) UpdateBilibiliAccountUser(ctx context.Context, id int64, uid int64, uname string, face string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.db.ExecContext(ctx, `UPDATE bilibili_accounts SET uid = ?, uname = ?, face = ?, checked_at = ?, updated_at = ? WHERE id = ?`,
+		uid, strings.TrimSpace(uname), strings.TrimSpace(face), now, now, id)
+	return err
+}
+
+// GetBilibiliCookie returns the credentials of an account; account 0 is the main account.
+func (s *Store) GetBilibiliCookie(ctx context.Context, accountID int64) (*CookieSetting, error) {
+	if accountID <= 0 {
+		return s.GetCookieSetting(ctx)
+	}
+	account, err := s.GetBilibiliAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &CookieSetting{
+		ID:           account.ID,
+		Content:      account.Cookie,
+		RefreshToken: account.RefreshToken,
+		CreatedAt:    account.CreatedAt,
+		UpdatedAt:    account.UpdatedAt,
+	}, nil
+}
+
+// SaveBilibiliCookie stores the credentials of an account; account 0 is the main account.
+func (s *Store) SaveBilibiliCookie(ctx context.Context, accountID int64, content string, refreshToken string) error {
+	if accountID <= 0 {
+		return s.SaveCookie(ctx, content, refreshToken)
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE bilibili_accounts SET cookie = ?, refresh_token = ?, updated_at = ? WHERE id = ?`,
+		content, refreshToken, time.Now().UTC().Format(time.RFC3339Nano), accountID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanBilibiliAccount(scanner interface{ Scan(dest ...any) error }) (*BilibiliAccount, error) {
+	item := BilibiliAccount{}
+	var isBot int
+	var checkedAt sql.NullString
+	var createdAt, updatedAt string
+	if err := scanner.Scan(&item.ID, &item.Name, &item.UID, &item.Uname, &item.Face, &item.Cookie, &item.RefreshToken, &isBot, &checkedAt, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	item.IsBot = isBot == 1
+	item.HasCookie = strings.TrimSpace(item.Cookie) != ""
+	if checkedAt.Valid && strings.TrimSpace(checkedAt.String) != "" {
+		parsed := parseSQLiteTime(checkedAt.String)
+		item.CheckedAt = &parsed
+	}
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+func (s *Store) GetCookieHealthSetting(ctx context.Context) (*CookieHealthSetting, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, enabled, interval_minutes, lead_minutes, email_alert, webhook_alert, provider, provider_params, updated_at
+	FROM cookie_health_settings ORDER BY id DESC LIMIT 1`)
+	item := CookieHealthSetting{}
+	var enabled, emailAlert, webhookAlert int
+	var paramsRaw string
+	var updatedAt string
+	if err := row.Scan(&item.ID, &enabled, &item.IntervalMinutes, &item.LeadMinutes, &emailAlert, &webhookAlert, &item.Provider, &paramsRaw, &updatedAt); err != nil {
+		return nil, err
+	}
+	item.Enabled = enabled == 1
+	item.EmailAlert = emailAlert == 1
+	item.WebhookAlert = webhookAlert == 1
+	item.ProviderParams = map[string]any{}
+	if err := json.Unmarshal([]byte(paramsRaw), &item.ProviderParams); err != nil || item.ProviderParams == nil {
+		item.ProviderParams = map[string]any{}
+	}
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
+
+func (s *Store) SaveCookieHealthSetting(ctx context.Context, req CookieHealthSetting) (*CookieHealthSetting, error) {
+	current, err := s.GetCookieHealthSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.LeadMinutes < 0 {
+		req.LeadMinutes = 0
+	}
+	if req.LeadMinutes > 1440 {
+		req.LeadMinutes = 1440
+	}
+	if req.ProviderParams == nil {
+		req.ProviderParams = map[string]any{}
+	}
+	paramsJSON, err := json.Marshal(req.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE cookie_health_settings SET
+		enabled=?,
+		interval_minutes=?,
+		lead_minutes=?,
+		email_alert=?,
+		webhook_alert=?,
+		provider=?,
+		provider_params=?,
+		updated_at=?
+	WHERE id=?`,
+		boolToInt(req.Enabled),
+		clampInt(req.IntervalMinutes, 5, 1440, 60),
+		req.LeadMinutes,
+		boolToInt(req.EmailAlert),
+		boolToInt(req.WebhookAlert),
+		strings.ToLower(strings.TrimSpace(req.Provider)),
+		string(paramsJSON),
+		time.Now().UTC().Format(time.RFC3339Nano),
+		current.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCookieHealthSetting(ctx)
+}
@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const encoderProfileColumns = `id, name, video_codec, frame_rate, gop_sec, preset, tune, profile, level, rate_control,
+	bitrate_kbps, crf, b_frames, pixel_format, audio_codec, audio_bitrate_kbps, audio_sample_rate, extra_args,
+	created_at, updated_at`
+
+// encoderOptionPattern keeps preset/tune/profile/level/pixel format values to plain encoder option tokens.
+var encoderOptionPattern = regexp.MustCompile(`^[A-Za-z0-9_.+-]*$`)
+
+// EncoderAudioCodecs are the audio encoders a profile may pick; FLV to Bilibili only carries AAC.
+var EncoderAudioCodecs = []string{"aac", "libfdk_aac"}
+
+func (s *Store) ListEncoderProfiles(ctx context.Context) ([]EncoderProfile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+encoderProfileColumns+` FROM encoder_profiles ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]EncoderProfile, 0)
+	for rows.Next() {
+		item, scanErr := scanEncoderProfile(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetEncoderProfileByID(ctx context.Context, id int64) (*EncoderProfile, error) {
+	if id <= 0 {
+		return nil, errors.New("encoder profile id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+encoderProfileColumns+` FROM encoder_profiles WHERE id = ?`, id)
+	return scanEncoderProfile(row)
+}
+
+// NormalizeEncoderProfile fills defaults and rejects values ffmpeg cannot take. Whether the video codec
+// is available in the local ffmpeg build is up to the caller.
+func NormalizeEncoderProfile(item EncoderProfile) (EncoderProfile, error) {
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Name == "" {
+		return item, errors.New("encoder profile name is required")
+	}
+	item.VideoCodec = strings.TrimSpace(item.VideoCodec)
+	if item.VideoCodec == "" {
+		item.VideoCodec = "libx264"
+	}
+	if item.VideoCodec == "copy" {
+		return item, errors.New("encoder profiles always encode; use the copy setting of the channel instead")
+	}
+	if item.FrameRate == 0 {
+		item.FrameRate = 30
+	}
+	if item.FrameRate < 1 || item.FrameRate > 120 {
+		return item, errors.New("frameRate must be between 1 and 120")
+	}
+	if item.GOPSec == 0 {
+		item.GOPSec = 1
+	}
+	if item.GOPSec < 0.5 || item.GOPSec > 10 {
+		return item, errors.New("gopSec must be between 0.5 and 10")
+	}
+	for label, value := range map[string]*string{
+		"preset":      &item.Preset,
+		"tune":        &item.Tune,
+		"profile":     &item.Profile,
+		"level":       &item.Level,
+		"pixelFormat": &item.PixelFormat,
+		"audioCodec":  &item.AudioCodec,
+	} {
+		*value = strings.TrimSpace(*value)
+		if !encoderOptionPattern.MatchString(*value) {
+			return item, fmt.Errorf("%s contains unsupported characters", label)
+		}
+	}
+	if item.PixelFormat == "" {
+		item.PixelFormat = "yuv420p"
+	}
+	item.RateControl = NormalizeEncoderRateControl(string(item.RateControl))
+	if item.BitrateKbps < 0 || item.BitrateKbps > 100000 {
+		return item, errors.New("bitrateKbps must be between 0 and 100000")
+	}
+	if item.CRF == 0 {
+		item.CRF = 23
+	}
+	if item.CRF < 1 || item.CRF > 51 {
+		return item, errors.New("crf must be between 1 and 51")
+	}
+	if item.BFrames < 0 || item.BFrames > 16 {
+		return item, errors.New("bFrames must be between 0 and 16")
+	}
+	if item.AudioCodec == "" {
+		item.AudioCodec = "aac"
+	}
+	supported := false
+	for _, codec := range EncoderAudioCodecs {
+		if item.AudioCodec == codec {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return item, fmt.Errorf("audioCodec must be one of %s", strings.Join(EncoderAudioCodecs, ", "))
+	}
+	if item.AudioBitrateKbps == 0 {
+		item.AudioBitrateKbps = 128
+	}
+	if item.AudioBitrateKbps < 32 || item.AudioBitrateKbps > 512 {
+		return item, errors.New("audioBitrateKbps must be between 32 and 512")
+	}
+	switch item.AudioSampleRate {
+	case 0:
+		item.AudioSampleRate = 44100
+	case 22050, 44100, 48000:
+	default:
+		return item, errors.New("audioSampleRate must be 22050, 44100 or 48000")
+	}
+	item.ExtraArgs = strings.TrimSpace(item.ExtraArgs)
+	return item, nil
+}
+
+// SaveEncoderProfile creates (ID 0) or updates a profile. Channels using it pick the change up on the
+// next push start.
+func (s *Store) SaveEncoderProfile(ctx context.Context, req EncoderProfile) (*EncoderProfile, error) {
+	item, err := NormalizeEncoderProfile(req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	args := []any{
+		item.Name, item.VideoCodec, item.FrameRate, item.GOPSec, item.Preset, item.Tune, item.Profile, item.Level,
+		string(item.RateControl), item.BitrateKbps, item.CRF, item.BFrames, item.PixelFormat, item.AudioCodec,
+		item.AudioBitrateKbps, item.AudioSampleRate, item.ExtraArgs, now,
+	}
+
+	if item.ID > 0 {
+		result, err := s.db.ExecContext(ctx, `UPDATE encoder_profiles SET
+			name = ?, video_codec = ?, frame_rate = ?, gop_sec = ?, preset = ?, tune = ?, profile = ?, level = ?,
+			rate_control = ?, bitrate_kbps = ?, crf = ?, b_frames = ?, pixel_format = ?, audio_codec = ?,
+			audio_bitrate_kbps = ?, audio_sample_rate = ?, extra_args = ?, updated_at = ?
+			WHERE id = ?`, append(args, item.ID)...)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil, errors.New("encoder profile not found")
+		}
+		return s.GetEncoderProfileByID(ctx, item.ID)
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO encoder_profiles (
+		name, video_codec, frame_rate, gop_sec, preset, tune, profile, level, rate_control,
+		bitrate_kbps, crf, b_frames, pixel_format, audio_codec, audio_bitrate_kbps, audio_sample_rate, extra_args,
+		updated_at, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, append(args, now)...)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetEncoderProfileByID(ctx, id)
+}
+
+// DeleteEncoderProfiles removes profiles that no push channel still uses.
+func (s *Store) DeleteEncoderProfiles(ctx context.Context, ids []int64) (int64, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	var inUse int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM push_settings WHERE encoder_profile_id IN (`+strings.Join(placeholders, ",")+`)`,
+		args...).Scan(&inUse); err != nil {
+		return 0, err
+	}
+	if inUse > 0 {
+		return 0, errors.New("encoder profile is used by a push channel")
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM encoder_profiles WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanEncoderProfile(scanner interface{ Scan(dest ...any) error }) (*EncoderProfile, error) {
+	item := EncoderProfile{}
+	var rateControl, createdAt, updatedAt string
+	if err := scanner.Scan(
+		&item.ID,
+		&item.Name,
+		&item.VideoCodec,
+		&item.FrameRate,
+		&item.GOPSec,
+		&item.Preset,
+		&item.Tune,
+		&item.Profile,
+		&item.Level,
+		&rateControl,
+		&item.BitrateKbps,
+		&item.CRF,
+		&item.BFrames,
+		&item.PixelFormat,
+		&item.AudioCodec,
+		&item.AudioBitrateKbps,
+		&item.AudioSampleRate,
+		&item.ExtraArgs,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	item.RateControl = NormalizeEncoderRateControl(rateControl)
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+func (s *Store) InsertLiveGift(ctx context.Context, item LiveGift) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO live_gifts (room_id, uid, uname, gift_id, gift_name, num, coin_type, price, total_coin, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.RoomID,
+		item.UID,
+		strings.TrimSpace(item.Uname),
+		item.GiftID,
+		strings.TrimSpace(item.GiftName),
+		item.Num,
+		strings.TrimSpace(item.CoinType),
+		item.Price,
+		item.TotalCoin,
+		liveMessageTime(item.CreatedAt),
+	)
+	return err
+}
+
+func (s *Store) ListLiveGifts(ctx context.Context, roomID int64, since time.Time, limit int) ([]LiveGift, error) {
+	query, args := liveMessageQuery(`SELECT id, room_id, uid, uname, gift_id, gift_name, num, coin_type, price, total_coin, created_at FROM live_gifts`, roomID, "", since, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveGift, 0, 32)
+	for rows.Next() {
+		var item LiveGift
+		var createdAt string
+		if err := rows.Scan(&item.ID, &item.RoomID, &item.UID, &item.Uname, &item.GiftID, &item.GiftName, &item.Num, &item.CoinType, &item.Price, &item.TotalCoin, &createdAt); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) InsertLiveSuperChat(ctx context.Context, item LiveSuperChat) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO live_super_chats (room_id, super_chat_id, uid, uname, message, price, duration_sec, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.RoomID,
+		item.SuperChatID,
+		item.UID,
+		strings.TrimSpace(item.Uname),
+		strings.TrimSpace(item.Message),
+		item.Price,
+		item.DurationSec,
+		liveMessageTime(item.CreatedAt),
+	)
+	return err
+}
+
+func (s *Store) ListLiveSuperChats(ctx context.Context, roomID int64, since time.Time, limit int) ([]LiveSuperChat, error) {
+	query, args := liveMessageQuery(`SELECT id, room_id, super_chat_id, uid, uname, message, price, duration_sec, created_at FROM live_super_chats`, roomID, "", since, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveSuperChat, 0, 32)
+	for rows.Next() {
+		var item LiveSuperChat
+		var createdAt string
+		if err := rows.Scan(&item.ID, &item.RoomID, &item.SuperChatID, &item.UID, &item.Uname, &item.Message, &item.Price, &item.DurationSec, &createdAt); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) InsertLiveGuardBuy(ctx context.Context, item LiveGuardBuy) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO live_guard_buys (room_id, uid, uname, guard_level, gift_name, num, price, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.RoomID,
+		item.UID,
+		strings.TrimSpace(item.Uname),
+		item.GuardLevel,
+		strings.TrimSpace(item.GiftName),
+		item.Num,
+		item.Price,
+		liveMessageTime(item.CreatedAt),
+	)
+	return err
+}
+
+func (s *Store) ListLiveGuardBuys(ctx context.Context, roomID int64, since time.Time, limit int) ([]LiveGuardBuy, error) {
+	query, args := liveMessageQuery(`SELECT id, room_id, uid, uname, guard_level, gift_name, num, price, created_at FROM live_guard_buys`, roomID, "", since, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveGuardBuy, 0, 32)
+	for rows.Next() {
+		var item LiveGuardBuy
+		var createdAt string
+		if err := rows.Scan(&item.ID, &item.RoomID, &item.UID, &item.Uname, &item.GuardLevel, &item.GiftName, &item.Num, &item.Price, &createdAt); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) InsertLiveInteraction(ctx context.Context, item LiveInteraction) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO live_interactions (room_id, uid, uname, kind, created_at) VALUES (?, ?, ?, ?, ?)`,
+		item.RoomID,
+		item.UID,
+		strings.TrimSpace(item.Uname),
+		strings.TrimSpace(item.Kind),
+		liveMessageTime(item.CreatedAt),
+	)
+	return err
+}
+
+// ListLiveInteractions lists enters, follows, shares and likes, only those of kind when it is set. A
+// zero since lists the latest regardless of age, like the other message lists.
+func (s *Store) ListLiveInteractions(ctx context.Context, roomID int64, kind string, since time.Time, limit int) ([]LiveInteraction, error) {
+	query, args := liveMessageQuery(`SELECT id, room_id, uid, uname, kind, created_at FROM live_interactions`, roomID, kind, since, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveInteraction, 0, 32)
+	for rows.Next() {
+		var item LiveInteraction
+		var createdAt string
+		if err := rows.Scan(&item.ID, &item.RoomID, &item.UID, &item.Uname, &item.Kind, &createdAt); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// liveMessageQuery filters a message table by room, kind and creation time when they are set.
+func liveMessageQuery(selectSQL string, roomID int64, kind string, since time.Time, limit int) (string, []any) {
+	query := selectSQL + ` WHERE 1=1`
+	args := make([]any, 0, 4)
+	if !since.IsZero() {
+		query += ` AND datetime(created_at) >= datetime(?)`
+		args = append(args, since.UTC().Format(time.RFC3339Nano))
+	}
+	if roomID > 0 {
+		query += ` AND room_id = ?`
+		args = append(args, roomID)
+	}
+	if kind = strings.TrimSpace(kind); kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, clampLimit(limit, 100, 1000))
+	return query, args
+}
+
+// liveMessageTime stores the time the message carried, or now when it had none.
+func liveMessageTime(value time.Time) string {
+	if value.IsZero() {
+		value = time.Now()
+	}
+	return value.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// liveRevenueRowsSQL lists every paid message as one row with its value in gold seeds; free gifts
+// carry theirs in silver.
+const liveRevenueRowsSQL = `SELECT room_id, uid, uname, 'gift' AS kind,
+		CASE WHEN coin_type = 'gold' THEN total_coin ELSE 0 END AS gold,
+		CASE WHEN coin_type = 'gold' THEN 0 ELSE total_coin END AS silver,
+		created_at
+	FROM live_gifts
+	UNION ALL SELECT room_id, uid, uname, 'super_chat', price * 1000, 0, created_at FROM live_super_chats
+	UNION ALL SELECT room_id, uid, uname, 'guard', price * num, 0, created_at FROM live_guard_buys`
+
+// liveRevenueSumsSQL aggregates rows of liveRevenueRowsSQL aliased r, in the order scanLiveRevenue reads.
+const liveRevenueSumsSQL = `COALESCE(SUM(CASE WHEN r.kind = 'gift' THEN r.gold END), 0),
+		COALESCE(SUM(CASE WHEN r.kind = 'super_chat' THEN r.gold END), 0),
+		COALESCE(SUM(CASE WHEN r.kind = 'guard' THEN r.gold END), 0),
+		COALESCE(SUM(r.silver), 0),
+		COALESCE(SUM(r.kind = 'gift'), 0),
+		COALESCE(SUM(r.kind = 'super_chat'), 0),
+		COALESCE(SUM(r.kind = 'guard'), 0)`
+
+// LiveRevenueBetween totals the revenue of a room (every room when roomID is 0) from from up to to,
+// up to now when to is zero.
+func (s *Store) LiveRevenueBetween(ctx context.Context, roomID int64, from time.Time, to time.Time) (LiveRevenueSummary, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	filter, args := liveRevenueFilter(roomID, from)
+	filter += ` AND datetime(r.created_at) < datetime(?)`
+	args = append(args, to.UTC().Format(time.RFC3339Nano))
+	row := s.db.QueryRowContext(ctx, `SELECT `+liveRevenueSumsSQL+` FROM (`+liveRevenueRowsSQL+`) r `+filter, args...)
+	summary := LiveRevenueSummary{}
+	err := scanLiveRevenue(row, &summary)
+	return summary, err
+}
+
+// ListLiveRevenueByDay returns the revenue of every UTC day since the cutoff that had any.
+func (s *Store) ListLiveRevenueByDay(ctx context.Context, roomID int64, since time.Time) ([]LiveRevenueDay, error) {
+	filter, args := liveRevenueFilter(roomID, since)
+	rows, err := s.db.QueryContext(ctx, `SELECT strftime('%Y-%m-%d', datetime(r.created_at)) AS day, `+liveRevenueSumsSQL+`
+	FROM (`+liveRevenueRowsSQL+`) r `+filter+` GROUP BY day ORDER BY day ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveRevenueDay, 0, 32)
+	for rows.Next() {
+		var item LiveRevenueDay
+		var day sql.NullString
+		if err := scanLiveRevenue(rows, &item.LiveRevenueSummary, &day); err != nil {
+			return nil, err
+		}
+		if !day.Valid {
+			continue
+		}
+		item.Day = day.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// liveSessionRowsSQL is liveRevenueRowsSQL matched to the sessions CTE by room and time. Session
+// and message times are both stored as RFC3339 UTC text, so they compare raw and the
+// (room_id, created_at) indexes of the message tables apply.
+const liveSessionRowsSQL = `SELECT s.id AS session_id, 'gift' AS kind,
+		CASE WHEN g.coin_type = 'gold' THEN g.total_coin ELSE 0 END AS gold,
+		CASE WHEN g.coin_type = 'gold' THEN 0 ELSE g.total_coin END AS silver
+	FROM sessions s JOIN live_gifts g ON g.room_id = s.room_id AND g.created_at >= s.started_at AND g.created_at < s.until
+	UNION ALL SELECT s.id, 'super_chat', c.price * 1000, 0
+	FROM sessions s JOIN live_super_chats c ON c.room_id = s.room_id AND c.created_at >= s.started_at AND c.created_at < s.until
+	UNION ALL SELECT s.id, 'guard', b.price * b.num, 0
+	FROM sessions s JOIN live_guard_buys b ON b.room_id = s.room_id AND b.created_at >= s.started_at AND b.created_at < s.until`
+
+// ListLiveSessionRevenue returns the revenue of the stream sessions started since the cutoff, newest
+// first, counting what the session's room received between its start and end.
+func (s *Store) ListLiveSessionRevenue(ctx context.Context, roomID int64, since time.Time, limit int) ([]LiveSessionRevenue, error) {
+	query := `WITH sessions AS (
+		SELECT id, channel_id, room_id, started_at, ended_at, COALESCE(ended_at, ?) AS until
+		FROM stream_sessions WHERE started_at >= ?`
+	args := []any{time.Now().UTC().Format(time.RFC3339Nano), since.UTC().Format(time.RFC3339Nano)}
+	if roomID > 0 {
+		query += ` AND room_id = ?`
+		args = append(args, roomID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?)
+	SELECT ss.id, ss.channel_id, ss.room_id, ss.started_at, ss.ended_at, ` + liveRevenueSumsSQL + `
+	FROM sessions ss LEFT JOIN (` + liveSessionRowsSQL + `) r ON r.session_id = ss.id
+	GROUP BY ss.id ORDER BY ss.id DESC`
+	args = append(args, clampLimit(limit, 100, 1000))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveSessionRevenue, 0, 32)
+	for rows.Next() {
+		var item LiveSessionRevenue
+		var startedAt string
+		var endedAt sql.NullString
+		if err := scanLiveRevenue(rows, &item.LiveRevenueSummary, &item.SessionID, &item.ChannelID, &item.RoomID, &startedAt, &endedAt); err != nil {
+			return nil, err
+		}
+		item.StartedAt = parseSQLiteTime(startedAt)
+		if endedAt.Valid && strings.TrimSpace(endedAt.String) != "" {
+			parsed := parseSQLiteTime(endedAt.String)
+			item.EndedAt = &parsed
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListTopLiveSupporters ranks viewers by the gold they spent since the cutoff. Anonymous viewers
+// (uid 0) are told apart by name.
+func (s *Store) ListTopLiveSupporters(ctx context.Context, roomID int64, since time.Time, limit int) ([]LiveSupporter, error) {
+	filter, args := liveRevenueFilter(roomID, since)
+	args = append(args, clampLimit(limit, 20, 500))
+	rows, err := s.db.QueryContext(ctx, `SELECT r.uid, MAX(r.uname),
+		COALESCE(SUM(CASE WHEN r.kind = 'gift' THEN r.gold END), 0),
+		COALESCE(SUM(CASE WHEN r.kind = 'super_chat' THEN r.gold END), 0),
+		COALESCE(SUM(CASE WHEN r.kind = 'guard' THEN r.gold END), 0),
+		SUM(r.gold) AS total_gold
+	FROM (`+liveRevenueRowsSQL+`) r `+filter+`
+	GROUP BY r.uid, CASE WHEN r.uid = 0 THEN r.uname ELSE '' END
+	HAVING total_gold > 0
+	ORDER BY total_gold DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveSupporter, 0, 20)
+	for rows.Next() {
+		var item LiveSupporter
+		if err := rows.Scan(&item.UID, &item.Uname, &item.GiftGold, &item.SuperChatGold, &item.GuardGold, &item.TotalGold); err != nil {
+			return nil, err
+		}
+		item.Battery = item.TotalGold / 100
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListLiveGiftStats breaks the gifts received since the cutoff down by gift type, most valuable first.
+func (s *Store) ListLiveGiftStats(ctx context.Context, roomID int64, since time.Time, limit int) ([]LiveGiftStat, error) {
+	query := `SELECT gift_id, MAX(gift_name), coin_type, COUNT(1), SUM(num), SUM(total_coin) AS total
+	FROM live_gifts WHERE datetime(created_at) >= datetime(?)`
+	args := []any{since.UTC().Format(time.RFC3339Nano)}
+	if roomID > 0 {
+		query += ` AND room_id = ?`
+		args = append(args, roomID)
+	}
+	query += ` GROUP BY gift_id, coin_type ORDER BY coin_type = 'gold' DESC, total DESC LIMIT ?`
+	args = append(args, clampLimit(limit, 50, 500))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]LiveGiftStat, 0, 32)
+	for rows.Next() {
+		var item LiveGiftStat
+		if err := rows.Scan(&item.GiftID, &item.GiftName, &item.CoinType, &item.Times, &item.Num, &item.TotalCoin); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func liveRevenueFilter(roomID int64, since time.Time) (string, []any) {
+	filter := `WHERE datetime(r.created_at) >= datetime(?)`
+	args := []any{since.UTC().Format(time.RFC3339Nano)}
+	if roomID > 0 {
+		filter += ` AND r.room_id = ?`
+		args = append(args, roomID)
+	}
+	return filter, args
+}
+
+// scanLiveRevenue scans the columns before the sums into lead, then the sums of liveRevenueSumsSQL.
+func scanLiveRevenue(scanner interface{ Scan(dest ...any) error }, summary *LiveRevenueSummary, lead ...any) error {
+	dest := append(lead,
+		&summary.GiftGold,
+		&summary.SuperChatGold,
+		&summary.GuardGold,
+		&summary.SilverCoin,
+		&summary.GiftCount,
+		&summary.SuperChatCount,
+		&summary.GuardCount,
+	)
+	if err := scanner.Scan(dest...); err != nil {
+		return err
+	}
+	summary.TotalGold = summary.GiftGold + summary.SuperChatGold + summary.GuardGold
+	summary.Battery = summary.TotalGold / 100
+	return nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+func (s *Store) InsertMonitorStateChange(ctx context.Context, item MonitorStateChange) (*MonitorStateChange, error) {
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now().UTC()
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO monitor_state_changes (room_id, kind, from_status, to_status, previous_title, title, unexpected, notified, notify_error, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.RoomID,
+		strings.TrimSpace(item.Kind),
+		item.FromStatus,
+		item.ToStatus,
+		item.PreviousTitle,
+		item.Title,
+		boolToInt(item.Unexpected),
+		boolToInt(item.Notified),
+		strings.TrimSpace(item.NotifyError),
+		item.CreatedAt.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, err
+	}
+	item.ID, _ = result.LastInsertId()
+	return &item, nil
+}
+
+// UpdateMonitorStateChangeNotice records how the email of a change went.
+func (s *Store) UpdateMonitorStateChangeNotice(ctx context.Context, id int64, notified bool, notifyError string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE monitor_state_changes SET notified=?, notify_error=? WHERE id=?`, boolToInt(notified), strings.TrimSpace(notifyError), id)
+	return err
+}
+
+// ListMonitorStateChanges lists the confirmed transitions newest first, of one room when roomID is set.
+func (s *Store) ListMonitorStateChanges(ctx context.Context, roomID int64, limit int) ([]MonitorStateChange, error) {
+	query := `SELECT id, room_id, kind, from_status, to_status, previous_title, title, unexpected, notified, notify_error, created_at FROM monitor_state_changes`
+	args := make([]any, 0, 2)
+	if roomID > 0 {
+		query += ` WHERE room_id = ?`
+		args = append(args, roomID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, clampLimit(limit, 100, 1000))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]MonitorStateChange, 0, 32)
+	for rows.Next() {
+		var item MonitorStateChange
+		var unexpected int
+		var notified int
+		var createdAt string
+		if err := rows.Scan(&item.ID, &item.RoomID, &item.Kind, &item.FromStatus, &item.ToStatus, &item.PreviousTitle, &item.Title, &unexpected, &notified, &item.NotifyError, &createdAt); err != nil {
+			return nil, err
+		}
+		item.Unexpected = unexpected == 1
+		item.Notified = notified == 1
+		item.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,329 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const overlayColumns = `id, channel_id, name, overlay_type, enabled, z_index, content, live_key, target_at, material_id, style,
+	danmaku, created_at, updated_at`
+
+// ListOverlays returns the layers of one channel (channelID > 0) or of all channels, bottom layer first.
+func (s *Store) ListOverlays(ctx context.Context, channelID int64) ([]Overlay, error) {
+	query := `SELECT ` + overlayColumns + ` FROM overlays`
+	args := make([]any, 0, 1)
+	if channelID > 0 {
+		query += ` WHERE channel_id = ?`
+		args = append(args, channelID)
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY channel_id ASC, z_index ASC, id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Overlay, 0)
+	for rows.Next() {
+		item, scanErr := scanOverlay(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetOverlayByID(ctx context.Context, id int64) (*Overlay, error) {
+	if id <= 0 {
+		return nil, errors.New("overlay id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+overlayColumns+` FROM overlays WHERE id = ?`, id)
+	return scanOverlay(row)
+}
+
+// SaveOverlay creates (ID 0) or updates an overlay layer. A zero ChannelID binds it to the default channel.
+func (s *Store) SaveOverlay(ctx context.Context, req OverlaySaveRequest) (*Overlay, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.LiveKey = strings.TrimSpace(req.LiveKey)
+	overlayType := NormalizeOverlayType(req.Type)
+	if req.Name == "" {
+		return nil, errors.New("overlay name is required")
+	}
+	switch overlayType {
+	case "":
+		return nil, errors.New("unsupported overlay type")
+	case OverlayTypeImage:
+		material, err := s.GetMaterialByID(ctx, req.MaterialID)
+		if err != nil {
+			return nil, errors.New("image material not found")
+		}
+		if material.FileType != FileTypeImage {
+			return nil, errors.New("overlay material must be an image")
+		}
+	case OverlayTypeCountdown:
+		if req.TargetAt == nil {
+			return nil, errors.New("countdown overlay requires targetAt")
+		}
+	case OverlayTypeLiveValue:
+		if req.LiveKey == "" {
+			return nil, errors.New("live value overlay requires liveKey")
+		}
+	}
+	danmaku := normalizeOverlayDanmaku(req.Danmaku)
+	if overlayType == OverlayTypeDanmaku && len(OverlayDanmakuPalette(danmaku)) > maxOverlayDanmakuColors {
+		return nil, fmt.Errorf("danmaku overlay supports at most %d name colors", maxOverlayDanmakuColors)
+	}
+	channel, err := s.GetPushSettingByID(ctx, req.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	styleJSON, err := json.Marshal(normalizeOverlayStyle(req.Style))
+	if err != nil {
+		return nil, err
+	}
+	danmakuJSON, err := json.Marshal(danmaku)
+	if err != nil {
+		return nil, err
+	}
+	var targetAt sql.NullString
+	if req.TargetAt != nil {
+		targetAt = sql.NullString{String: req.TargetAt.UTC().Format(time.RFC3339Nano), Valid: true}
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	if req.ID > 0 {
+		result, err := s.db.ExecContext(ctx, `UPDATE overlays SET
+			channel_id = ?, name = ?, overlay_type = ?, enabled = ?, z_index = ?, content = ?, live_key = ?,
+			target_at = ?, material_id = ?, style = ?, danmaku = ?, updated_at = ?
+		WHERE id = ?`,
+			channel.ID, req.Name, string(overlayType), boolToInt(req.Enabled), req.ZIndex, req.Content, req.LiveKey,
+			targetAt, req.MaterialID, string(styleJSON), string(danmakuJSON), now, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil, sql.ErrNoRows
+		}
+		return s.GetOverlayByID(ctx, req.ID)
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO overlays (
+		channel_id, name, overlay_type, enabled, z_index, content, live_key, target_at, material_id, style, danmaku,
+		created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		channel.ID, req.Name, string(overlayType), boolToInt(req.Enabled), req.ZIndex, req.Content, req.LiveKey,
+		targetAt, req.MaterialID, string(styleJSON), string(danmakuJSON), now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOverlayByID(ctx, id)
+}
+
+// UpdateOverlayContent changes only the content of a layer; the text file ffmpeg reloads is rewritten by the caller.
+func (s *Store) UpdateOverlayContent(ctx context.Context, id int64, content string) (*Overlay, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE overlays SET content = ?, updated_at = ? WHERE id = ?`,
+		content, time.Now().UTC().Format(time.RFC3339Nano), id)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return s.GetOverlayByID(ctx, id)
+}
+
+// UpdateOverlayLiveValue sets the content of every live_value layer bound to key, on one channel
+// (channelID > 0) or on all of them, and returns the updated layers.
+func (s *Store) UpdateOverlayLiveValue(ctx context.Context, channelID int64, key string, value string) ([]Overlay, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, errors.New("live value key is required")
+	}
+	query := `UPDATE overlays SET content = ?, updated_at = ? WHERE overlay_type = ? AND live_key = ?`
+	args := []any{value, time.Now().UTC().Format(time.RFC3339Nano), string(OverlayTypeLiveValue), key}
+	if channelID > 0 {
+		query += ` AND channel_id = ?`
+		args = append(args, channelID)
+	}
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	items, err := s.ListOverlays(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	updated := make([]Overlay, 0, len(items))
+	for _, item := range items {
+		if item.Type == OverlayTypeLiveValue && item.LiveKey == key {
+			updated = append(updated, item)
+		}
+	}
+	return updated, nil
+}
+
+func (s *Store) DeleteOverlays(ctx context.Context, ids []int64) (int64, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM overlays WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanOverlay(scanner interface{ Scan(dest ...any) error }) (*Overlay, error) {
+	item := Overlay{}
+	var overlayType, styleRaw, danmakuRaw, createdAt, updatedAt string
+	var enabled int
+	var targetAt sql.NullString
+	if err := scanner.Scan(
+		&item.ID,
+		&item.ChannelID,
+		&item.Name,
+		&overlayType,
+		&enabled,
+		&item.ZIndex,
+		&item.Content,
+		&item.LiveKey,
+		&targetAt,
+		&item.MaterialID,
+		&styleRaw,
+		&danmakuRaw,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	item.Type = OverlayType(overlayType)
+	item.Enabled = enabled == 1
+	if targetAt.Valid && strings.TrimSpace(targetAt.String) != "" {
+		parsed := parseSQLiteTime(targetAt.String)
+		item.TargetAt = &parsed
+	}
+	_ = json.Unmarshal([]byte(styleRaw), &item.Style)
+	item.Style = normalizeOverlayStyle(item.Style)
+	_ = json.Unmarshal([]byte(danmakuRaw), &item.Danmaku)
+	item.Danmaku = normalizeOverlayDanmaku(item.Danmaku)
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
+
+func normalizeOverlayStyle(style OverlayStyle) OverlayStyle {
+	style.X = strings.TrimSpace(style.X)
+	style.Y = strings.TrimSpace(style.Y)
+	if style.X == "" {
+		style.X = "20"
+	}
+	if style.Y == "" {
+		style.Y = "20"
+	}
+	if style.FontSize <= 0 {
+		style.FontSize = 28
+	}
+	if style.FontSize > 200 {
+		style.FontSize = 200
+	}
+	style.FontColor = strings.TrimSpace(style.FontColor)
+	if style.FontColor == "" {
+		style.FontColor = "white"
+	}
+	style.BoxColor = strings.TrimSpace(style.BoxColor)
+	if style.BoxColor == "" {
+		style.BoxColor = "black@0.45"
+	}
+	style.FontFile = strings.TrimSpace(style.FontFile)
+	if style.Width < 0 {
+		style.Width = 0
+	}
+	if style.Opacity <= 0 || style.Opacity > 1 {
+		style.Opacity = 1
+	}
+	return style
+}
+
+const maxOverlayDanmakuColors = 8
+
+func normalizeOverlayDanmaku(cfg OverlayDanmakuConfig) OverlayDanmakuConfig {
+	cfg.Mode = strings.ToLower(strings.TrimSpace(cfg.Mode))
+	if cfg.Mode != OverlayDanmakuModeScroll {
+		cfg.Mode = OverlayDanmakuModeStack
+	}
+	if cfg.MaxLines <= 0 {
+		cfg.MaxLines = 6
+	}
+	if cfg.MaxLines > 20 {
+		cfg.MaxLines = 20
+	}
+	if cfg.MaxChars <= 0 {
+		cfg.MaxChars = 40
+	}
+	if cfg.ScrollSpeed <= 0 {
+		cfg.ScrollSpeed = 120
+	}
+	colors := make([]string, 0, len(cfg.NameColors))
+	for _, color := range cfg.NameColors {
+		if color = strings.TrimSpace(color); color != "" {
+			colors = append(colors, color)
+		}
+	}
+	cfg.NameColors = colors
+	userColors := make(map[string]string, len(cfg.UserColors))
+	for uid, color := range cfg.UserColors {
+		uid, color = strings.TrimSpace(uid), strings.TrimSpace(color)
+		if uid != "" && color != "" {
+			userColors[uid] = color
+		}
+	}
+	cfg.UserColors = userColors
+	words := make([]string, 0, len(cfg.BlockedWords))
+	for _, word := range cfg.BlockedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	cfg.BlockedWords = words
+	return cfg
+}
+
+// OverlayDanmakuPalette lists the distinct name colors of a danmaku box in a stable order; each one
+// becomes its own drawtext per line.
+func OverlayDanmakuPalette(cfg OverlayDanmakuConfig) []string {
+	palette := make([]string, 0, len(cfg.NameColors)+len(cfg.UserColors))
+	seen := make(map[string]struct{}, cap(palette))
+	add := func(color string) {
+		if _, ok := seen[color]; ok {
+			return
+		}
+		seen[color] = struct{}{}
+		palette = append(palette, color)
+	}
+	for _, color := range cfg.NameColors {
+		add(color)
+	}
+	uids := make([]string, 0, len(cfg.UserColors))
+	for uid := range cfg.UserColors {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	for _, uid := range uids {
+		add(cfg.UserColors[uid])
+	}
+	return palette
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const playlistColumns = `id, name, mode, items, created_at, updated_at`
+
+func (s *Store) ListPlaylists(ctx context.Context) ([]Playlist, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+playlistColumns+` FROM playlists ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Playlist, 0)
+	for rows.Next() {
+		item, scanErr := scanPlaylist(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetPlaylistByID(ctx context.Context, id int64) (*Playlist, error) {
+	if id <= 0 {
+		return nil, errors.New("playlist id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+playlistColumns+` FROM playlists WHERE id = ?`, id)
+	return scanPlaylist(row)
+}
+
+// SavePlaylist creates (ID 0) or updates a playlist. Channels playing it pick the change up at the
+// next item boundary.
+func (s *Store) SavePlaylist(ctx context.Context, req PlaylistSaveRequest) (*Playlist, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("playlist name is required")
+	}
+	items := make([]PlaylistItem, 0, len(req.Items))
+	for idx, item := range req.Items {
+		material, err := s.GetMaterialByID(ctx, item.MaterialID)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: material %d not found", idx+1, item.MaterialID)
+		}
+		if material.FileType != FileTypeVideo {
+			return nil, fmt.Errorf("item %d: material %q is not a video", idx+1, material.Name)
+		}
+		if item.InPoint < 0 {
+			item.InPoint = 0
+		}
+		if item.OutPoint < 0 {
+			item.OutPoint = 0
+		}
+		if item.OutPoint > 0 && item.OutPoint <= item.InPoint {
+			return nil, fmt.Errorf("item %d: outPoint must be after inPoint", idx+1)
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("playlist needs at least one item")
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	mode := NormalizePlaylistMode(req.Mode)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	if req.ID > 0 {
+		result, err := s.db.ExecContext(ctx, `UPDATE playlists SET name = ?, mode = ?, items = ?, updated_at = ? WHERE id = ?`,
+			req.Name, string(mode), string(itemsJSON), now, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil, errors.New("playlist not found")
+		}
+		return s.GetPlaylistByID(ctx, req.ID)
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO playlists (name, mode, items, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		req.Name, string(mode), string(itemsJSON), now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPlaylistByID(ctx, id)
+}
+
+// DeletePlaylists removes playlists that no push channel still uses.
+func (s *Store) DeletePlaylists(ctx context.Context, ids []int64) (int64, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	var inUse int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM push_settings WHERE input_type = ? AND playlist_id IN (`+strings.Join(placeholders, ",")+`)`,
+		append([]any{string(InputTypePlaylist)}, args...)...).Scan(&inUse); err != nil {
+		return 0, err
+	}
+	if inUse > 0 {
+		return 0, errors.New("playlist is used by a push channel")
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM playlists WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanPlaylist(scanner interface{ Scan(dest ...any) error }) (*Playlist, error) {
+	item := Playlist{}
+	var mode, itemsRaw, createdAt, updatedAt string
+	if err := scanner.Scan(&item.ID, &item.Name, &mode, &itemsRaw, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	item.Mode = NormalizePlaylistMode(mode)
+	item.Items = []PlaylistItem{}
+	if err := json.Unmarshal([]byte(itemsRaw), &item.Items); err != nil {
+		item.Items = []PlaylistItem{}
+	}
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+func (s *Store) InsertPushMetricSummary(ctx context.Context, item PushMetricSummary) (int64, error) {
+	var sessionID any
+	if item.SessionID != nil && *item.SessionID > 0 {
+		sessionID = *item.SessionID
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO push_metric_summaries (
+		channel_id, session_id, started_at, ended_at, samples, avg_fps, min_fps, max_fps,
+		avg_bitrate_kbps, min_bitrate_kbps, avg_speed, min_speed, frames, dup_frames, drop_frames,
+		total_size, out_time_ms, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ChannelID,
+		sessionID,
+		item.StartedAt.UTC().Format(time.RFC3339Nano),
+		item.EndedAt.UTC().Format(time.RFC3339Nano),
+		item.Samples,
+		item.AvgFPS,
+		item.MinFPS,
+		item.MaxFPS,
+		item.AvgBitrateKbps,
+		item.MinBitrateKbps,
+		item.AvgSpeed,
+		item.MinSpeed,
+		item.Frames,
+		item.DupFrames,
+		item.DropFrames,
+		item.TotalSize,
+		item.OutTimeMS,
+		time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListPushMetricSummaries returns the newest run aggregates first; channelID <= 0 lists every channel.
+func (s *Store) ListPushMetricSummaries(ctx context.Context, channelID int64, limit int) ([]PushMetricSummary, error) {
+	if channelID > 0 {
+		return s.listPushMetricSummariesWhere(ctx, `channel_id = ?`, []any{channelID}, limit)
+	}
+	return s.listPushMetricSummariesWhere(ctx, "", nil, limit)
+}
+
+func (s *Store) listPushMetricSummariesWhere(ctx context.Context, filter string, args []any, limit int) ([]PushMetricSummary, error) {
+	limit = clampLimit(limit, 50, 1000)
+	query := `SELECT id, channel_id, session_id, started_at, ended_at, samples, avg_fps, min_fps, max_fps,
+		avg_bitrate_kbps, min_bitrate_kbps, avg_speed, min_speed, frames, dup_frames, drop_frames,
+		total_size, out_time_ms, created_at
+	FROM push_metric_summaries`
+	if filter != "" {
+		query += ` WHERE ` + filter
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]PushMetricSummary, 0, limit)
+	for rows.Next() {
+		item := PushMetricSummary{}
+		var sessionID sql.NullInt64
+		var startedAt, endedAt, createdAt string
+		if err := rows.Scan(
+			&item.ID,
+			&item.ChannelID,
+			&sessionID,
+			&startedAt,
+			&endedAt,
+			&item.Samples,
+			&item.AvgFPS,
+			&item.MinFPS,
+			&item.MaxFPS,
+			&item.AvgBitrateKbps,
+			&item.MinBitrateKbps,
+			&item.AvgSpeed,
+			&item.MinSpeed,
+			&item.Frames,
+			&item.DupFrames,
+			&item.DropFrames,
+			&item.TotalSize,
+			&item.OutTimeMS,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		if sessionID.Valid {
+			item.SessionID = &sessionID.Int64
+		}
+		item.StartedAt = parseSQLiteTime(startedAt)
+		item.EndedAt = parseSQLiteTime(endedAt)
+		item.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) CreateStreamSession(ctx context.Context, item StreamSession) (int64, error) {
+	startedAt := item.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO stream_sessions (
+		channel_id, room_id, started_at, status, input_type, output_resolution, output_bitrate_kbps
+	) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		item.ChannelID,
+		item.RoomID,
+		startedAt.UTC().Format(time.RFC3339Nano),
+		string(StreamSessionRunning),
+		string(item.InputType),
+		item.OutputResolution,
+		item.OutputBitrateKbps,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// FinishStreamSession closes a running session; exitCode is nil when ffmpeg never reported one.
+func (s *Store) FinishStreamSession(ctx context.Context, id int64, status StreamSessionStatus, exitCode *int, failureClass PushFailureClass, note string) error {
+	var exitValue any
+	if exitCode != nil {
+		exitValue = *exitCode
+	}
+	if len(note) > 2000 {
+		note = note[:2000]
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE stream_sessions SET ended_at=?, status=?, exit_code=?, failure_class=?, note=? WHERE id=?`,
+		time.Now().UTC().Format(time.RFC3339Nano), string(status), exitValue, string(failureClass), note, id)
+	return err
+}
+
+// CloseOpenStreamSessions marks sessions left open by a crash or hard shutdown as interrupted.
+func (s *Store) CloseOpenStreamSessions(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE stream_sessions SET ended_at=?, status=? WHERE ended_at IS NULL`,
+		time.Now().UTC().Format(time.RFC3339Nano), string(StreamSessionInterrupted))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const streamSessionColumns = `id, channel_id, room_id, started_at, ended_at, status, exit_code, failure_class, note,
+	input_type, output_resolution, output_bitrate_kbps`
+
+func (s *Store) ListStreamSessions(ctx context.Context, req StreamSessionListRequest) (QueryPageModel[StreamSession], error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	req.Limit = clampLimit(req.Limit, 20, 200)
+
+	filter := "WHERE 1=1"
+	args := make([]any, 0, 2)
+	if req.ChannelID > 0 {
+		filter += " AND channel_id = ?"
+		args = append(args, req.ChannelID)
+	}
+	if status := strings.TrimSpace(req.Status); status != "" {
+		filter += " AND status = ?"
+		args = append(args, status)
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM stream_sessions "+filter, args...).Scan(&total); err != nil {
+		return QueryPageModel[StreamSession]{}, err
+	}
+	offset := (req.Page - 1) * req.Limit
+	args = append(args, req.Limit, offset)
+	rows, err := s.db.QueryContext(ctx, `SELECT `+streamSessionColumns+` FROM stream_sessions `+filter+` ORDER BY id DESC LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return QueryPageModel[StreamSession]{}, err
+	}
+	defer rows.Close()
+	items := make([]StreamSession, 0, req.Limit)
+	for rows.Next() {
+		item, scanErr := scanStreamSession(rows)
+		if scanErr != nil {
+			return QueryPageModel[StreamSession]{}, scanErr
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return QueryPageModel[StreamSession]{}, err
+	}
+	return QueryPageModel[StreamSession]{
+		Page:      req.Page,
+		PageCount: len(items),
+		DataCount: total,
+		PageSize:  req.Limit,
+		Data:      items,
+	}, nil
+}
+
+// GetStreamSessionByID returns a session together with the metric summary of its run, if any, and
+// what its room received while it ran.
+func (s *Store) GetStreamSessionByID(ctx context.Context, id int64) (*StreamSession, error) {
+	if id <= 0 {
+		return nil, errors.New("stream session id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+streamSessionColumns+` FROM stream_sessions WHERE id = ?`, id)
+	item, err := scanStreamSession(row)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := s.listPushMetricSummariesWhere(ctx, `session_id = ?`, []any{id}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(summaries) > 0 {
+		item.Metrics = &summaries[0]
+	}
+	if item.RoomID > 0 {
+		end := time.Time{}
+		if item.EndedAt != nil {
+			end = *item.EndedAt
+		}
+		revenue, err := s.LiveRevenueBetween(ctx, item.RoomID, item.StartedAt, end)
+		if err != nil {
+			return nil, err
+		}
+		item.Revenue = &revenue
+	}
+	return item, nil
+}
+
+func scanStreamSession(scanner interface{ Scan(dest ...any) error }) (*StreamSession, error) {
+	item := StreamSession{}
+	var startedAt string
+	var endedAt sql.NullString
+	var exitCode sql.NullInt64
+	var status, failureClass, inputType string
+	if err := scanner.Scan(
+		&item.ID,
+		&item.ChannelID,
+		&item.RoomID,
+		&startedAt,
+		&endedAt,
+		&status,
+		&exitCode,
+		&failureClass,
+		&item.Note,
+		&inputType,
+		&item.OutputResolution,
+		&item.OutputBitrateKbps,
+	); err != nil {
+		return nil, err
+	}
+	item.StartedAt = parseSQLiteTime(startedAt)
+	item.Status = StreamSessionStatus(status)
+	item.FailureClass = PushFailureClass(failureClass)
+	item.InputType = InputType(inputType)
+	if exitCode.Valid {
+		value := int(exitCode.Int64)
+		item.ExitCode = &value
+	}
+	end := time.Now()
+	if endedAt.Valid && strings.TrimSpace(endedAt.String) != "" {
+		parsed := parseSQLiteTime(endedAt.String)
+		item.EndedAt = &parsed
+		end = parsed
+	}
+	if !item.StartedAt.IsZero() && end.After(item.StartedAt) {
+		item.DurationSec = int64(end.Sub(item.StartedAt) / time.Second)
+	}
+	return &item, nil
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const recordingColumns = `id, channel_id, session_id, file_name, path, format, size_bytes, started_at, ended_at, created_at`
+
+// CreateRecording indexes a finished DVR segment; a path that is already indexed is left alone.
+func (s *Store) CreateRecording(ctx context.Context, item Recording) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO recordings (
+		channel_id, session_id, file_name, path, format, size_bytes, started_at, ended_at, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ChannelID,
+		item.SessionID,
+		item.FileName,
+		item.Path,
+		item.Format,
+		item.SizeBytes,
+		item.StartedAt.UTC().Format(time.RFC3339Nano),
+		item.EndedAt.UTC().Format(time.RFC3339Nano),
+		time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	return err
+}
+
+func (s *Store) ListRecordings(ctx context.Context, req RecordingListRequest) (QueryPageModel[Recording], error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	req.Limit = clampLimit(req.Limit, 20, 200)
+
+	filter := "WHERE 1=1"
+	args := make([]any, 0, 2)
+	if req.ChannelID > 0 {
+		filter += " AND channel_id = ?"
+		args = append(args, req.ChannelID)
+	}
+	if req.SessionID > 0 {
+		filter += " AND session_id = ?"
+		args = append(args, req.SessionID)
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM recordings "+filter, args...).Scan(&total); err != nil {
+		return QueryPageModel[Recording]{}, err
+	}
+	offset := (req.Page - 1) * req.Limit
+	items, err := s.listRecordingsWhere(ctx, filter+" ORDER BY started_at DESC, id DESC LIMIT ? OFFSET ?", append(args, req.Limit, offset)...)
+	if err != nil {
+		return QueryPageModel[Recording]{}, err
+	}
+	return QueryPageModel[Recording]{
+		Page:      req.Page,
+		PageCount: len(items),
+		DataCount: total,
+		PageSize:  req.Limit,
+		Data:      items,
+	}, nil
+}
+
+func (s *Store) GetRecordingByID(ctx context.Context, id int64) (*Recording, error) {
+	if id <= 0 {
+		return nil, errors.New("recording id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+recordingColumns+` FROM recordings WHERE id = ?`, id)
+	return scanRecording(row)
+}
+
+// DeleteRecordings removes the rows and returns them so the caller can delete the files.
+func (s *Store) DeleteRecordings(ctx context.Context, ids []int64) ([]Recording, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return []Recording{}, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	where := `WHERE id IN (` + strings.Join(placeholders, ",") + `)`
+	items, err := s.listRecordingsWhere(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM recordings `+where, args...); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListRecordingsEndedBefore returns up to limit segments that ended before cutoff, oldest first.
+func (s *Store) ListRecordingsEndedBefore(ctx context.Context, cutoff time.Time, limit int) ([]Recording, error) {
+	return s.listRecordingsWhere(ctx, `WHERE datetime(ended_at) < datetime(?) ORDER BY started_at ASC, id ASC LIMIT ?`,
+		cutoff.UTC().Format(time.RFC3339Nano), limit)
+}
+
+// ListOldestRecordings returns up to limit segments, oldest first.
+func (s *Store) ListOldestRecordings(ctx context.Context, limit int) ([]Recording, error) {
+	return s.listRecordingsWhere(ctx, `ORDER BY started_at ASC, id ASC LIMIT ?`, limit)
+}
+
+// RecordingsTotalSize sums the size of every indexed segment.
+func (s *Store) RecordingsTotalSize(ctx context.Context) (int64, error) {
+	var total int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(size_bytes), 0) FROM recordings`).Scan(&total)
+	return total, err
+}
+
+func (s *Store) listRecordingsWhere(ctx context.Context, clause string, args ...any) ([]Recording, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+recordingColumns+` FROM recordings `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Recording, 0)
+	for rows.Next() {
+		item, scanErr := scanRecording(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func scanRecording(scanner interface{ Scan(dest ...any) error }) (*Recording, error) {
+	item := Recording{}
+	var startedAt, endedAt, createdAt string
+	if err := scanner.Scan(
+		&item.ID,
+		&item.ChannelID,
+		&item.SessionID,
+		&item.FileName,
+		&item.Path,
+		&item.Format,
+		&item.SizeBytes,
+		&startedAt,
+		&endedAt,
+		&createdAt,
+	); err != nil {
+		return nil, err
+	}
+	item.StartedAt = parseSQLiteTime(startedAt)
+	item.EndedAt = parseSQLiteTime(endedAt)
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	return &item, nil
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const sceneColumns = `id, channel_id, name, layout, camera_id, video_material_id, sources, min_dwell_sec, slate_sec,
+	created_at, updated_at`
+
+// ErrSceneNotFound is returned when a scene looked up by id or name does not exist.
+var ErrSceneNotFound = errors.New("scene not found")
+
+// sceneLayoutPattern matches the mosaic layout names of push settings, e.g. 2x2, focus-left, canvas.
+var sceneLayoutPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// ListScenes returns the scenes of one channel (channelID > 0) or of all channels.
+func (s *Store) ListScenes(ctx context.Context, channelID int64) ([]Scene, error) {
+	query := `SELECT ` + sceneColumns + ` FROM scenes`
+	args := make([]any, 0, 1)
+	if channelID > 0 {
+		query += ` WHERE channel_id = ?`
+		args = append(args, channelID)
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY channel_id ASC, id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Scene, 0)
+	for rows.Next() {
+		item, scanErr := scanScene(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetSceneByID(ctx context.Context, id int64) (*Scene, error) {
+	if id <= 0 {
+		return nil, errors.New("scene id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+sceneColumns+` FROM scenes WHERE id = ?`, id)
+	return scanScene(row)
+}
+
+// FindSceneByName looks a scene of a channel up by name, ignoring case, for chat and bot commands.
+func (s *Store) FindSceneByName(ctx context.Context, channelID int64, name string) (*Scene, error) {
+	channel, err := s.GetPushSettingByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+sceneColumns+` FROM scenes WHERE channel_id = ? AND name = ? COLLATE NOCASE`,
+		channel.ID, strings.TrimSpace(name))
+	item, err := scanScene(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %q", ErrSceneNotFound, strings.TrimSpace(name))
+	}
+	return item, err
+}
+
+// SaveScene creates (ID 0) or updates a scene. A zero ChannelID binds it to the default channel.
+func (s *Store) SaveScene(ctx context.Context, req Scene) (*Scene, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("scene name is required")
+	}
+	channel, err := s.GetPushSettingByID(ctx, req.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	req.Layout = strings.ToLower(strings.TrimSpace(req.Layout))
+	if req.Layout == "" {
+		req.Layout = SceneLayoutSingle
+	}
+	if !sceneLayoutPattern.MatchString(req.Layout) {
+		return nil, errors.New("invalid scene layout")
+	}
+	if req.Layout == SceneLayoutSingle {
+		req.Sources = []MultiInputSource{}
+		switch {
+		case req.CameraID > 0 && req.VideoMaterialID > 0:
+			return nil, errors.New("single scene takes either cameraId or videoMaterialId")
+		case req.CameraID > 0:
+			if _, err := s.GetCameraSourceByID(ctx, req.CameraID); err != nil {
+				return nil, fmt.Errorf("camera %d not found", req.CameraID)
+			}
+		case req.VideoMaterialID > 0:
+			material, err := s.GetMaterialByID(ctx, req.VideoMaterialID)
+			if err != nil {
+				return nil, fmt.Errorf("video material %d not found", req.VideoMaterialID)
+			}
+			if material.FileType != FileTypeVideo {
+				return nil, errors.New("scene material must be a video")
+			}
+		default:
+			return nil, errors.New("single scene needs cameraId or videoMaterialId")
+		}
+	} else {
+		req.CameraID = 0
+		req.VideoMaterialID = 0
+		req.Sources = normalizeMultiInputMeta(req.Sources, nil, 9)
+		if len(req.Sources) < 2 {
+			return nil, errors.New("mosaic scene needs at least 2 sources")
+		}
+		if req.Sources, err = s.ResolveMultiInputSources(ctx, req.Sources); err != nil {
+			return nil, err
+		}
+	}
+	if req.MinDwellSec < 0 || req.MinDwellSec > 3600 {
+		return nil, errors.New("minDwellSec must be between 0 and 3600")
+	}
+	if req.SlateSec < 0 || req.SlateSec > 30 {
+		return nil, errors.New("slateSec must be between 0 and 30")
+	}
+	sourcesJSON, err := json.Marshal(req.Sources)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	args := []any{
+		channel.ID, req.Name, req.Layout, req.CameraID, req.VideoMaterialID, string(sourcesJSON), req.MinDwellSec,
+		req.SlateSec, now,
+	}
+
+	if req.ID > 0 {
+		result, err := s.db.ExecContext(ctx, `UPDATE scenes SET
+			channel_id = ?, name = ?, layout = ?, camera_id = ?, video_material_id = ?, sources = ?, min_dwell_sec = ?,
+			slate_sec = ?, updated_at = ?
+		WHERE id = ?`, append(args, req.ID)...)
+		if err != nil {
+			return nil, sceneSaveError(err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil, errors.New("scene not found")
+		}
+		return s.GetSceneByID(ctx, req.ID)
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO scenes (
+		channel_id, name, layout, camera_id, video_material_id, sources, min_dwell_sec, slate_sec, updated_at, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, append(args, now)...)
+	if err != nil {
+		return nil, sceneSaveError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSceneByID(ctx, id)
+}
+
+func sceneSaveError(err error) error {
+	if strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return errors.New("scene name already exists on this channel")
+	}
+	return err
+}
+
+// DeleteScenes removes scenes; danmaku rules pointing at them fail until they are changed.
+func (s *Store) DeleteScenes(ctx context.Context, ids []int64) (int64, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM scenes WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ApplySceneToUpdateRequest points the input of a push setting update at the scene: the camera or
+// video material of a single scene, or the mosaic of the others.
+func ApplySceneToUpdateRequest(req *PushSettingUpdateRequest, scene *Scene, camera *CameraSource) error {
+	if scene.Layout != SceneLayoutSingle {
+		urls := make([]string, 0, len(scene.Sources))
+		for _, source := range scene.Sources {
+			if source.URL != "" {
+				urls = append(urls, source.URL)
+			}
+		}
+		req.MultiInputEnabled = true
+		req.MultiInputLayout = scene.Layout
+		req.MultiInputURLs = urls
+		req.MultiInputMeta = append([]MultiInputSource(nil), scene.Sources...)
+		return nil
+	}
+	req.MultiInputEnabled = false
+	if camera != nil {
+		return ApplyCameraSourceToUpdateRequest(req, camera)
+	}
+	if scene.VideoMaterialID <= 0 {
+		return errors.New("scene has no camera or video material")
+	}
+	req.InputType = string(InputTypeVideo)
+	req.VideoID = scene.VideoMaterialID
+	return nil
+}
+
+func scanScene(scanner interface{ Scan(dest ...any) error }) (*Scene, error) {
+	item := Scene{}
+	var sourcesRaw, createdAt, updatedAt string
+	if err := scanner.Scan(
+		&item.ID,
+		&item.ChannelID,
+		&item.Name,
+		&item.Layout,
+		&item.CameraID,
+		&item.VideoMaterialID,
+		&sourcesRaw,
+		&item.MinDwellSec,
+		&item.SlateSec,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	item.Sources = parseMultiInputMeta(sourcesRaw, nil)
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const pushScheduleColumns = `id, name, enabled, channel_id, action, cron_expr, windows, timezone, params,
+	skip_dates, skip_count, last_run_at, created_at, updated_at`
+
+func (s *Store) ListPushSchedules(ctx context.Context) ([]PushSchedule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+pushScheduleColumns+` FROM push_schedules ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]PushSchedule, 0)
+	for rows.Next() {
+		item, scanErr := scanPushSchedule(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetPushScheduleByID(ctx context.Context, id int64) (*PushSchedule, error) {
+	if id <= 0 {
+		return nil, errors.New("schedule id must be greater than zero")
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+pushScheduleColumns+` FROM push_schedules WHERE id = ?`, id)
+	return scanPushSchedule(row)
+}
+
+// SavePushSchedule creates (ID 0) or updates a schedule. Trigger expressions are validated by the
+// schedule service before they get here.
+func (s *Store) SavePushSchedule(ctx context.Context, req PushScheduleSaveRequest) (*PushSchedule, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.CronExpr = strings.TrimSpace(req.CronExpr)
+	req.Timezone = strings.TrimSpace(req.Timezone)
+	action := NormalizePushScheduleAction(req.Action)
+	if req.Name == "" {
+		return nil, errors.New("schedule name is required")
+	}
+	if action == "" {
+		return nil, errors.New("unsupported schedule action")
+	}
+	if req.CronExpr == "" && len(req.Windows) == 0 {
+		return nil, errors.New("schedule needs a cron expression or at least one weekly window")
+	}
+	if req.SkipCount < 0 {
+		req.SkipCount = 0
+	}
+	windowsJSON, err := json.Marshal(normalizePushScheduleWindows(req.Windows))
+	if err != nil {
+		return nil, err
+	}
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, err
+	}
+	skipDatesJSON, err := json.Marshal(normalizeScheduleDates(req.SkipDates))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	if req.ID > 0 {
+		if _, getErr := s.GetPushScheduleByID(ctx, req.ID); getErr != nil {
+			return nil, getErr
+		}
+		_, err = s.db.ExecContext(ctx, `UPDATE push_schedules SET
+			name = ?, enabled = ?, channel_id = ?, action = ?, cron_expr = ?, windows = ?, timezone = ?,
+			params = ?, skip_dates = ?, skip_count = ?, updated_at = ?
+		WHERE id = ?`,
+			req.Name, boolToInt(req.Enabled), req.ChannelID, string(action), req.CronExpr, string(windowsJSON), req.Timezone,
+			string(paramsJSON), string(skipDatesJSON), req.SkipCount, now, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		return s.GetPushScheduleByID(ctx, req.ID)
+	}
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO push_schedules (
+		name, enabled, channel_id, action, cron_expr, windows, timezone, params, skip_dates, skip_count, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		req.Name, boolToInt(req.Enabled), req.ChannelID, string(action), req.CronExpr, string(windowsJSON), req.Timezone,
+		string(paramsJSON), string(skipDatesJSON), req.SkipCount, now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPushScheduleByID(ctx, id)
+}
+
+func (s *Store) DeletePushSchedules(ctx context.Context, ids []int64) (int64, error) {
+	keys := dedupPositiveIDs(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, id := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM push_schedules WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// MarkPushScheduleRun stores the last firing time and the remaining number of runs to skip.
+func (s *Store) MarkPushScheduleRun(ctx context.Context, id int64, runAt time.Time, skipCount int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE push_schedules SET last_run_at = ?, skip_count = ? WHERE id = ?`,
+		runAt.UTC().Format(time.RFC3339Nano), skipCount, id)
+	return err
+}
+
+// PushScheduleRunEvent is the live event type every schedule firing is recorded as.
+const PushScheduleRunEvent = "schedule.run"
+
+// ListPushScheduleRuns returns the newest run records, newest first, of one schedule and/or one
+// channel when the ids are positive.
+func (s *Store) ListPushScheduleRuns(ctx context.Context, scheduleID int64, channelID int64, limit int) ([]PushScheduleRun, error) {
+	limit = clampInt(limit, 1, 1000, 50)
+	query := `SELECT payload FROM live_events WHERE event_type = ?`
+	args := []any{PushScheduleRunEvent}
+	if scheduleID > 0 {
+		query += ` AND json_extract(payload, '$.scheduleId') = ?`
+		args = append(args, scheduleID)
+	}
+	if channelID > 0 {
+		query += ` AND json_extract(payload, '$.channelId') = ?`
+		args = append(args, channelID)
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]PushScheduleRun, 0, limit)
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var run PushScheduleRun
+		if err := json.Unmarshal([]byte(payload), &run); err != nil {
+			continue
+		}
+		items = append(items, run)
+	}
+	return items, rows.Err()
+}
+
+func scanPushSchedule(scanner interface{ Scan(dest ...any) error }) (*PushSchedule, error) {
+	item := PushSchedule{}
+	var enabled int
+	var action, windowsRaw, paramsRaw, skipDatesRaw string
+	var lastRunAt sql.NullString
+	var createdAt, updatedAt string
+	if err := scanner.Scan(
+		&item.ID,
+		&item.Name,
+		&enabled,
+		&item.ChannelID,
+		&action,
+		&item.CronExpr,
+		&windowsRaw,
+		&item.Timezone,
+		&paramsRaw,
+		&skipDatesRaw,
+		&item.SkipCount,
+		&lastRunAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	item.Enabled = enabled == 1
+	item.Action = PushScheduleAction(action)
+	item.Windows = []PushScheduleWindow{}
+	if err := json.Unmarshal([]byte(windowsRaw), &item.Windows); err != nil {
+		item.Windows = []PushScheduleWindow{}
+	}
+	_ = json.Unmarshal([]byte(paramsRaw), &item.Params)
+	item.SkipDates = []string{}
+	if err := json.Unmarshal([]byte(skipDatesRaw), &item.SkipDates); err != nil {
+		item.SkipDates = []string{}
+	}
+	if lastRunAt.Valid && strings.TrimSpace(lastRunAt.String) != "" {
+		parsed := parseSQLiteTime(lastRunAt.String)
+		item.LastRunAt = &parsed
+	}
+	item.CreatedAt = parseSQLiteTime(createdAt)
+	item.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &item, nil
+}
+
+func normalizePushScheduleWindows(items []PushScheduleWindow) []PushScheduleWindow {
+	result := make([]PushScheduleWindow, 0, len(items))
+	for _, item := range items {
+		days := make([]int, 0, len(item.Days))
+		seen := make(map[int]bool, 7)
+		for _, day := range item.Days {
+			if day < 0 || day > 6 || seen[day] {
+				continue
+			}
+			seen[day] = true
+			days = append(days, day)
+		}
+		item.Start = strings.TrimSpace(item.Start)
+		item.End = strings.TrimSpace(item.End)
+		if len(days) == 0 || item.Start == "" {
+			continue
+		}
+		item.Days = days
+		result = append(result, item)
+	}
+	return result
+}
+
+// normalizeScheduleDates keeps valid YYYY-MM-DD dates, each once.
+func normalizeScheduleDates(items []string) []string {
+	result := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if _, err := time.Parse("2006-01-02", item); err != nil || seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	return result
+}
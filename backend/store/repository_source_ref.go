@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ResolveMultiInputSources returns a copy of mosaic sources with the URL of every source that
+// references a camera source or a material filled from that record: the camera's current stream URL
+// or a media:// path of the material. Plain URL sources are kept as they are.
+func (s *Store) ResolveMultiInputSources(ctx context.Context, items []MultiInputSource) ([]MultiInputSource, error) {
+	resolved := make([]MultiInputSource, len(items))
+	copy(resolved, items)
+	for idx := range resolved {
+		item := &resolved[idx]
+		switch {
+		case item.CameraID > 0:
+			camera, err := s.GetCameraSourceByID(ctx, item.CameraID)
+			if err != nil {
+				return nil, fmt.Errorf("mosaic camera %d not found", item.CameraID)
+			}
+			streamURL := camera.StreamURL()
+			if streamURL == "" {
+				return nil, fmt.Errorf("camera %q has no stream url usable in a mosaic", camera.Name)
+			}
+			item.URL = streamURL
+			if item.Title == "" {
+				item.Title = camera.Name
+			}
+		case item.MaterialID > 0:
+			material, err := s.GetMaterialByID(ctx, item.MaterialID)
+			if err != nil {
+				return nil, fmt.Errorf("mosaic material %d not found", item.MaterialID)
+			}
+			item.URL = "media://" + material.Path
+			if item.Title == "" {
+				item.Title = material.Name
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// ResolveMosaicSources returns the setting with its mosaic sources resolved, see
+// ResolveMultiInputSources. The legacy URL list is rebuilt from them so an outdated camera URL stored
+// there does not come back as an extra source. Settings without a mosaic are returned unchanged.
+func (s *Store) ResolveMosaicSources(ctx context.Context, setting *PushSetting) (*PushSetting, error) {
+	if setting == nil || !setting.MultiInputEnabled {
+		return setting, nil
+	}
+	sources, err := s.ResolveMultiInputSources(ctx, setting.MultiInputMeta)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *setting
+	resolved.MultiInputMeta = sources
+	resolved.MultiInputURLs = make([]string, 0, len(sources))
+	for _, source := range sources {
+		if source.URL != "" {
+			resolved.MultiInputURLs = append(resolved.MultiInputURLs, source.URL)
+		}
+	}
+	return &resolved, nil
+}
+
+// CommandCameraIDs returns the camera source ids an advanced-mode command references as {{camera N}}.
+// Command templates are parsed by the stream package, which sets it; while nil, commands reference
+// nothing.
+var CommandCameraIDs func(cmdLine string) []int64
+
+// CameraSourceUsage lists the records that use a camera source.
+func (s *Store) CameraSourceUsage(ctx context.Context, cameraID int64) ([]CameraSourceReference, error) {
+	if _, err := s.GetCameraSourceByID(ctx, cameraID); err != nil {
+		return nil, err
+	}
+	references, err := s.cameraSourceReferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := references[cameraID]
+	if items == nil {
+		items = []CameraSourceReference{}
+	}
+	return items, nil
+}
+
+// cameraSourceReferences maps camera source ids to the channels, scenes and schedules using them.
+// Mosaic sources live in JSON columns, so the records are scanned rather than queried.
+func (s *Store) cameraSourceReferences(ctx context.Context) (map[int64][]CameraSourceReference, error) {
+	references := make(map[int64][]CameraSourceReference)
+	add := func(cameraID int64, ref CameraSourceReference) {
+		if cameraID <= 0 {
+			return
+		}
+		for _, existing := range references[cameraID] {
+			if existing == ref {
+				return
+			}
+		}
+		references[cameraID] = append(references[cameraID], ref)
+	}
+
+	settings, err := s.ListPushSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range settings {
+		for _, source := range setting.MultiInputMeta {
+			add(source.CameraID, CameraSourceReference{Kind: CameraReferenceMosaic, ID: setting.ID, Name: setting.Name, ChannelID: setting.ID})
+		}
+		add(setting.Failover.BackupCameraID, CameraSourceReference{Kind: CameraReferenceFailover, ID: setting.ID, Name: setting.Name, ChannelID: setting.ID})
+		if setting.Model == ConfigModelAdvance && CommandCameraIDs != nil {
+			for _, id := range CommandCameraIDs(setting.FFmpegCommand) {
+				add(id, CameraSourceReference{Kind: CameraReferenceCommand, ID: setting.ID, Name: setting.Name, ChannelID: setting.ID})
+			}
+		}
+	}
+
+	scenes, err := s.ListScenes(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, scene := range scenes {
+		ref := CameraSourceReference{Kind: CameraReferenceScene, ID: scene.ID, Name: scene.Name, ChannelID: scene.ChannelID}
+		add(scene.CameraID, ref)
+		for _, source := range scene.Sources {
+			add(source.CameraID, ref)
+		}
+	}
+
+	schedules, err := s.ListPushSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules {
+		if schedule.Action == PushScheduleActionSwitchCamera {
+			add(schedule.Params.CameraID, CameraSourceReference{Kind: CameraReferenceSchedule, ID: schedule.ID, Name: schedule.Name, ChannelID: schedule.ChannelID})
+		}
+	}
+	return references, nil
+}
+
+// ensureCameraSourcesUnused fails when any of the camera sources is still referenced, naming the users.
+func (s *Store) ensureCameraSourcesUnused(ctx context.Context, ids []int64) error {
+	references, err := s.cameraSourceReferences(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		items := references[id]
+		if len(items) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(items))
+		for _, item := range items {
+			names = append(names, fmt.Sprintf("%s %q", item.Kind, item.Name))
+		}
+		return fmt.Errorf("camera %d is still used by %s", id, strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -317,6 +317,7 @@ const ADVANCED_EXPORT_PRESETS = {
   basic: "totals,hourlyEvents,hourlyDanmaku,eventTypeTop",
   ops: "totals,hourlyEvents,hourlyDanmaku,eventTypeTop,keywordStats,sessionStats,queueSummary,consumerState",
   alerts: "totals,alertTrend,eventTypeTop,deadLetter",
+  revenue: "totals,revenue,revenueDaily,revenueBySession,topSupporters,giftBreakdown,superChats,guardHistory",
 };
 
 function normalizeRuntimeConfigResult(result) {
@@ -387,6 +388,7 @@ function normalizeMosaicSource(item = {}) {
     title: String(item.title || "").trim(),
     primary: Boolean(item.primary),
     sourceType: String(item.sourceType || "").trim() || "manual",
+    cameraId: Number(item.cameraId || 0) || 0,
     materialId: Number(item.materialId || 0) || 0,
   };
 }
@@ -504,6 +506,9 @@ function renderMosaicSources() {
           <label>素材ID(可选)
             <input data-field="materialId" type="number" value="${source.materialId || 0}" />
           </label>
+          <label>摄像头ID(可选，按摄像头库最新地址拉流)
+            <input data-field="cameraId" type="number" value="${source.cameraId || 0}" />
+          </label>
         </div>
         <div class="actions">
           <button data-action="primary">${source.primary ? "主画面" : "设为主画面"}</button>
@@ -537,7 +542,7 @@ function renderMosaicSources() {
         if (!field) return;
         const next = [...mosaicSources];
         if (!next[index]) return;
-        if (field === "materialId") {
+        if (field === "materialId" || field === "cameraId") {
           next[index][field] = Number(input.value || 0) || 0;
         } else {
           next[index][field] = (input.value || "").trim();
@@ -1369,6 +1374,7 @@ function bindActions() {
         title: option.dataset.title || option.textContent || "",
         primary: next.length === 0,
         sourceType: option.dataset.sourceType || "camera",
+        cameraId: Number(option.dataset.cameraId || 0) || 0,
         materialId: 0,
       });
       if (next.length >= 9) break;
@@ -1410,7 +1416,9 @@ function bindActions() {
   document.getElementById("btnListApiKey").onclick = withError("integrationBox", refreshApiKeys);
   document.getElementById("btnSaveRule").onclick = withError("integrationBox", async () => {
     const payload = {
+      eventType: asString("ruleEventType"),
       keyword: asString("ruleKeyword"),
+      minGold: asNumber("ruleMinGold", 0),
       action: asString("ruleAction"),
       ptzDirection: asString("ruleDirection"),
       ptzSpeed: asNumber("ruleSpeed", 1),
@@ -1428,6 +1436,7 @@ function bindActions() {
       name: asString("webhookName"),
       url: asString("webhookUrl"),
       secret: document.getElementById("webhookSecret").value || "",
+      events: asString("webhookEvents").split(",").map((item) => item.trim()).filter(Boolean),
       enabled: asString("webhookEnabled") === "true",
     };
     const result = await apiPost("/api/v1/integration/webhooks", payload);